@@ -0,0 +1,368 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/ttpr0/go-routing/attr"
+	"github.com/ttpr0/go-routing/batched/onetomany"
+	"github.com/ttpr0/go-routing/geo"
+	. "github.com/ttpr0/go-routing/util"
+	"golang.org/x/exp/slog"
+)
+
+//**********************************************************
+// accessibility request and response
+//**********************************************************
+
+type AccessibilityRequest struct {
+	SupplyLocations Array[geo.Coord] `json:"supply_locations"`
+	// capacity of every supply location (defaults to 1)
+	SupplyWeights   Array[float32]   `json:"supply_weights"`
+	DemandLocations Array[geo.Coord] `json:"demand_locations"`
+	// population of every demand location (defaults to 1)
+	DemandWeights Array[float32] `json:"demand_weights"`
+	// one of ["2sfca", "e2sfca", "gravity", "cumulative"]
+	Measure       string              `json:"measure"`
+	Decay         DecayRequest        `json:"decay"`
+	Profile       string              `json:"profile"`
+	Metric        string              `json:"metric"`
+	TimeWindow    [2]int32            `json:"time_window"`
+	ScheduleDay   string              `json:"schedule_day"`
+	Scenario      string              `json:"scenario"`
+	AvoidRoads    []attr.RoadType     `json:"avoid_roads"`
+	AvoidFeatures []attr.AvoidFeature `json:"avoid_features"`
+	AvoidArea     geo.Feature         `json:"avoid_area"`
+}
+
+type DecayRequest struct {
+	// one of ["linear", "gaussian", "exponential", "step"]
+	Type     string `json:"type"`
+	MaxRange int32  `json:"max_range"`
+	// upper bounds of the steps (only used by step decay)
+	Ranges []int32 `json:"ranges"`
+	// weights of the steps or the decay parameter of the exponential decay
+	Factors []float32 `json:"factors"`
+}
+
+type AccessibilityResponse struct {
+	// accessibility score of every demand location (-1 if the location could not be mapped to the graph)
+	Access Array[float32] `json:"access"`
+}
+
+//**********************************************************
+// accessibility handler
+//**********************************************************
+
+func HandleAccessibilityRequest(req AccessibilityRequest) Result {
+	slog.Info("Run Accessibility Request")
+
+	task_, res := PrepareAccessibilityTask(req)
+	if !task_.HasValue() {
+		return res
+	}
+	task := task_.Value
+	workers := _GetMatrixWorkerCount(MANAGER._GetServiceConfig(), task.supply_nodes.Length())
+	access, err := CalcAccessibility(task, workers, nil)
+	if err != nil {
+		return BadRequest(err.Error())
+	}
+
+	resp := AccessibilityResponse{Access: access}
+	slog.Info("Accessibility reponse build")
+	return OK(resp)
+}
+
+//**********************************************************
+// accessibility utilities
+//**********************************************************
+
+// Mapped nodes, weights and one-to-many algorithm of an accessibility request.
+type AccessibilityTask struct {
+	otm            onetomany.IOneToMany
+	measure        string
+	decay          IDistanceDecay
+	supply_nodes   Array[int32]
+	demand_nodes   Array[int32]
+	supply_weights Array[float32]
+	demand_weights Array[float32]
+}
+
+// Returns the number of rows (one per supply location) computed by the measure, the 2sfca-measures pass over the supply locations twice.
+func _GetAccessibilityRowCount(measure string, supply_count int) int32 {
+	switch measure {
+	case "2sfca", "e2sfca":
+		return 2 * int32(supply_count)
+	default:
+		return int32(supply_count)
+	}
+}
+
+// Validates the request, maps the locations to nodes and selects the algorithm used to compute the distances.
+func PrepareAccessibilityTask(req AccessibilityRequest) (Optional[AccessibilityTask], Result) {
+	decay_, err := NewDistanceDecay(req.Decay)
+	if err != nil {
+		return None[AccessibilityTask](), BadRequest(err.Error())
+	}
+	if req.SupplyWeights != nil && req.SupplyWeights.Length() != req.SupplyLocations.Length() {
+		return None[AccessibilityTask](), BadRequest("supply_weights and supply_locations differ in length")
+	}
+	if req.DemandWeights != nil && req.DemandWeights.Length() != req.DemandLocations.Length() {
+		return None[AccessibilityTask](), BadRequest("demand_weights and demand_locations differ in length")
+	}
+	// the classic measures use binary catchments instead of the requested decay
+	var decay IDistanceDecay
+	switch req.Measure {
+	case "2sfca", "cumulative":
+		decay = &BinaryDecay{max_range: decay_.GetMaxDistance()}
+	case "e2sfca", "gravity":
+		decay = decay_
+	default:
+		return None[AccessibilityTask](), BadRequest("Invalid accessibility measure")
+	}
+
+	// get profile
+	profile_, res := GetRequestProfile(MANAGER, req.Profile, req.Metric)
+	if !profile_.HasValue() {
+		return None[AccessibilityTask](), res
+	}
+	profile := profile_.Value
+	// map coords to nodes
+	att := profile.GetAttributes()
+	supply_nodes := MapCoordsToNodes(att, req.SupplyLocations)
+	demand_nodes := MapCoordsToNodes(att, req.DemandLocations)
+
+	// get graph
+	otm, res := GetMatrixOneToMany(profile, MatrixRequest{
+		TimeWindow:    req.TimeWindow,
+		ScheduleDay:   req.ScheduleDay,
+		Scenario:      req.Scenario,
+		AvoidRoads:    req.AvoidRoads,
+		AvoidFeatures: req.AvoidFeatures,
+		AvoidArea:     req.AvoidArea,
+	}, demand_nodes, decay.GetMaxDistance())
+	if !otm.HasValue() {
+		return None[AccessibilityTask](), res
+	}
+	return Some(AccessibilityTask{
+		otm:            otm.Value,
+		measure:        req.Measure,
+		decay:          decay,
+		supply_nodes:   supply_nodes,
+		demand_nodes:   demand_nodes,
+		supply_weights: _GetWeightsOrDefault(req.SupplyWeights, req.SupplyLocations.Length()),
+		demand_weights: _GetWeightsOrDefault(req.DemandWeights, req.DemandLocations.Length()),
+	}), OK("")
+}
+
+// Computes the accessibility of every demand location (-1 if the location could not be mapped to the graph).
+//
+// Distances are consumed row by row from the solvers, the full matrix is never stored.
+// If given, on_row is called after every row (see _GetAccessibilityRowCount) and aborts the computation by returning an error.
+func CalcAccessibility(task AccessibilityTask, workers int, on_row func() error) (Array[float32], error) {
+	otm := task.otm
+	decay := task.decay
+	supply_nodes := task.supply_nodes
+	demand_nodes := task.demand_nodes
+	supply_weights := task.supply_weights
+	demand_weights := task.demand_weights
+	max_range := decay.GetMaxDistance()
+	if on_row == nil {
+		on_row = func() error { return nil }
+	}
+
+	access := NewArray[float32](demand_nodes.Length())
+	switch task.measure {
+	case "2sfca", "e2sfca":
+		// step 1: supply to demand ratio of every supply location
+		ratios := NewArray[float32](supply_nodes.Length())
+		err := CalcMatrixRows(otm, supply_nodes, demand_nodes, max_range, workers, func(s int, row Array[float32]) error {
+			demand := float32(0)
+			for d, dist := range row {
+				if dist < 0 {
+					continue
+				}
+				demand += demand_weights[d] * decay.GetDistanceWeight(int32(dist))
+			}
+			if demand > 0 {
+				ratios[s] = supply_weights[s] / demand
+			}
+			return on_row()
+		})
+		if err != nil {
+			return nil, err
+		}
+		// step 2: sum of weighted ratios reachable from every demand location
+		err = CalcMatrixRows(otm, supply_nodes, demand_nodes, max_range, workers, func(s int, row Array[float32]) error {
+			if ratios[s] == 0 {
+				return on_row()
+			}
+			for d, dist := range row {
+				if dist < 0 {
+					continue
+				}
+				access[d] += ratios[s] * decay.GetDistanceWeight(int32(dist))
+			}
+			return on_row()
+		})
+		if err != nil {
+			return nil, err
+		}
+	case "gravity", "cumulative":
+		err := CalcMatrixRows(otm, supply_nodes, demand_nodes, max_range, workers, func(s int, row Array[float32]) error {
+			for d, dist := range row {
+				if dist < 0 {
+					continue
+				}
+				access[d] += supply_weights[s] * decay.GetDistanceWeight(int32(dist))
+			}
+			return on_row()
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for d, node := range demand_nodes {
+		if node == -1 {
+			access[d] = -1
+		}
+	}
+	return access, nil
+}
+
+func _GetWeightsOrDefault(weights Array[float32], count int) Array[float32] {
+	if weights != nil {
+		return weights
+	}
+	weights = NewArray[float32](count)
+	for i := 0; i < count; i++ {
+		weights[i] = 1
+	}
+	return weights
+}
+
+//**********************************************************
+// distance decay
+//**********************************************************
+
+type IDistanceDecay interface {
+	// Returns the weight of the distance (0 beyond the max distance).
+	GetDistanceWeight(dist int32) float32
+	// Returns the distance from which on all weights are 0.
+	GetMaxDistance() int32
+}
+
+func NewDistanceDecay(req DecayRequest) (IDistanceDecay, error) {
+	switch req.Type {
+	case "linear":
+		if req.MaxRange <= 0 {
+			return nil, errors.New("linear decay requires max_range")
+		}
+		return &LinearDecay{max_range: req.MaxRange}, nil
+	case "gaussian":
+		if req.MaxRange <= 0 {
+			return nil, errors.New("gaussian decay requires max_range")
+		}
+		return &GaussianDecay{max_range: req.MaxRange}, nil
+	case "exponential":
+		if req.MaxRange <= 0 {
+			return nil, errors.New("exponential decay requires max_range")
+		}
+		if len(req.Factors) != 1 {
+			return nil, errors.New("exponential decay requires a single factor")
+		}
+		return &ExponentialDecay{max_range: req.MaxRange, factor: req.Factors[0]}, nil
+	case "step":
+		if len(req.Ranges) == 0 || len(req.Ranges) != len(req.Factors) {
+			return nil, errors.New("step decay requires the same number of ranges and factors")
+		}
+		for i := 1; i < len(req.Ranges); i++ {
+			if req.Ranges[i] <= req.Ranges[i-1] {
+				return nil, errors.New("step decay requires ascending ranges")
+			}
+		}
+		return &StepDecay{ranges: req.Ranges, factors: req.Factors}, nil
+	default:
+		return nil, fmt.Errorf("unknown decay type %v", req.Type)
+	}
+}
+
+type BinaryDecay struct {
+	max_range int32
+}
+
+func (self *BinaryDecay) GetDistanceWeight(dist int32) float32 {
+	if dist > self.max_range {
+		return 0
+	}
+	return 1
+}
+func (self *BinaryDecay) GetMaxDistance() int32 {
+	return self.max_range
+}
+
+type LinearDecay struct {
+	max_range int32
+}
+
+func (self *LinearDecay) GetDistanceWeight(dist int32) float32 {
+	if dist > self.max_range {
+		return 0
+	}
+	return 1 - float32(dist)/float32(self.max_range)
+}
+func (self *LinearDecay) GetMaxDistance() int32 {
+	return self.max_range
+}
+
+// Gaussian decay normalized to reach 0 at max_range.
+type GaussianDecay struct {
+	max_range int32
+}
+
+func (self *GaussianDecay) GetDistanceWeight(dist int32) float32 {
+	if dist > self.max_range {
+		return 0
+	}
+	d := float64(dist) / float64(self.max_range)
+	min := math.Exp(-0.5)
+	return float32((math.Exp(-0.5*d*d) - min) / (1 - min))
+}
+func (self *GaussianDecay) GetMaxDistance() int32 {
+	return self.max_range
+}
+
+// Exponential decay exp(-factor * dist) cut off at max_range.
+type ExponentialDecay struct {
+	max_range int32
+	factor    float32
+}
+
+func (self *ExponentialDecay) GetDistanceWeight(dist int32) float32 {
+	if dist > self.max_range {
+		return 0
+	}
+	return float32(math.Exp(-float64(self.factor) * float64(dist)))
+}
+func (self *ExponentialDecay) GetMaxDistance() int32 {
+	return self.max_range
+}
+
+// Piecewise constant decay, distances up to ranges[i] are weighted by factors[i].
+type StepDecay struct {
+	ranges  []int32
+	factors []float32
+}
+
+func (self *StepDecay) GetDistanceWeight(dist int32) float32 {
+	for i, r := range self.ranges {
+		if dist <= r {
+			return self.factors[i]
+		}
+	}
+	return 0
+}
+func (self *StepDecay) GetMaxDistance() int32 {
+	return self.ranges[len(self.ranges)-1]
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"math"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ttpr0/go-routing/batched/onetomany"
+	"github.com/ttpr0/go-routing/comps"
+	"github.com/ttpr0/go-routing/geo"
+	"github.com/ttpr0/go-routing/graph"
+	"github.com/ttpr0/go-routing/structs"
+	. "github.com/ttpr0/go-routing/util"
+)
+
+// Creates the accessibility task on the line 0-1-2-3-4-5 (two-way edges of 10s).
+//
+// Supply (weights 10, 20, 30) is located at the nodes 0, 2 and 5, demand (weights 100, 200, 300) at the nodes 1, 3 and 4
+// and one location that could not be mapped.
+func create_accessibility_task(measure string, decay IDistanceDecay) AccessibilityTask {
+	nodes := NewArray[structs.Node](6)
+	edges := NewList[structs.Edge](10)
+	for i := 0; i < 6; i++ {
+		nodes[i] = structs.Node{Loc: geo.Coord{float32(i), 0}}
+		if i > 0 {
+			edges.Add(structs.Edge{NodeA: int32(i - 1), NodeB: int32(i)})
+			edges.Add(structs.Edge{NodeA: int32(i), NodeB: int32(i - 1)})
+		}
+	}
+	base := comps.NewGraphBase(nodes, Array[structs.Edge](edges))
+	weight := comps.NewDefaultWeighting(base)
+	for i := 0; i < edges.Length(); i++ {
+		weight.SetEdgeWeight(int32(i), 10)
+	}
+	g := graph.BuildGraph(base, weight)
+	return AccessibilityTask{
+		otm:            onetomany.NewRangeDijkstra(g, decay.GetMaxDistance()),
+		measure:        measure,
+		decay:          decay,
+		supply_nodes:   Array[int32]{0, 2, 5},
+		demand_nodes:   Array[int32]{1, 3, 4, -1},
+		supply_weights: Array[float32]{10, 20, 30},
+		demand_weights: Array[float32]{100, 200, 300, 50},
+	}
+}
+
+func TestCalcAccessibility(t *testing.T) {
+	// distances from the supply (rows) to the demand (columns):
+	//   0: 10 30 40
+	//   2: 10 10 20
+	//   5: 40 20 10
+	tests := []struct {
+		name     string
+		measure  string
+		decay    IDistanceDecay
+		expected []float32
+	}{
+		// catchments within 15s: ratios 10/100, 20/(100+200) and 30/300
+		{"2sfca", "2sfca", &BinaryDecay{max_range: 15}, []float32{0.1 + 20.0/300, 20.0 / 300, 0.1, -1}},
+		{"cumulative", "cumulative", &BinaryDecay{max_range: 15}, []float32{30, 20, 30, -1}},
+		// weights 1-d/40: 0.75 at 10s, 0.5 at 20s, 0.25 at 30s and 0 at 40s
+		{"gravity", "gravity", &LinearDecay{max_range: 40}, []float32{10*0.75 + 20*0.75, 10*0.25 + 20*0.75 + 30*0.5, 20*0.5 + 30*0.75, -1}},
+		// ratios 10/125, 20/375 and 30/325
+		{"e2sfca", "e2sfca", &LinearDecay{max_range: 40}, []float32{10.0/125*0.75 + 20.0/375*0.75, 10.0/125*0.25 + 20.0/375*0.75 + 30.0/325*0.5, 20.0/375*0.5 + 30.0/325*0.75, -1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := create_accessibility_task(tt.measure, tt.decay)
+			rows := int32(0)
+			access, err := CalcAccessibility(task, 2, func() error {
+				atomic.AddInt32(&rows, 1)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for d, expected := range tt.expected {
+				if math.Abs(float64(access[d]-expected)) > 1e-5 {
+					t.Errorf("demand %v: expected %v, got %v", d, expected, access[d])
+				}
+			}
+			if expected := _GetAccessibilityRowCount(tt.measure, task.supply_nodes.Length()); rows != expected {
+				t.Errorf("expected %v rows, got %v", expected, rows)
+			}
+		})
+	}
+}
@@ -0,0 +1,282 @@
+package attr
+
+import (
+	"errors"
+	"os"
+
+	. "github.com/ttpr0/go-routing/util"
+)
+
+//*******************************************
+// edge details
+//*******************************************
+
+const (
+	_TOLL   byte = 1
+	_FERRY  byte = 2
+	_BRIDGE byte = 4
+	_TUNNEL byte = 8
+)
+
+// Columnar storage of the edge details.
+type _EdgeDetailStore struct {
+	surface    Array[SurfaceType]
+	smoothness Array[SmoothnessType]
+	incline    Array[int8]
+	lanes      Array[byte]
+	access     Array[AccessType]
+	flags      Array[byte]
+	width      Array[float32]
+	ascent     Array[int16]
+	descent    Array[int16]
+}
+
+func _NewEdgeDetailStore(details Array[EdgeDetails]) _EdgeDetailStore {
+	count := details.Length()
+	store := _EdgeDetailStore{
+		surface:    NewArray[SurfaceType](count),
+		smoothness: NewArray[SmoothnessType](count),
+		incline:    NewArray[int8](count),
+		lanes:      NewArray[byte](count),
+		access:     NewArray[AccessType](count),
+		flags:      NewArray[byte](count),
+		width:      NewArray[float32](count),
+		ascent:     NewArray[int16](count),
+		descent:    NewArray[int16](count),
+	}
+	for i, d := range details {
+		store.Set(int32(i), d)
+	}
+	return store
+}
+
+func (self *_EdgeDetailStore) Get(edge int32) EdgeDetails {
+	flags := self.flags[edge]
+	return EdgeDetails{
+		Surface:    self.surface[edge],
+		Smoothness: self.smoothness[edge],
+		Incline:    self.incline[edge],
+		Lanes:      self.lanes[edge],
+		Access:     self.access[edge],
+		Toll:       flags&_TOLL != 0,
+		Ferry:      flags&_FERRY != 0,
+		Bridge:     flags&_BRIDGE != 0,
+		Tunnel:     flags&_TUNNEL != 0,
+		Width:      self.width[edge],
+		Ascent:     self.ascent[edge],
+		Descent:    self.descent[edge],
+	}
+}
+func (self *_EdgeDetailStore) Set(edge int32, details EdgeDetails) {
+	flags := byte(0)
+	if details.Toll {
+		flags |= _TOLL
+	}
+	if details.Ferry {
+		flags |= _FERRY
+	}
+	if details.Bridge {
+		flags |= _BRIDGE
+	}
+	if details.Tunnel {
+		flags |= _TUNNEL
+	}
+	self.surface[edge] = details.Surface
+	self.smoothness[edge] = details.Smoothness
+	self.incline[edge] = details.Incline
+	self.lanes[edge] = details.Lanes
+	self.access[edge] = details.Access
+	self.flags[edge] = flags
+	self.width[edge] = details.Width
+	self.ascent[edge] = details.Ascent
+	self.descent[edge] = details.Descent
+}
+func (self *_EdgeDetailStore) Remove(remove Array[bool]) {
+	self.surface = _RemoveColumn(self.surface, remove)
+	self.smoothness = _RemoveColumn(self.smoothness, remove)
+	self.incline = _RemoveColumn(self.incline, remove)
+	self.lanes = _RemoveColumn(self.lanes, remove)
+	self.access = _RemoveColumn(self.access, remove)
+	self.flags = _RemoveColumn(self.flags, remove)
+	self.width = _RemoveColumn(self.width, remove)
+	self.ascent = _RemoveColumn(self.ascent, remove)
+	self.descent = _RemoveColumn(self.descent, remove)
+}
+func (self *_EdgeDetailStore) Store(writer BufferWriter) {
+	WriteArray(writer, self.surface)
+	WriteArray(writer, self.smoothness)
+	WriteArray(writer, self.incline)
+	WriteArray(writer, self.lanes)
+	WriteArray(writer, self.access)
+	WriteArray(writer, self.flags)
+	WriteArray(writer, self.width)
+	WriteArray(writer, self.ascent)
+	WriteArray(writer, self.descent)
+}
+func _LoadEdgeDetailStore(reader BufferReader) _EdgeDetailStore {
+	return _EdgeDetailStore{
+		surface:    ReadArray[SurfaceType](reader),
+		smoothness: ReadArray[SmoothnessType](reader),
+		incline:    ReadArray[int8](reader),
+		lanes:      ReadArray[byte](reader),
+		access:     ReadArray[AccessType](reader),
+		flags:      ReadArray[byte](reader),
+		width:      ReadArray[float32](reader),
+		ascent:     ReadArray[int16](reader),
+		descent:    ReadArray[int16](reader),
+	}
+}
+
+//*******************************************
+// node details
+//*******************************************
+
+// Columnar storage of the node details.
+type _NodeDetailStore struct {
+	signals   Array[bool]
+	barrier   Array[BarrierType]
+	elevation Array[int16]
+}
+
+func _NewNodeDetailStore(details Array[NodeDetails]) _NodeDetailStore {
+	count := details.Length()
+	store := _NodeDetailStore{
+		signals:   NewArray[bool](count),
+		barrier:   NewArray[BarrierType](count),
+		elevation: NewArray[int16](count),
+	}
+	for i, d := range details {
+		store.Set(int32(i), d)
+	}
+	return store
+}
+
+func (self *_NodeDetailStore) Get(node int32) NodeDetails {
+	return NodeDetails{
+		TrafficSignals: self.signals[node],
+		Barrier:        self.barrier[node],
+		Elevation:      self.elevation[node],
+	}
+}
+func (self *_NodeDetailStore) Set(node int32, details NodeDetails) {
+	self.signals[node] = details.TrafficSignals
+	self.barrier[node] = details.Barrier
+	self.elevation[node] = details.Elevation
+}
+func (self *_NodeDetailStore) Remove(remove Array[bool]) {
+	self.signals = _RemoveColumn(self.signals, remove)
+	self.barrier = _RemoveColumn(self.barrier, remove)
+	self.elevation = _RemoveColumn(self.elevation, remove)
+}
+func (self *_NodeDetailStore) Reorder(mapping Array[int32]) {
+	self.signals = _ReorderColumn(self.signals, mapping)
+	self.barrier = _ReorderColumn(self.barrier, mapping)
+	self.elevation = _ReorderColumn(self.elevation, mapping)
+}
+func (self *_NodeDetailStore) Store(writer BufferWriter) {
+	WriteArray(writer, self.signals)
+	WriteArray(writer, self.barrier)
+	WriteArray(writer, self.elevation)
+}
+func _LoadNodeDetailStore(reader BufferReader) _NodeDetailStore {
+	return _NodeDetailStore{
+		signals:   ReadArray[bool](reader),
+		barrier:   ReadArray[BarrierType](reader),
+		elevation: ReadArray[int16](reader),
+	}
+}
+
+//*******************************************
+// avoid features
+//*******************************************
+
+// Checks if the edge or the node it leads to has any of the features.
+func HasAnyFeature(att IAttributes, edge int32, node int32, features []AvoidFeature) bool {
+	details := att.GetEdgeDetails(edge)
+	for _, feature := range features {
+		switch feature {
+		case AVOID_TOLLS:
+			if details.Toll || att.GetNodeDetails(node).Barrier == BARRIER_TOLL_BOOTH {
+				return true
+			}
+		case AVOID_FERRIES:
+			if details.Ferry {
+				return true
+			}
+		case AVOID_TUNNELS:
+			if details.Tunnel {
+				return true
+			}
+		case AVOID_BRIDGES:
+			if details.Bridge {
+				return true
+			}
+		case AVOID_UNPAVED:
+			if !details.Surface.IsPaved() {
+				return true
+			}
+		case AVOID_BARRIERS:
+			if att.GetNodeDetails(node).Barrier != 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//*******************************************
+// load and store details
+//*******************************************
+
+func _StoreDetails(nodes _NodeDetailStore, edges _EdgeDetailStore, file string) {
+	writer := NewBufferWriter()
+	nodes.Store(writer)
+	edges.Store(writer)
+
+	detailfile, _ := os.Create(file)
+	defer detailfile.Close()
+	detailfile.Write(writer.Bytes())
+}
+
+// Loads the details if stored, graphs stored without details get unknown details.
+func _LoadDetails(file string, nodecount, edgecount int) (_NodeDetailStore, _EdgeDetailStore) {
+	_, err := os.Stat(file)
+	if errors.Is(err, os.ErrNotExist) {
+		node_details := NewArray[NodeDetails](nodecount)
+		for i := range node_details {
+			node_details[i].Elevation = NO_ELEVATION
+		}
+		return _NewNodeDetailStore(node_details), _NewEdgeDetailStore(NewArray[EdgeDetails](edgecount))
+	}
+	data, _ := os.ReadFile(file)
+	reader := NewBufferReader(data)
+	nodes := _LoadNodeDetailStore(reader)
+	edges := _LoadEdgeDetailStore(reader)
+	if nodes.signals.Length() != nodecount || edges.surface.Length() != edgecount {
+		panic("invalid details: " + file)
+	}
+	return nodes, edges
+}
+
+//*******************************************
+// column utility
+//*******************************************
+
+func _RemoveColumn[T any](column Array[T], remove Array[bool]) Array[T] {
+	new_column := NewList[T](column.Length())
+	for i, v := range column {
+		if remove[i] {
+			continue
+		}
+		new_column.Add(v)
+	}
+	return Array[T](new_column)
+}
+
+func _ReorderColumn[T any](column Array[T], mapping Array[int32]) Array[T] {
+	new_column := NewArray[T](column.Length())
+	for i, id := range mapping {
+		new_column[id] = column[i]
+	}
+	return new_column
+}
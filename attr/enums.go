@@ -27,6 +27,13 @@ const (
 	UNCLASSIFIED   RoadType = 13
 	ROAD           RoadType = 14
 	TRACK          RoadType = 15
+	SERVICE        RoadType = 16
+	FOOTWAY        RoadType = 17
+	PATH           RoadType = 18
+	PEDESTRIAN     RoadType = 19
+	STEPS          RoadType = 20
+	CYCLEWAY       RoadType = 21
+	BRIDLEWAY      RoadType = 22
 )
 
 func (self RoadType) String() string {
@@ -61,6 +68,20 @@ func (self RoadType) String() string {
 		return "road"
 	case TRACK:
 		return "track"
+	case SERVICE:
+		return "service"
+	case FOOTWAY:
+		return "footway"
+	case PATH:
+		return "path"
+	case PEDESTRIAN:
+		return "pedestrian"
+	case STEPS:
+		return "steps"
+	case CYCLEWAY:
+		return "cycleway"
+	case BRIDLEWAY:
+		return "bridleway"
 	}
 	return ""
 }
@@ -97,6 +118,20 @@ func RoadTypeFromString(typ string) RoadType {
 		return ROAD
 	case "track":
 		return TRACK
+	case "service":
+		return SERVICE
+	case "footway":
+		return FOOTWAY
+	case "path":
+		return PATH
+	case "pedestrian":
+		return PEDESTRIAN
+	case "steps":
+		return STEPS
+	case "cycleway":
+		return CYCLEWAY
+	case "bridleway":
+		return BRIDLEWAY
 	}
 	return 0
 }
@@ -116,3 +151,231 @@ func (self *RoadType) UnmarshalJSON(data []byte) error {
 	*self = prof_typ
 	return nil
 }
+
+type SurfaceType int8
+
+const (
+	SURFACE_PAVED         SurfaceType = 1
+	SURFACE_ASPHALT       SurfaceType = 2
+	SURFACE_CONCRETE      SurfaceType = 3
+	SURFACE_PAVING_STONES SurfaceType = 4
+	SURFACE_SETT          SurfaceType = 5
+	SURFACE_COBBLESTONE   SurfaceType = 6
+	SURFACE_METAL         SurfaceType = 7
+	SURFACE_WOOD          SurfaceType = 8
+	SURFACE_UNPAVED       SurfaceType = 9
+	SURFACE_COMPACTED     SurfaceType = 10
+	SURFACE_FINE_GRAVEL   SurfaceType = 11
+	SURFACE_GRAVEL        SurfaceType = 12
+	SURFACE_GROUND        SurfaceType = 13
+	SURFACE_GRASS         SurfaceType = 14
+	SURFACE_SAND          SurfaceType = 15
+)
+
+var surface_names = [...]string{"", "paved", "asphalt", "concrete", "paving_stones", "sett", "cobblestone", "metal", "wood",
+	"unpaved", "compacted", "fine_gravel", "gravel", "ground", "grass", "sand"}
+
+func (self SurfaceType) String() string {
+	if self < 0 || int(self) >= len(surface_names) {
+		return ""
+	}
+	return surface_names[self]
+}
+
+// Returns the surface-type of the osm surface-value, 0 if unknown.
+func SurfaceTypeFromString(typ string) SurfaceType {
+	switch typ {
+	case "dirt", "earth", "mud":
+		return SURFACE_GROUND
+	case "pebblestone":
+		return SURFACE_GRAVEL
+	case "unhewn_cobblestone":
+		return SURFACE_COBBLESTONE
+	case "concrete:plates", "concrete:lanes":
+		return SURFACE_CONCRETE
+	}
+	for i, name := range surface_names {
+		if i > 0 && name == typ {
+			return SurfaceType(i)
+		}
+	}
+	return 0
+}
+
+// Checks if the surface is paved, unknown surfaces are assumed to be paved.
+func (self SurfaceType) IsPaved() bool {
+	return self < SURFACE_UNPAVED
+}
+
+func (self SurfaceType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(self.String())
+}
+
+type SmoothnessType int8
+
+const (
+	SMOOTHNESS_EXCELLENT     SmoothnessType = 1
+	SMOOTHNESS_GOOD          SmoothnessType = 2
+	SMOOTHNESS_INTERMEDIATE  SmoothnessType = 3
+	SMOOTHNESS_BAD           SmoothnessType = 4
+	SMOOTHNESS_VERY_BAD      SmoothnessType = 5
+	SMOOTHNESS_HORRIBLE      SmoothnessType = 6
+	SMOOTHNESS_VERY_HORRIBLE SmoothnessType = 7
+	SMOOTHNESS_IMPASSABLE    SmoothnessType = 8
+)
+
+var smoothness_names = [...]string{"", "excellent", "good", "intermediate", "bad", "very_bad", "horrible", "very_horrible", "impassable"}
+
+func (self SmoothnessType) String() string {
+	if self < 0 || int(self) >= len(smoothness_names) {
+		return ""
+	}
+	return smoothness_names[self]
+}
+
+// Returns the smoothness of the osm smoothness-value, 0 if unknown.
+func SmoothnessTypeFromString(typ string) SmoothnessType {
+	for i, name := range smoothness_names {
+		if i > 0 && name == typ {
+			return SmoothnessType(i)
+		}
+	}
+	return 0
+}
+
+func (self SmoothnessType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(self.String())
+}
+
+// Access-class of an edge for the vehicle of the graph.
+type AccessType int8
+
+const (
+	ACCESS_YES         AccessType = 1
+	ACCESS_DESTINATION AccessType = 2
+	ACCESS_DELIVERY    AccessType = 3
+	ACCESS_CUSTOMERS   AccessType = 4
+	ACCESS_PERMISSIVE  AccessType = 5
+	ACCESS_DESIGNATED  AccessType = 6
+	ACCESS_PRIVATE     AccessType = 7
+	ACCESS_NO          AccessType = 8
+)
+
+var access_names = [...]string{"", "yes", "destination", "delivery", "customers", "permissive", "designated", "private", "no"}
+
+func (self AccessType) String() string {
+	if self < 0 || int(self) >= len(access_names) {
+		return ""
+	}
+	return access_names[self]
+}
+
+// Returns the access-class of the osm access-value, 0 if unknown.
+func AccessTypeFromString(typ string) AccessType {
+	switch typ {
+	case "official":
+		return ACCESS_DESIGNATED
+	case "agricultural", "forestry":
+		return ACCESS_PRIVATE
+	}
+	for i, name := range access_names {
+		if i > 0 && name == typ {
+			return AccessType(i)
+		}
+	}
+	return 0
+}
+
+func (self AccessType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(self.String())
+}
+
+type BarrierType int8
+
+const (
+	BARRIER_GATE          BarrierType = 1
+	BARRIER_LIFT_GATE     BarrierType = 2
+	BARRIER_SWING_GATE    BarrierType = 3
+	BARRIER_BOLLARD       BarrierType = 4
+	BARRIER_BLOCK         BarrierType = 5
+	BARRIER_CYCLE_BARRIER BarrierType = 6
+	BARRIER_STILE         BarrierType = 7
+	BARRIER_KERB          BarrierType = 8
+	BARRIER_TOLL_BOOTH    BarrierType = 9
+	BARRIER_BORDER        BarrierType = 10
+	BARRIER_OTHER         BarrierType = 11
+)
+
+var barrier_names = [...]string{"", "gate", "lift_gate", "swing_gate", "bollard", "block", "cycle_barrier", "stile", "kerb",
+	"toll_booth", "border_control", "other"}
+
+func (self BarrierType) String() string {
+	if self < 0 || int(self) >= len(barrier_names) {
+		return ""
+	}
+	return barrier_names[self]
+}
+
+// Returns the barrier-type of the osm barrier-value, other barriers are mapped to BARRIER_OTHER.
+func BarrierTypeFromString(typ string) BarrierType {
+	switch typ {
+	case "", "no", "entrance":
+		return 0
+	}
+	for i, name := range barrier_names {
+		if i > 0 && name == typ {
+			return BarrierType(i)
+		}
+	}
+	return BARRIER_OTHER
+}
+
+func (self BarrierType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(self.String())
+}
+
+// Features of edges (or the nodes they lead to) that can be avoided.
+type AvoidFeature int8
+
+const (
+	AVOID_TOLLS    AvoidFeature = 1
+	AVOID_FERRIES  AvoidFeature = 2
+	AVOID_TUNNELS  AvoidFeature = 3
+	AVOID_BRIDGES  AvoidFeature = 4
+	AVOID_UNPAVED  AvoidFeature = 5
+	AVOID_BARRIERS AvoidFeature = 6
+)
+
+var avoid_feature_names = [...]string{"", "tolls", "ferries", "tunnels", "bridges", "unpaved", "barriers"}
+
+func (self AvoidFeature) String() string {
+	if self < 0 || int(self) >= len(avoid_feature_names) {
+		return ""
+	}
+	return avoid_feature_names[self]
+}
+
+func AvoidFeatureFromString(typ string) AvoidFeature {
+	for i, name := range avoid_feature_names {
+		if i > 0 && name == typ {
+			return AvoidFeature(i)
+		}
+	}
+	return 0
+}
+
+func (self AvoidFeature) MarshalJSON() ([]byte, error) {
+	return json.Marshal(self.String())
+}
+func (self *AvoidFeature) UnmarshalJSON(data []byte) error {
+	var typ string
+	if err := json.Unmarshal(data, &typ); err != nil {
+		return err
+	}
+	feature := AvoidFeatureFromString(typ)
+	if feature == 0 {
+		return errors.New("invalid avoid feature")
+	}
+	*self = feature
+	return nil
+}
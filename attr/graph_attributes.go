@@ -14,6 +14,8 @@ import (
 type IAttributes interface {
 	GetNodeAttribs(node int32) NodeAttribs
 	GetEdgeAttribs(edge int32) EdgeAttribs
+	GetNodeDetails(node int32) NodeDetails
+	GetEdgeDetails(edge int32) EdgeDetails
 	GetNodeGeom(node int32) geo.Coord
 	GetEdgeGeom(edge int32) geo.CoordArray
 	GetClosestNode(point geo.Coord) (int32, bool)
@@ -22,15 +24,20 @@ type IAttributes interface {
 type GraphAttributes struct {
 	node_attribs Array[NodeAttribs]
 	edge_attribs Array[EdgeAttribs]
+	node_details _NodeDetailStore
+	edge_details _EdgeDetailStore
 	node_geoms   []geo.Coord
 	edge_geoms   []geo.CoordArray
+	restrictions Array[structs.TurnRestriction]
 	index        Optional[KDTree[int32]]
 }
 
-func New(nodes Array[NodeAttribs], edges Array[EdgeAttribs], node_geoms Array[geo.Coord], edge_geoms Array[geo.CoordArray]) *GraphAttributes {
+func New(nodes Array[NodeAttribs], edges Array[EdgeAttribs], node_details Array[NodeDetails], edge_details Array[EdgeDetails], node_geoms Array[geo.Coord], edge_geoms Array[geo.CoordArray]) *GraphAttributes {
 	return &GraphAttributes{
 		node_attribs: nodes,
 		edge_attribs: edges,
+		node_details: _NewNodeDetailStore(node_details),
+		edge_details: _NewEdgeDetailStore(edge_details),
 		node_geoms:   node_geoms,
 		edge_geoms:   edge_geoms,
 	}
@@ -42,6 +49,18 @@ func (self *GraphAttributes) GetNodeAttribs(node int32) NodeAttribs {
 func (self *GraphAttributes) GetEdgeAttribs(edge int32) EdgeAttribs {
 	return self.edge_attribs[edge]
 }
+func (self *GraphAttributes) GetNodeDetails(node int32) NodeDetails {
+	return self.node_details.Get(node)
+}
+func (self *GraphAttributes) GetEdgeDetails(edge int32) EdgeDetails {
+	return self.edge_details.Get(edge)
+}
+func (self *GraphAttributes) SetNodeDetails(node int32, details NodeDetails) {
+	self.node_details.Set(node, details)
+}
+func (self *GraphAttributes) SetEdgeDetails(edge int32, details EdgeDetails) {
+	self.edge_details.Set(edge, details)
+}
 func (self *GraphAttributes) GetNodeGeom(node int32) geo.Coord {
 	return self.node_geoms[node]
 }
@@ -49,6 +68,14 @@ func (self *GraphAttributes) GetEdgeGeom(edge int32) geo.CoordArray {
 	geom := self.edge_geoms[edge]
 	return geom
 }
+
+// Returns the forbidden turns of the graph (e.g. parsed from turn-restrictions).
+func (self *GraphAttributes) GetTurnRestrictions() Array[structs.TurnRestriction] {
+	return self.restrictions
+}
+func (self *GraphAttributes) SetTurnRestrictions(restrictions Array[structs.TurnRestriction]) {
+	self.restrictions = restrictions
+}
 func (self *GraphAttributes) GetClosestNode(point geo.Coord) (int32, bool) {
 	if self.index.HasValue() {
 		return self.index.Value.GetClosest(point[:], 0.05)
@@ -95,6 +122,24 @@ func (self *MappedAttributes) GetEdgeAttribs(edge int32) EdgeAttribs {
 	}
 	return self.attributes.GetEdgeAttribs(m_edge)
 }
+func (self *MappedAttributes) GetNodeDetails(node int32) NodeDetails {
+	var m_node int32
+	if self.node_mapping.HasValue() {
+		m_node = self.node_mapping.Value.GetSource(node)
+	} else {
+		m_node = node
+	}
+	return self.attributes.GetNodeDetails(m_node)
+}
+func (self *MappedAttributes) GetEdgeDetails(edge int32) EdgeDetails {
+	var m_edge int32
+	if self.edge_mapping.HasValue() {
+		m_edge = self.edge_mapping.Value.GetSource(edge)
+	} else {
+		m_edge = edge
+	}
+	return self.attributes.GetEdgeDetails(m_edge)
+}
 func (self *MappedAttributes) GetNodeGeom(node int32) geo.Coord {
 	var m_node int32
 	if self.node_mapping.HasValue() {
@@ -137,6 +182,7 @@ func (self *GraphAttributes) ReorderNodes(mapping Array[int32]) {
 		new_nodes[id] = self.node_attribs[i]
 	}
 	self.node_attribs = new_nodes
+	self.node_details.Reorder(mapping)
 
 	// geom
 	new_node_geoms := NewArray[geo.Coord](len(self.node_geoms))
@@ -145,6 +191,11 @@ func (self *GraphAttributes) ReorderNodes(mapping Array[int32]) {
 	}
 	self.node_geoms = new_node_geoms
 	self.index = None[KDTree[int32]]()
+
+	// turn-restrictions
+	for i := range self.restrictions {
+		self.restrictions[i].Via = mapping[self.restrictions[i].Via]
+	}
 }
 func (self *GraphAttributes) RemoveNodes(nodes List[int32]) {
 	remove := NewArray[bool](len(self.node_attribs))
@@ -163,8 +214,21 @@ func (self *GraphAttributes) RemoveNodes(nodes List[int32]) {
 	}
 
 	self.node_attribs = Array[NodeAttribs](new_nodes)
+	self.node_details.Remove(remove)
 	self.node_geoms = new_node_geoms
 	self.index = None[KDTree[int32]]()
+
+	// turn-restrictions at removed nodes are removed
+	mapping := _GetRemoveMapping(remove)
+	new_restrictions := NewList[structs.TurnRestriction](len(self.restrictions))
+	for _, r := range self.restrictions {
+		if mapping[r.Via] == -1 {
+			continue
+		}
+		r.Via = mapping[r.Via]
+		new_restrictions.Add(r)
+	}
+	self.restrictions = Array[structs.TurnRestriction](new_restrictions)
 }
 func (self *GraphAttributes) RemoveEdges(edges List[int32]) {
 	remove := NewArray[bool](len(self.edge_attribs))
@@ -183,8 +247,37 @@ func (self *GraphAttributes) RemoveEdges(edges List[int32]) {
 	}
 
 	self.edge_attribs = Array[EdgeAttribs](new_edges)
+	self.edge_details.Remove(remove)
 	self.edge_geoms = new_edge_geoms
 	self.index = None[KDTree[int32]]()
+
+	// turn-restrictions of removed edges are removed
+	mapping := _GetRemoveMapping(remove)
+	new_restrictions := NewList[structs.TurnRestriction](len(self.restrictions))
+	for _, r := range self.restrictions {
+		if mapping[r.From] == -1 || mapping[r.To] == -1 {
+			continue
+		}
+		r.From = mapping[r.From]
+		r.To = mapping[r.To]
+		new_restrictions.Add(r)
+	}
+	self.restrictions = Array[structs.TurnRestriction](new_restrictions)
+}
+
+// Maps the old ids to the ids after removing, removed ids are mapped to -1.
+func _GetRemoveMapping(remove Array[bool]) Array[int32] {
+	mapping := NewArray[int32](len(remove))
+	c := int32(0)
+	for i := 0; i < len(remove); i++ {
+		if remove[i] {
+			mapping[i] = -1
+			continue
+		}
+		mapping[i] = c
+		c += 1
+	}
+	return mapping
 }
 
 //*******************************************
@@ -212,6 +305,12 @@ func Store(attr *GraphAttributes, path string) {
 	attrfile.Write(attrib_writer.Bytes())
 
 	_StoreGraphGeom(attr.node_geoms, attr.edge_geoms, path+"-geom")
+	_StoreDetails(attr.node_details, attr.edge_details, path+"-details")
+	if attr.restrictions.Length() > 0 {
+		WriteArrayToFile(attr.restrictions, path+"-restrictions")
+	} else {
+		os.Remove(path + "-restrictions")
+	}
 }
 
 func Load(path string) *GraphAttributes {
@@ -244,12 +343,17 @@ func Load(path string) *GraphAttributes {
 	}
 
 	node_geoms, edge_geoms := _LoadGraphGeom(path+"-geom", nodecount, edgecount)
+	node_details, edge_details := _LoadDetails(path+"-details", nodecount, edgecount)
+	restrictions := _LoadTurnRestrictions(path + "-restrictions")
 
 	return &GraphAttributes{
 		node_attribs: nodes,
 		edge_attribs: edges,
+		node_details: node_details,
+		edge_details: edge_details,
 		node_geoms:   node_geoms,
 		edge_geoms:   edge_geoms,
+		restrictions: restrictions,
 	}
 }
 
@@ -283,12 +387,17 @@ func LoadMin(path string) *GraphAttributes {
 	}
 
 	node_geoms, edge_geoms := _LoadGraphGeomMin(path+"-geom", nodecount, edgecount)
+	node_details, edge_details := _LoadDetails(path+"-details", nodecount, edgecount)
+	restrictions := _LoadTurnRestrictions(path + "-restrictions")
 
 	return &GraphAttributes{
 		node_attribs: nodes,
 		edge_attribs: edges,
+		node_details: node_details,
+		edge_details: edge_details,
 		node_geoms:   node_geoms,
 		edge_geoms:   edge_geoms,
+		restrictions: restrictions,
 	}
 }
 
@@ -335,6 +444,15 @@ func _StoreGraphGeom(nodes []geo.Coord, edges []geo.CoordArray, filename string)
 	geomfile.Write(geombuffer.Bytes())
 }
 
+// Loads the turn-restrictions if stored (graphs without restrictions don't store them).
+func _LoadTurnRestrictions(file string) Array[structs.TurnRestriction] {
+	_, err := os.Stat(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return NewArray[structs.TurnRestriction](0)
+	}
+	return ReadArrayFromFile[structs.TurnRestriction](file)
+}
+
 func _LoadGraphGeom(file string, nodecount, edgecount int) ([]geo.Coord, []geo.CoordArray) {
 	_, err := os.Stat(file)
 	if errors.Is(err, os.ErrNotExist) {
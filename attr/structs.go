@@ -14,3 +14,40 @@ type EdgeAttribs struct {
 type NodeAttribs struct {
 	Type int8
 }
+
+// Unknown elevation of nodes.
+const NO_ELEVATION int16 = -32768
+
+// Detail attributes of an edge, zero-values are unknown.
+type EdgeDetails struct {
+	Surface    SurfaceType
+	Smoothness SmoothnessType
+	// incline in percent (positive if ascending in edge-direction)
+	Incline int8
+	Lanes   byte
+	Access  AccessType
+	Toll    bool
+	Ferry   bool
+	Bridge  bool
+	Tunnel  bool
+	// width in meters
+	Width float32
+	// ascent and descent along the edge in meters
+	Ascent  int16
+	Descent int16
+}
+
+// Returns the details of the edge traversed in opposite direction.
+func (self EdgeDetails) Reversed() EdgeDetails {
+	self.Incline = -self.Incline
+	self.Ascent, self.Descent = self.Descent, self.Ascent
+	return self
+}
+
+// Detail attributes of a node, zero-values are unknown.
+type NodeDetails struct {
+	TrafficSignals bool
+	Barrier        BarrierType
+	// elevation in meters, NO_ELEVATION if unknown
+	Elevation int16
+}
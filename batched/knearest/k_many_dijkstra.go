@@ -0,0 +1,96 @@
+package knearest
+
+import (
+	"github.com/ttpr0/go-routing/graph"
+	. "github.com/ttpr0/go-routing/util"
+)
+
+// Multi-source dijkstra keeping the k best labels (of distinct sources) per node.
+func NewKManyDijkstra(g graph.IGraph, max_range int32, k int) *KManyDijkstra {
+	return &KManyDijkstra{g: g, max_range: max_range, k: k}
+}
+
+type KManyDijkstra struct {
+	g         graph.IGraph
+	max_range int32
+	k         int
+}
+
+func (self *KManyDijkstra) CreateSolver() ISolver {
+	node_flags := NewFlags[KDistFlag](int32(self.g.NodeCount()), KDistFlag{})
+	return &KManyDijkstraSolver{
+		g:          self.g,
+		node_flags: node_flags,
+		max_range:  self.max_range,
+		k:          self.k,
+	}
+}
+
+type KManyDijkstraSolver struct {
+	g          graph.IGraph
+	node_flags Flags[KDistFlag]
+	max_range  int32
+	k          int
+}
+
+func (self *KManyDijkstraSolver) CalcKNearestNeighbours(sources List[Array[Tuple[int32, int32]]]) error {
+	self.node_flags.Reset()
+	_CalcKManyDijkstra(self.g, sources, self.node_flags, self.max_range, self.k)
+	return nil
+}
+
+func (self *KManyDijkstraSolver) GetNeighbour(node int32, k int8) int32 {
+	label := self.node_flags.Get(node).Get(k)
+	if !label.HasValue() {
+		return -1
+	}
+	return label.Value.Source
+}
+func (self *KManyDijkstraSolver) GetDistance(node int32, k int8) int32 {
+	label := self.node_flags.Get(node).Get(k)
+	if !label.HasValue() {
+		return 1000000
+	}
+	return label.Value.Dist
+}
+
+func _CalcKManyDijkstra(g graph.IGraph, sources List[Array[Tuple[int32, int32]]], node_flags Flags[KDistFlag], max_range int32, k int) {
+	heap := NewPriorityQueue[KPQItem, int32](100)
+	explorer := g.GetGraphExplorer()
+
+	for source_id, source := range sources {
+		for _, item := range source {
+			start := item.A
+			dist := item.B
+			heap.Enqueue(KPQItem{start, int32(source_id), dist}, dist)
+		}
+	}
+
+	// labels are settled in order of their distance, the first k labels of distinct sources reaching a node are the k nearest
+	for {
+		curr_item, ok := heap.Dequeue()
+		if !ok {
+			break
+		}
+		curr_id := curr_item.item
+		curr_source := curr_item.source
+		curr_dist := curr_item.dist
+		curr_flag := node_flags.Get(curr_id)
+		if curr_flag.Count() >= k || curr_flag.HasSource(curr_source) {
+			continue
+		}
+		curr_flag.Insert(curr_source, curr_dist, k)
+		explorer.ForAdjacentEdges(curr_id, graph.FORWARD, graph.ADJACENT_EDGES, func(ref graph.EdgeRef) {
+			other_id := ref.OtherID
+			other_flag := node_flags.Get(other_id)
+			if other_flag.Count() >= k || other_flag.HasSource(curr_source) {
+				return
+			}
+			new_length := curr_dist + explorer.GetEdgeWeight(ref)
+			if new_length > max_range {
+				return
+			}
+			heap.Enqueue(KPQItem{other_id, curr_source, new_length}, new_length)
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package knearest
+
+import (
+	"github.com/ttpr0/go-routing/comps"
+	"github.com/ttpr0/go-routing/graph"
+	. "github.com/ttpr0/go-routing/util"
+)
+
+// Edge-based variant of KManyDijkstra respecting the turn-costs of the graph.
+//
+// Every edge keeps the k best labels (of distinct sources), the labels of a node are the k best labels of its incoming edges.
+func NewKManyDijkstraTC(g graph.IGraph, max_range int32, k int) *KManyDijkstraTC {
+	return &KManyDijkstraTC{g: g, max_range: max_range, k: k}
+}
+
+type KManyDijkstraTC struct {
+	g         graph.IGraph
+	max_range int32
+	k         int
+}
+
+func (self *KManyDijkstraTC) CreateSolver() ISolver {
+	node_flags := NewFlags[KDistFlag](int32(self.g.NodeCount()), KDistFlag{})
+	edge_flags := NewFlags[KDistFlag](int32(self.g.EdgeCount()), KDistFlag{})
+	return &KManyDijkstraTCSolver{
+		g:          self.g,
+		node_flags: node_flags,
+		edge_flags: edge_flags,
+		max_range:  self.max_range,
+		k:          self.k,
+	}
+}
+
+type KManyDijkstraTCSolver struct {
+	g          graph.IGraph
+	node_flags Flags[KDistFlag]
+	edge_flags Flags[KDistFlag]
+	max_range  int32
+	k          int
+}
+
+func (self *KManyDijkstraTCSolver) CalcKNearestNeighbours(sources List[Array[Tuple[int32, int32]]]) error {
+	self.node_flags.Reset()
+	self.edge_flags.Reset()
+	_CalcKManyDijkstraTC(self.g, sources, self.node_flags, self.edge_flags, self.max_range, self.k)
+	return nil
+}
+
+func (self *KManyDijkstraTCSolver) GetNeighbour(node int32, k int8) int32 {
+	label := self.node_flags.Get(node).Get(k)
+	if !label.HasValue() {
+		return -1
+	}
+	return label.Value.Source
+}
+func (self *KManyDijkstraTCSolver) GetDistance(node int32, k int8) int32 {
+	label := self.node_flags.Get(node).Get(k)
+	if !label.HasValue() {
+		return 1000000
+	}
+	return label.Value.Dist
+}
+
+func _CalcKManyDijkstraTC(g graph.IGraph, sources List[Array[Tuple[int32, int32]]], node_flags Flags[KDistFlag], edge_flags Flags[KDistFlag], max_range int32, k int) {
+	heap := NewPriorityQueue[KPQItem, int32](100)
+	explorer := g.GetGraphExplorer()
+
+	for source_id, source := range sources {
+		for _, item := range source {
+			start := item.A
+			dist := item.B
+			node_flags.Get(start).Insert(int32(source_id), dist, k)
+			explorer.ForAdjacentEdges(start, graph.FORWARD, graph.ADJACENT_EDGES, func(ref graph.EdgeRef) {
+				edge_dist := explorer.GetEdgeWeight(ref) + dist
+				if edge_dist > max_range {
+					return
+				}
+				heap.Enqueue(KPQItem{ref.EdgeID, int32(source_id), edge_dist}, edge_dist)
+			})
+		}
+	}
+
+	// labels are settled in order of their distance, the first k labels of distinct sources reaching an edge are the k nearest
+	for {
+		curr_item, ok := heap.Dequeue()
+		if !ok {
+			break
+		}
+		curr_id := curr_item.item
+		curr_source := curr_item.source
+		curr_dist := curr_item.dist
+		curr_flag := edge_flags.Get(curr_id)
+		if curr_flag.Count() >= k || curr_flag.HasSource(curr_source) {
+			continue
+		}
+		curr_flag.Insert(curr_source, curr_dist, k)
+		curr_edge := g.GetEdge(curr_id)
+		node_flags.Get(curr_edge.NodeB).Insert(curr_source, curr_dist, k)
+		curr_ref := graph.EdgeRef{EdgeID: curr_id, Type: 0, OtherID: curr_edge.NodeB}
+		explorer.ForAdjacentEdges(curr_edge.NodeB, graph.FORWARD, graph.ADJACENT_EDGES, func(ref graph.EdgeRef) {
+			turn_cost := explorer.GetTurnCost(curr_ref, curr_edge.NodeB, ref)
+			if turn_cost >= comps.TURN_RESTRICTED {
+				return
+			}
+			other_flag := edge_flags.Get(ref.EdgeID)
+			if other_flag.Count() >= k || other_flag.HasSource(curr_source) {
+				return
+			}
+			new_length := curr_dist + explorer.GetEdgeWeight(ref) + turn_cost
+			if new_length > max_range {
+				return
+			}
+			heap.Enqueue(KPQItem{ref.EdgeID, curr_source, new_length}, new_length)
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package knearest
+
+import (
+	"testing"
+
+	"github.com/ttpr0/go-routing/comps"
+	"github.com/ttpr0/go-routing/geo"
+	"github.com/ttpr0/go-routing/graph"
+	"github.com/ttpr0/go-routing/structs"
+	. "github.com/ttpr0/go-routing/util"
+)
+
+// Creates the square 0-1-2-3 with two-way edges, 0-3 takes 15s all others 10s.
+//
+// If restricted, turning from 0->1 into 1->2 is forbidden.
+func create_turn_graph(restricted bool) graph.IGraph {
+	nodes := Array[structs.Node]{{Loc: geo.Coord{0, 0}}, {Loc: geo.Coord{1, 0}}, {Loc: geo.Coord{1, 1}}, {Loc: geo.Coord{0, 1}}}
+	edges := NewList[structs.Edge](8)
+	weights := NewList[int32](8)
+	for _, e := range [][3]int32{{0, 1, 10}, {1, 2, 10}, {2, 3, 10}, {3, 0, 15}} {
+		edges.Add(structs.Edge{NodeA: e[0], NodeB: e[1]})
+		edges.Add(structs.Edge{NodeA: e[1], NodeB: e[0]})
+		weights.Add(e[2])
+		weights.Add(e[2])
+	}
+	base := comps.NewGraphBase(nodes, Array[structs.Edge](edges))
+	weight := comps.NewTCWeighting(base)
+	for i, w := range weights {
+		weight.SetEdgeWeight(int32(i), w)
+	}
+	if restricted {
+		weight.SetTurnCost(0, 1, 2, comps.TURN_RESTRICTED)
+	}
+	return graph.BuildTCGraph(base, weight)
+}
+
+func TestKManyDijkstraTC(t *testing.T) {
+	// source 0 starts at node 0, source 1 at node 3 with an initial distance of 12
+	sources := List[Array[Tuple[int32, int32]]]{{MakeTuple(int32(0), int32(0))}, {MakeTuple(int32(3), int32(12))}}
+	tests := []struct {
+		name       string
+		restricted bool
+		// (source, distance) of the two nearest sources per node
+		expected [][2][2]int32
+	}{
+		{"unrestricted", false, [][2][2]int32{{{0, 0}, {1, 27}}, {{0, 10}, {1, 32}}, {{0, 20}, {1, 22}}, {{1, 12}, {0, 15}}}},
+		{"restricted", true, [][2][2]int32{{{0, 0}, {1, 27}}, {{0, 10}, {1, 32}}, {{1, 22}, {0, 25}}, {{1, 12}, {0, 15}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			solver := NewKManyDijkstraTC(create_turn_graph(tt.restricted), 100, 2).CreateSolver()
+			solver.CalcKNearestNeighbours(sources)
+			for node, labels := range tt.expected {
+				for k, label := range labels {
+					neighbour := solver.GetNeighbour(int32(node), int8(k))
+					dist := solver.GetDistance(int32(node), int8(k))
+					if neighbour != label[0] || dist != label[1] {
+						t.Errorf("node %v, k %v: expected %v at %v, got %v at %v", node, k, label[0], label[1], neighbour, dist)
+					}
+				}
+			}
+			if neighbour := solver.GetNeighbour(0, 2); neighbour != -1 {
+				t.Errorf("expected no third neighbour, got %v", neighbour)
+			}
+		})
+	}
+}
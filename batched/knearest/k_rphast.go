@@ -0,0 +1,130 @@
+package knearest
+
+import (
+	"github.com/ttpr0/go-routing/graph"
+	"github.com/ttpr0/go-routing/structs"
+	. "github.com/ttpr0/go-routing/util"
+)
+
+// CH based k-nearest neighbours using an upward search per source and a single downward sweep merging the labels.
+//
+// Only nodes reachable from the target nodes by the downward sweep will contain correct labels.
+func NewKRPHAST(g graph.ICHGraph, target_nodes Array[int32], max_range int32, k int) *KRPHAST {
+	return &KRPHAST{
+		g:                 g,
+		max_range:         max_range,
+		k:                 k,
+		down_edges_subset: _TargetSelection(g, target_nodes),
+	}
+}
+
+type KRPHAST struct {
+	g                 graph.ICHGraph
+	max_range         int32
+	k                 int
+	down_edges_subset List[structs.Shortcut]
+}
+
+func (self *KRPHAST) CreateSolver() ISolver {
+	node_flags := NewFlags[KDistFlag](int32(self.g.NodeCount()), KDistFlag{})
+	dist_flags := NewFlags[DistFlag](int32(self.g.NodeCount()), DistFlag{1000000, -1})
+	return &KRPHASTSolver{
+		g:                 self.g,
+		max_range:         self.max_range,
+		k:                 self.k,
+		down_edges_subset: self.down_edges_subset,
+		node_flags:        node_flags,
+		dist_flags:        dist_flags,
+	}
+}
+
+type KRPHASTSolver struct {
+	g                 graph.ICHGraph
+	max_range         int32
+	k                 int
+	down_edges_subset List[structs.Shortcut]
+	node_flags        Flags[KDistFlag]
+	dist_flags        Flags[DistFlag]
+}
+
+func (self *KRPHASTSolver) CalcKNearestNeighbours(sources List[Array[Tuple[int32, int32]]]) error {
+	self.node_flags.Reset()
+	_CalcKRPHAST(self.g, sources, self.node_flags, self.dist_flags, self.max_range, self.k, self.down_edges_subset)
+	return nil
+}
+
+func (self *KRPHASTSolver) GetNeighbour(node int32, k int8) int32 {
+	label := self.node_flags.Get(node).Get(k)
+	if !label.HasValue() {
+		return -1
+	}
+	return label.Value.Source
+}
+func (self *KRPHASTSolver) GetDistance(node int32, k int8) int32 {
+	label := self.node_flags.Get(node).Get(k)
+	if !label.HasValue() {
+		return 1000000
+	}
+	return label.Value.Dist
+}
+
+func _CalcKRPHAST(g graph.ICHGraph, sources List[Array[Tuple[int32, int32]]], node_flags Flags[KDistFlag], dist_flags Flags[DistFlag], max_range int32, k int, down_edges_subset List[structs.Shortcut]) {
+	explorer := g.GetGraphExplorer()
+
+	// upward search from every source
+	for source_id, source := range sources {
+		dist_flags.Reset()
+		heap := NewPriorityQueue[PQItem, int32](100)
+		for _, item := range source {
+			start := item.A
+			dist := item.B
+			start_flag := dist_flags.Get(start)
+			if start_flag.Dist > dist {
+				start_flag.Dist = dist
+				heap.Enqueue(PQItem{start, dist}, dist)
+			}
+		}
+		for {
+			curr_item, ok := heap.Dequeue()
+			if !ok {
+				break
+			}
+			curr_id := curr_item.item
+			curr_dist := curr_item.dist
+			curr_flag := dist_flags.Get(curr_id)
+			if curr_flag.Dist < curr_dist {
+				continue
+			}
+			node_flags.Get(curr_id).Insert(int32(source_id), curr_dist, k)
+			explorer.ForAdjacentEdges(curr_id, graph.FORWARD, graph.ADJACENT_UPWARDS, func(ref graph.EdgeRef) {
+				other_id := ref.OtherID
+				other_flag := dist_flags.Get(other_id)
+				new_length := curr_dist + explorer.GetEdgeWeight(ref)
+				if new_length > max_range {
+					return
+				}
+				if other_flag.Dist > new_length {
+					other_flag.Dist = new_length
+					heap.Enqueue(PQItem{other_id, new_length}, new_length)
+				}
+			})
+		}
+	}
+
+	// downwards sweep merging the labels
+	for i := 0; i < len(down_edges_subset); i++ {
+		edge := down_edges_subset[i]
+		curr_flag := node_flags.Get(edge.From)
+		if curr_flag.Count() == 0 {
+			continue
+		}
+		other_flag := node_flags.Get(edge.To)
+		for _, label := range curr_flag.labels {
+			new_len := label.Dist + edge.Weight
+			if new_len > max_range {
+				break
+			}
+			other_flag.Insert(label.Source, new_len, k)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package knearest
+
+import (
+	"testing"
+
+	"github.com/ttpr0/go-routing/comps"
+	"github.com/ttpr0/go-routing/geo"
+	"github.com/ttpr0/go-routing/graph"
+	"github.com/ttpr0/go-routing/preproc"
+	"github.com/ttpr0/go-routing/structs"
+	. "github.com/ttpr0/go-routing/util"
+)
+
+// Creates a size x size grid with two-way edges of varying weights.
+//
+// Returns the contracted graph and the same (reordered) graph without contraction.
+func create_ch_grid(size int) (graph.ICHGraph, graph.IGraph) {
+	nodes := NewArray[structs.Node](size * size)
+	for i := 0; i < size*size; i++ {
+		nodes[i] = structs.Node{Loc: geo.Coord{float32(i % size), float32(i / size)}}
+	}
+	edges := NewList[structs.Edge](4 * size * size)
+	weights := NewList[int32](4 * size * size)
+	add_edge := func(a, b int, w int32) {
+		edges.Add(structs.Edge{NodeA: int32(a), NodeB: int32(b)})
+		edges.Add(structs.Edge{NodeA: int32(b), NodeB: int32(a)})
+		weights.Add(w)
+		weights.Add(w + 1)
+	}
+	for i := 0; i < size*size; i++ {
+		if i%size+1 < size {
+			add_edge(i, i+1, int32(10+(i*7)%13))
+		}
+		if i+size < size*size {
+			add_edge(i, i+size, int32(10+(i*11)%17))
+		}
+	}
+	base := comps.NewGraphBase(nodes, Array[structs.Edge](edges))
+	weight := comps.NewDefaultWeighting(base)
+	for i, w := range weights {
+		weight.SetEdgeWeight(int32(i), w)
+	}
+
+	ch := preproc.CalcContraction6(base, weight)
+	ordering := preproc.ComputeLevelOrdering(graph.BuildGraph(base, weight), ch)
+	new_base := comps.ReorderNodes(base, ordering)
+	new_ch := comps.ReorderNodes(ch, ordering)
+	ch_index := preproc.PreparePHASTIndex(new_base, weight, new_ch)
+	return graph.BuildCHGraph(new_base, weight, new_ch, Some(ch_index)), graph.BuildGraph(new_base, weight)
+}
+
+func TestKRPHASTMatchesKManyDijkstra(t *testing.T) {
+	ch_g, g := create_ch_grid(6)
+	targets := NewArray[int32](g.NodeCount())
+	for i := range targets {
+		targets[i] = int32(i)
+	}
+	sources := List[Array[Tuple[int32, int32]]]{
+		{MakeTuple(int32(0), int32(0))},
+		{MakeTuple(int32(14), int32(5))},
+		{},
+		{MakeTuple(int32(35), int32(0)), MakeTuple(int32(20), int32(30))},
+	}
+	for _, max_range := range []int32{40, 1000} {
+		// distances of every single source, sources at equal distances can be ordered either way
+		source_dists := NewList[ISolver](sources.Length())
+		for i := range sources {
+			single := NewList[Array[Tuple[int32, int32]]](sources.Length())
+			for j := range sources {
+				if i == j {
+					single.Add(sources[j])
+				} else {
+					single.Add(Array[Tuple[int32, int32]]{})
+				}
+			}
+			solver := NewKManyDijkstra(g, max_range, 1).CreateSolver()
+			solver.CalcKNearestNeighbours(single)
+			source_dists.Add(solver)
+		}
+		for _, k := range []int{1, 2, 3} {
+			rphast := NewKRPHAST(ch_g, targets, max_range, k).CreateSolver()
+			rphast.CalcKNearestNeighbours(sources)
+			dijkstra := NewKManyDijkstra(g, max_range, k).CreateSolver()
+			dijkstra.CalcKNearestNeighbours(sources)
+			for _, node := range targets {
+				for j := 0; j < k; j++ {
+					d1, d2 := rphast.GetDistance(node, int8(j)), dijkstra.GetDistance(node, int8(j))
+					if d1 != d2 {
+						t.Errorf("range %v, k %v: node %v at %v: K-RPHAST distance %v, K-Many-Dijkstra %v", max_range, k, node, j, d1, d2)
+					}
+					n := rphast.GetNeighbour(node, int8(j))
+					if n == -1 {
+						if d1 <= max_range {
+							t.Errorf("range %v, k %v: node %v at %v: no neighbour at %v", max_range, k, node, j, d1)
+						}
+						continue
+					}
+					if d := source_dists[n].GetDistance(node, 0); d != d1 {
+						t.Errorf("range %v, k %v: node %v at %v: neighbour %v is at %v, not %v", max_range, k, node, j, n, d, d1)
+					}
+				}
+			}
+		}
+	}
+}
@@ -1,5 +1,11 @@
 package knearest
 
+import (
+	"github.com/ttpr0/go-routing/graph"
+	"github.com/ttpr0/go-routing/structs"
+	. "github.com/ttpr0/go-routing/util"
+)
+
 type DistFlag struct {
 	Dist   int32
 	Source int32
@@ -13,3 +19,115 @@ type PQItem struct {
 	item int32
 	dist int32
 }
+
+type KPQItem struct {
+	item   int32
+	source int32
+	dist   int32
+}
+
+// Stores the k best (source, distance) labels of a node sorted by distance.
+//
+// Every source is contained at most once.
+type KDistFlag struct {
+	labels List[DistFlag]
+}
+
+func (self *KDistFlag) Count() int {
+	return self.labels.Length()
+}
+
+func (self *KDistFlag) HasSource(source int32) bool {
+	for _, label := range self.labels {
+		if label.Source == source {
+			return true
+		}
+	}
+	return false
+}
+
+// Inserts the label if it is among the k best labels of the node.
+//
+// If the source is already contained its distance is updated. Returns true if the labels changed.
+func (self *KDistFlag) Insert(source int32, dist int32, k int) bool {
+	for i, label := range self.labels {
+		if label.Source != source {
+			continue
+		}
+		if label.Dist <= dist {
+			return false
+		}
+		self.labels.Remove(i)
+		break
+	}
+	if self.labels.Length() >= k && self.labels[k-1].Dist <= dist {
+		return false
+	}
+	if self.labels == nil {
+		self.labels = NewList[DistFlag](k)
+	}
+	pos := self.labels.Length()
+	for pos > 0 && self.labels[pos-1].Dist > dist {
+		pos -= 1
+	}
+	self.labels.Add(DistFlag{})
+	copy(self.labels[pos+1:], self.labels[pos:])
+	self.labels[pos] = DistFlag{Dist: dist, Source: source}
+	if self.labels.Length() > k {
+		self.labels = self.labels[:k]
+	}
+	return true
+}
+
+func (self *KDistFlag) Get(k int8) Optional[DistFlag] {
+	if int(k) >= self.labels.Length() || k < 0 {
+		return None[DistFlag]()
+	}
+	return Some(self.labels[k])
+}
+
+func _TargetSelection(g graph.ICHGraph, target_nodes Array[int32]) List[structs.Shortcut] {
+	node_queue := NewQueue[int32]()
+	for i := 0; i < target_nodes.Length(); i++ {
+		if target_nodes[i] == -1 {
+			continue
+		}
+		node_queue.Push(target_nodes[i])
+	}
+
+	// select graph subset by marking visited nodes
+	explorer := g.GetGraphExplorer()
+	graph_subset := NewArray[bool](int(g.NodeCount()))
+	for {
+		if node_queue.Size() == 0 {
+			break
+		}
+		node, _ := node_queue.Pop()
+		if graph_subset[node] {
+			continue
+		}
+		graph_subset[node] = true
+		node_level := g.GetNodeLevel(node)
+		explorer.ForAdjacentEdges(node, graph.BACKWARD, graph.ADJACENT_ALL, func(ref graph.EdgeRef) {
+			if graph_subset[ref.OtherID] {
+				return
+			}
+			if node_level >= g.GetNodeLevel(ref.OtherID) {
+				return
+			}
+			node_queue.Push(ref.OtherID)
+		})
+	}
+	// selecting subset of downward edges for linear sweep
+	down_edges_subset := NewList[structs.Shortcut](target_nodes.Length())
+	down_edges, _ := g.GetDownEdges(graph.FORWARD)
+	for i := 0; i < len(down_edges); i++ {
+		edge := down_edges[i]
+		if !graph_subset[edge.From] {
+			continue
+		}
+		down_edges_subset.Add(edge)
+	}
+
+	return down_edges_subset
+}
@@ -0,0 +1,183 @@
+package nearest
+
+import (
+	"github.com/ttpr0/go-routing/attr"
+	"github.com/ttpr0/go-routing/comps"
+	"github.com/ttpr0/go-routing/geo"
+	"github.com/ttpr0/go-routing/graph"
+	. "github.com/ttpr0/go-routing/util"
+)
+
+// Edge-based variant of AvoidManyDijkstra respecting the turn-costs of the graph.
+//
+// Edges are labeled instead of nodes, the nearest source of a node is the one of its closest incoming edge.
+func NewAvoidManyDijkstraTC(g graph.IGraph, max_range int32, att attr.IAttributes, avoid_roads Optional[[]attr.RoadType], avoid_features Optional[[]attr.AvoidFeature], avoid_areas Optional[geo.Feature]) *AvoidManyDijkstraTC {
+	return &AvoidManyDijkstraTC{
+		g:              g,
+		max_range:      max_range,
+		att:            att,
+		avoid_roads:    avoid_roads,
+		avoid_features: avoid_features,
+		avoid_areas:    avoid_areas,
+	}
+}
+
+type AvoidManyDijkstraTC struct {
+	g              graph.IGraph
+	max_range      int32
+	att            attr.IAttributes
+	avoid_roads    Optional[[]attr.RoadType]
+	avoid_features Optional[[]attr.AvoidFeature]
+	avoid_areas    Optional[geo.Feature]
+}
+
+func (self *AvoidManyDijkstraTC) CreateSolver() ISolver {
+	node_flags := NewFlags[DistFlag](int32(self.g.NodeCount()), DistFlag{1000000, -1})
+	edge_flags := NewFlags[DistFlag](int32(self.g.EdgeCount()), DistFlag{1000000, -1})
+	return &AvoidManyDijkstraTCSolver{
+		g:              self.g,
+		node_flags:     node_flags,
+		edge_flags:     edge_flags,
+		max_range:      self.max_range,
+		att:            self.att,
+		avoid_roads:    self.avoid_roads,
+		avoid_features: self.avoid_features,
+		avoid_areas:    self.avoid_areas,
+	}
+}
+
+type AvoidManyDijkstraTCSolver struct {
+	g              graph.IGraph
+	node_flags     Flags[DistFlag]
+	edge_flags     Flags[DistFlag]
+	max_range      int32
+	att            attr.IAttributes
+	avoid_roads    Optional[[]attr.RoadType]
+	avoid_features Optional[[]attr.AvoidFeature]
+	avoid_areas    Optional[geo.Feature]
+}
+
+func (self *AvoidManyDijkstraTCSolver) CalcNearestNeighbours(sources List[Array[Tuple[int32, int32]]]) error {
+	self.node_flags.Reset()
+	self.edge_flags.Reset()
+	var avoid_geom Optional[geo.Geometry]
+	if self.avoid_areas.HasValue() {
+		avoid_geom = Some(self.avoid_areas.Value.Geometry())
+	} else {
+		avoid_geom = None[geo.Geometry]()
+	}
+	_CalcAvoidManyDijkstraTC(self.g, sources, self.node_flags, self.edge_flags, self.max_range, self.att, self.avoid_roads, self.avoid_features, avoid_geom)
+	return nil
+}
+
+func (self *AvoidManyDijkstraTCSolver) GetNeighbour(node int32) int32 {
+	flag := self.node_flags.Get(node)
+	return flag.Source
+}
+func (self *AvoidManyDijkstraTCSolver) GetDistance(node int32) int32 {
+	flag := self.node_flags.Get(node)
+	return flag.Dist
+}
+
+func _CalcAvoidManyDijkstraTC(g graph.IGraph, sources List[Array[Tuple[int32, int32]]], node_flags Flags[DistFlag], edge_flags Flags[DistFlag], max_range int32, att attr.IAttributes, avoid_roads Optional[[]attr.RoadType], avoid_features Optional[[]attr.AvoidFeature], avoid_geom Optional[geo.Geometry]) {
+	heap := NewPriorityQueue[PQItem, int32](100)
+	explorer := g.GetGraphExplorer()
+
+	temp_point := geo.NewPoint(geo.Coord{0, 0})
+	is_avoided := func(ref graph.EdgeRef) bool {
+		if avoid_geom.HasValue() {
+			coord := g.GetNodeGeom(ref.OtherID)
+			temp_point.SetCoordinates(coord)
+			if avoid_geom.Value.Contains(&temp_point) {
+				return true
+			}
+		}
+		if avoid_roads.HasValue() {
+			edge_attr := att.GetEdgeAttribs(ref.EdgeID)
+			if Contains(avoid_roads.Value, edge_attr.Type) {
+				return true
+			}
+		}
+		if avoid_features.HasValue() {
+			if attr.HasAnyFeature(att, ref.EdgeID, ref.OtherID, avoid_features.Value) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for source_id, source := range sources {
+		for _, item := range source {
+			start := item.A
+			if avoid_geom.HasValue() {
+				coord := g.GetNodeGeom(start)
+				temp_point.SetCoordinates(coord)
+				if avoid_geom.Value.Contains(&temp_point) {
+					continue
+				}
+			}
+			dist := item.B
+			start_flag := node_flags.Get(start)
+			if start_flag.Dist > dist {
+				start_flag.Dist = dist
+				start_flag.Source = int32(source_id)
+			}
+			explorer.ForAdjacentEdges(start, graph.FORWARD, graph.ADJACENT_EDGES, func(ref graph.EdgeRef) {
+				if is_avoided(ref) {
+					return
+				}
+				edge_dist := explorer.GetEdgeWeight(ref) + dist
+				if edge_dist > max_range {
+					return
+				}
+				edge_flag := edge_flags.Get(ref.EdgeID)
+				if edge_flag.Dist <= edge_dist {
+					return
+				}
+				edge_flag.Dist = edge_dist
+				edge_flag.Source = int32(source_id)
+				heap.Enqueue(PQItem{ref.EdgeID, edge_dist}, edge_dist)
+			})
+		}
+	}
+
+	for {
+		curr_item, ok := heap.Dequeue()
+		if !ok {
+			break
+		}
+		curr_id := curr_item.item
+		curr_dist := curr_item.dist
+		curr_flag := edge_flags.Get(curr_id)
+		if curr_flag.Dist < curr_dist {
+			continue
+		}
+		curr_edge := g.GetEdge(curr_id)
+		// edges are settled in order of their distance, the first one settled at a node reaches it first
+		node_flag := node_flags.Get(curr_edge.NodeB)
+		if node_flag.Dist > curr_flag.Dist {
+			node_flag.Dist = curr_flag.Dist
+			node_flag.Source = curr_flag.Source
+		}
+		curr_ref := graph.EdgeRef{EdgeID: curr_id, Type: 0, OtherID: curr_edge.NodeB}
+		explorer.ForAdjacentEdges(curr_edge.NodeB, graph.FORWARD, graph.ADJACENT_EDGES, func(ref graph.EdgeRef) {
+			turn_cost := explorer.GetTurnCost(curr_ref, curr_edge.NodeB, ref)
+			if turn_cost >= comps.TURN_RESTRICTED {
+				return
+			}
+			if is_avoided(ref) {
+				return
+			}
+			new_length := curr_flag.Dist + explorer.GetEdgeWeight(ref) + turn_cost
+			if new_length > max_range {
+				return
+			}
+			other_flag := edge_flags.Get(ref.EdgeID)
+			if other_flag.Dist > new_length {
+				other_flag.Dist = new_length
+				other_flag.Source = curr_flag.Source
+				heap.Enqueue(PQItem{ref.EdgeID, new_length}, new_length)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package nearest
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/ttpr0/go-routing/attr"
+	"github.com/ttpr0/go-routing/comps"
+	"github.com/ttpr0/go-routing/geo"
+	"github.com/ttpr0/go-routing/graph"
+	"github.com/ttpr0/go-routing/structs"
+	. "github.com/ttpr0/go-routing/util"
+)
+
+// Creates the square 0-1-2-3 with two-way edges, 0-3 takes 15s all others 10s.
+//
+// If restricted, turning from 0->1 into 1->2 is forbidden.
+func create_turn_graph(restricted bool) graph.IGraph {
+	nodes := Array[structs.Node]{{Loc: geo.Coord{0, 0}}, {Loc: geo.Coord{1, 0}}, {Loc: geo.Coord{1, 1}}, {Loc: geo.Coord{0, 1}}}
+	edges := NewList[structs.Edge](8)
+	weights := NewList[int32](8)
+	for _, e := range [][3]int32{{0, 1, 10}, {1, 2, 10}, {2, 3, 10}, {3, 0, 15}} {
+		edges.Add(structs.Edge{NodeA: e[0], NodeB: e[1]})
+		edges.Add(structs.Edge{NodeA: e[1], NodeB: e[0]})
+		weights.Add(e[2])
+		weights.Add(e[2])
+	}
+	base := comps.NewGraphBase(nodes, Array[structs.Edge](edges))
+	weight := comps.NewTCWeighting(base)
+	for i, w := range weights {
+		weight.SetEdgeWeight(int32(i), w)
+	}
+	if restricted {
+		weight.SetTurnCost(0, 1, 2, comps.TURN_RESTRICTED)
+	}
+	return graph.BuildTCGraph(base, weight)
+}
+
+func TestAvoidManyDijkstraTC(t *testing.T) {
+	// source 0 starts at node 0, source 1 at node 3 with an initial distance of 12
+	sources := List[Array[Tuple[int32, int32]]]{{MakeTuple(int32(0), int32(0))}, {MakeTuple(int32(3), int32(12))}}
+	tests := []struct {
+		name       string
+		restricted bool
+		neighbours []int32
+		distances  []int32
+	}{
+		{"unrestricted", false, []int32{0, 0, 0, 1}, []int32{0, 10, 20, 12}},
+		{"restricted", true, []int32{0, 0, 1, 1}, []int32{0, 10, 22, 12}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alg := NewAvoidManyDijkstraTC(create_turn_graph(tt.restricted), 100, nil, None[[]attr.RoadType](), None[[]attr.AvoidFeature](), None[geo.Feature]())
+			solver := alg.CreateSolver()
+			solver.CalcNearestNeighbours(sources)
+			neighbours := make([]int32, 4)
+			distances := make([]int32, 4)
+			for i := int32(0); i < 4; i++ {
+				neighbours[i] = solver.GetNeighbour(i)
+				distances[i] = solver.GetDistance(i)
+			}
+			if !slices.Equal(neighbours, tt.neighbours) || !slices.Equal(distances, tt.distances) {
+				t.Errorf("expected neighbours %v at %v, got %v at %v", tt.neighbours, tt.distances, neighbours, distances)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package nearest
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/ttpr0/go-routing/attr"
+	"github.com/ttpr0/go-routing/geo"
+	. "github.com/ttpr0/go-routing/util"
+)
+
+func TestAvoidManyDijkstra(t *testing.T) {
+	// source 0 starts at node 0, source 1 at node 3 with an initial distance of 12
+	sources := List[Array[Tuple[int32, int32]]]{{MakeTuple(int32(0), int32(0))}, {MakeTuple(int32(3), int32(12))}}
+	// polygon around node 1
+	area := geo.NewPolygon([][]geo.Coord{{{0.5, -0.5}, {1.5, -0.5}, {1.5, 0.5}, {0.5, 0.5}, {0.5, -0.5}}})
+	tests := []struct {
+		name       string
+		avoid_area Optional[geo.Feature]
+		neighbours []int32
+		distances  []int32
+	}{
+		{"no avoid options", None[geo.Feature](), []int32{0, 0, 0, 1}, []int32{0, 10, 20, 12}},
+		{"avoid area", Some(geo.NewFeature(&area, nil)), []int32{0, -1, 1, 1}, []int32{0, 1000000, 22, 12}},
+	}
+	g := create_turn_graph(false)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			solver := NewAvoidManyDijkstra(g, 100, nil, None[[]attr.RoadType](), None[[]attr.AvoidFeature](), tt.avoid_area).CreateSolver()
+			solver.CalcNearestNeighbours(sources)
+			neighbours := make([]int32, 4)
+			distances := make([]int32, 4)
+			for i := int32(0); i < 4; i++ {
+				neighbours[i] = solver.GetNeighbour(i)
+				distances[i] = solver.GetDistance(i)
+			}
+			if !slices.Equal(neighbours, tt.neighbours) || !slices.Equal(distances, tt.distances) {
+				t.Errorf("expected neighbours %v at %v, got %v at %v", tt.neighbours, tt.distances, neighbours, distances)
+			}
+			if tt.avoid_area.HasValue() {
+				return
+			}
+			// without avoid options the result equals the many-dijkstra
+			many := NewManyDijkstra(g, 100).CreateSolver()
+			many.CalcNearestNeighbours(sources)
+			for i := int32(0); i < 4; i++ {
+				if many.GetNeighbour(i) != neighbours[i] || many.GetDistance(i) != distances[i] {
+					t.Errorf("node %v: many-dijkstra found %v at %v", i, many.GetNeighbour(i), many.GetDistance(i))
+				}
+			}
+		})
+	}
+}
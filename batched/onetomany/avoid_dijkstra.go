@@ -7,43 +7,47 @@ import (
 	. "github.com/ttpr0/go-routing/util"
 )
 
-func NewAvoidDijkstra(g graph.IGraph, max_range int32, att attr.IAttributes, avoid_roads Optional[[]attr.RoadType], avoid_areas Optional[geo.Feature]) *AvoidDijkstra {
+func NewAvoidDijkstra(g graph.IGraph, max_range int32, att attr.IAttributes, avoid_roads Optional[[]attr.RoadType], avoid_features Optional[[]attr.AvoidFeature], avoid_areas Optional[geo.Feature]) *AvoidDijkstra {
 	return &AvoidDijkstra{
-		g:           g,
-		max_range:   max_range,
-		att:         att,
-		avoid_roads: avoid_roads,
-		avoid_areas: avoid_areas,
+		g:              g,
+		max_range:      max_range,
+		att:            att,
+		avoid_roads:    avoid_roads,
+		avoid_features: avoid_features,
+		avoid_areas:    avoid_areas,
 	}
 }
 
 type AvoidDijkstra struct {
-	g           graph.IGraph
-	max_range   int32
-	att         attr.IAttributes
-	avoid_roads Optional[[]attr.RoadType]
-	avoid_areas Optional[geo.Feature]
+	g              graph.IGraph
+	max_range      int32
+	att            attr.IAttributes
+	avoid_roads    Optional[[]attr.RoadType]
+	avoid_features Optional[[]attr.AvoidFeature]
+	avoid_areas    Optional[geo.Feature]
 }
 
 func (self *AvoidDijkstra) CreateSolver() ISolver {
 	node_flags := NewFlags[DistFlag](int32(self.g.NodeCount()), DistFlag{1000000})
 	return &AvoidDijkstraSolver{
-		g:           self.g,
-		node_flags:  node_flags,
-		max_range:   self.max_range,
-		att:         self.att,
-		avoid_roads: self.avoid_roads,
-		avoid_areas: self.avoid_areas,
+		g:              self.g,
+		node_flags:     node_flags,
+		max_range:      self.max_range,
+		att:            self.att,
+		avoid_roads:    self.avoid_roads,
+		avoid_features: self.avoid_features,
+		avoid_areas:    self.avoid_areas,
 	}
 }
 
 type AvoidDijkstraSolver struct {
-	g           graph.IGraph
-	node_flags  Flags[DistFlag]
-	max_range   int32
-	att         attr.IAttributes
-	avoid_roads Optional[[]attr.RoadType]
-	avoid_areas Optional[geo.Feature]
+	g              graph.IGraph
+	node_flags     Flags[DistFlag]
+	max_range      int32
+	att            attr.IAttributes
+	avoid_roads    Optional[[]attr.RoadType]
+	avoid_features Optional[[]attr.AvoidFeature]
+	avoid_areas    Optional[geo.Feature]
 }
 
 // CalcDiatanceFromStarts implements ISolver.
@@ -55,7 +59,7 @@ func (self *AvoidDijkstraSolver) CalcDistanceFromStart(starts Array[Tuple[int32,
 	} else {
 		avoid_geom = None[geo.Geometry]()
 	}
-	_CalcAvoidDijkstra(self.g, starts, self.node_flags, self.max_range, self.att, self.avoid_roads, avoid_geom)
+	_CalcAvoidDijkstra(self.g, starts, self.node_flags, self.max_range, self.att, self.avoid_roads, self.avoid_features, avoid_geom)
 	return nil
 }
 
@@ -64,7 +68,7 @@ func (self *AvoidDijkstraSolver) GetDistance(node int32) int32 {
 	return self.node_flags.Get(node).Dist
 }
 
-func _CalcAvoidDijkstra(g graph.IGraph, starts Array[Tuple[int32, int32]], node_flags Flags[DistFlag], max_range int32, att attr.IAttributes, avoid_roads Optional[[]attr.RoadType], avoid_geom Optional[geo.Geometry]) {
+func _CalcAvoidDijkstra(g graph.IGraph, starts Array[Tuple[int32, int32]], node_flags Flags[DistFlag], max_range int32, att attr.IAttributes, avoid_roads Optional[[]attr.RoadType], avoid_features Optional[[]attr.AvoidFeature], avoid_geom Optional[geo.Geometry]) {
 	heap := NewPriorityQueue[PQItem, int32](100)
 	explorer := g.GetGraphExplorer()
 
@@ -111,6 +115,11 @@ func _CalcAvoidDijkstra(g graph.IGraph, starts Array[Tuple[int32, int32]], node_
 					return
 				}
 			}
+			if avoid_features.HasValue() {
+				if attr.HasAnyFeature(att, ref.EdgeID, other_id, avoid_features.Value) {
+					return
+				}
+			}
 			other_flag := node_flags.Get(other_id)
 			new_length := curr_flag.Dist + explorer.GetEdgeWeight(ref)
 			if new_length > max_range {
@@ -0,0 +1,177 @@
+package onetomany
+
+import (
+	"github.com/ttpr0/go-routing/attr"
+	"github.com/ttpr0/go-routing/comps"
+	"github.com/ttpr0/go-routing/geo"
+	"github.com/ttpr0/go-routing/graph"
+	. "github.com/ttpr0/go-routing/util"
+)
+
+// Edge-based variant of AvoidDijkstra respecting the turn-costs of the graph.
+//
+// Edges are labeled instead of nodes, the distance of a node is the minimum distance of its incoming edges.
+func NewAvoidDijkstraTC(g graph.IGraph, max_range int32, att attr.IAttributes, avoid_roads Optional[[]attr.RoadType], avoid_features Optional[[]attr.AvoidFeature], avoid_areas Optional[geo.Feature]) *AvoidDijkstraTC {
+	return &AvoidDijkstraTC{
+		g:              g,
+		max_range:      max_range,
+		att:            att,
+		avoid_roads:    avoid_roads,
+		avoid_features: avoid_features,
+		avoid_areas:    avoid_areas,
+	}
+}
+
+type AvoidDijkstraTC struct {
+	g              graph.IGraph
+	max_range      int32
+	att            attr.IAttributes
+	avoid_roads    Optional[[]attr.RoadType]
+	avoid_features Optional[[]attr.AvoidFeature]
+	avoid_areas    Optional[geo.Feature]
+}
+
+func (self *AvoidDijkstraTC) CreateSolver() ISolver {
+	node_flags := NewFlags[DistFlag](int32(self.g.NodeCount()), DistFlag{1000000})
+	edge_flags := NewFlags[DistFlag](int32(self.g.EdgeCount()), DistFlag{1000000})
+	return &AvoidDijkstraTCSolver{
+		g:              self.g,
+		node_flags:     node_flags,
+		edge_flags:     edge_flags,
+		max_range:      self.max_range,
+		att:            self.att,
+		avoid_roads:    self.avoid_roads,
+		avoid_features: self.avoid_features,
+		avoid_areas:    self.avoid_areas,
+	}
+}
+
+type AvoidDijkstraTCSolver struct {
+	g              graph.IGraph
+	node_flags     Flags[DistFlag]
+	edge_flags     Flags[DistFlag]
+	max_range      int32
+	att            attr.IAttributes
+	avoid_roads    Optional[[]attr.RoadType]
+	avoid_features Optional[[]attr.AvoidFeature]
+	avoid_areas    Optional[geo.Feature]
+}
+
+// CalcDiatanceFromStarts implements ISolver.
+func (self *AvoidDijkstraTCSolver) CalcDistanceFromStart(starts Array[Tuple[int32, int32]]) error {
+	self.node_flags.Reset()
+	self.edge_flags.Reset()
+	var avoid_geom Optional[geo.Geometry]
+	if self.avoid_areas.HasValue() {
+		avoid_geom = Some(self.avoid_areas.Value.Geometry())
+	} else {
+		avoid_geom = None[geo.Geometry]()
+	}
+	_CalcAvoidDijkstraTC(self.g, starts, self.node_flags, self.edge_flags, self.max_range, self.att, self.avoid_roads, self.avoid_features, avoid_geom)
+	return nil
+}
+
+// GetDistance implements ISolver.
+func (self *AvoidDijkstraTCSolver) GetDistance(node int32) int32 {
+	return self.node_flags.Get(node).Dist
+}
+
+func _CalcAvoidDijkstraTC(g graph.IGraph, starts Array[Tuple[int32, int32]], node_flags Flags[DistFlag], edge_flags Flags[DistFlag], max_range int32, att attr.IAttributes, avoid_roads Optional[[]attr.RoadType], avoid_features Optional[[]attr.AvoidFeature], avoid_geom Optional[geo.Geometry]) {
+	heap := NewPriorityQueue[PQItem, int32](100)
+	explorer := g.GetGraphExplorer()
+
+	temp_point := geo.NewPoint(geo.Coord{0, 0})
+	is_avoided := func(ref graph.EdgeRef) bool {
+		if avoid_geom.HasValue() {
+			coord := g.GetNodeGeom(ref.OtherID)
+			temp_point.SetCoordinates(coord)
+			if avoid_geom.Value.Contains(&temp_point) {
+				return true
+			}
+		}
+		if avoid_roads.HasValue() {
+			edge_attr := att.GetEdgeAttribs(ref.EdgeID)
+			if Contains(avoid_roads.Value, edge_attr.Type) {
+				return true
+			}
+		}
+		if avoid_features.HasValue() {
+			if attr.HasAnyFeature(att, ref.EdgeID, ref.OtherID, avoid_features.Value) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, item := range starts {
+		start := item.A
+		if avoid_geom.HasValue() {
+			coord := g.GetNodeGeom(start)
+			temp_point.SetCoordinates(coord)
+			if avoid_geom.Value.Contains(&temp_point) {
+				continue
+			}
+		}
+		dist := item.B
+		start_flag := node_flags.Get(start)
+		if start_flag.Dist > dist {
+			start_flag.Dist = dist
+		}
+		explorer.ForAdjacentEdges(start, graph.FORWARD, graph.ADJACENT_EDGES, func(ref graph.EdgeRef) {
+			if is_avoided(ref) {
+				return
+			}
+			edge_dist := explorer.GetEdgeWeight(ref) + dist
+			if edge_dist > max_range {
+				return
+			}
+			edge_flag := edge_flags.Get(ref.EdgeID)
+			if edge_flag.Dist <= edge_dist {
+				return
+			}
+			edge_flag.Dist = edge_dist
+			heap.Enqueue(PQItem{ref.EdgeID, edge_dist}, edge_dist)
+			node_flag := node_flags.Get(ref.OtherID)
+			if edge_dist < node_flag.Dist {
+				node_flag.Dist = edge_dist
+			}
+		})
+	}
+
+	for {
+		curr_item, ok := heap.Dequeue()
+		if !ok {
+			break
+		}
+		curr_id := curr_item.item
+		curr_dist := curr_item.dist
+		curr_flag := edge_flags.Get(curr_id)
+		if curr_flag.Dist < curr_dist {
+			continue
+		}
+		curr_edge := g.GetEdge(curr_id)
+		curr_ref := graph.EdgeRef{EdgeID: curr_id, Type: 0, OtherID: curr_edge.NodeB}
+		explorer.ForAdjacentEdges(curr_edge.NodeB, graph.FORWARD, graph.ADJACENT_EDGES, func(ref graph.EdgeRef) {
+			turn_cost := explorer.GetTurnCost(curr_ref, curr_edge.NodeB, ref)
+			if turn_cost >= comps.TURN_RESTRICTED {
+				return
+			}
+			if is_avoided(ref) {
+				return
+			}
+			new_length := curr_flag.Dist + explorer.GetEdgeWeight(ref) + turn_cost
+			if new_length > max_range {
+				return
+			}
+			other_flag := edge_flags.Get(ref.EdgeID)
+			if other_flag.Dist > new_length {
+				other_flag.Dist = new_length
+				heap.Enqueue(PQItem{ref.EdgeID, new_length}, new_length)
+				node_flag := node_flags.Get(ref.OtherID)
+				if new_length < node_flag.Dist {
+					node_flag.Dist = new_length
+				}
+			}
+		})
+	}
+}
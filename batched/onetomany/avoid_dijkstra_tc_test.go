@@ -0,0 +1,77 @@
+package onetomany
+
+import (
+	"testing"
+
+	"github.com/ttpr0/go-routing/attr"
+	"github.com/ttpr0/go-routing/comps"
+	"github.com/ttpr0/go-routing/geo"
+	"github.com/ttpr0/go-routing/graph"
+	"github.com/ttpr0/go-routing/structs"
+	. "github.com/ttpr0/go-routing/util"
+)
+
+// Creates the square 0-1-2-3 with two-way edges, 0-3 takes 15s all others 10s.
+//
+// If restricted, turning from 0->1 into 1->2 is forbidden.
+func create_turn_graph(restricted bool) graph.IGraph {
+	nodes := Array[structs.Node]{{Loc: geo.Coord{0, 0}}, {Loc: geo.Coord{1, 0}}, {Loc: geo.Coord{1, 1}}, {Loc: geo.Coord{0, 1}}}
+	edges := NewList[structs.Edge](8)
+	weights := NewList[int32](8)
+	for _, e := range [][3]int32{{0, 1, 10}, {1, 2, 10}, {2, 3, 10}, {3, 0, 15}} {
+		edges.Add(structs.Edge{NodeA: e[0], NodeB: e[1]})
+		edges.Add(structs.Edge{NodeA: e[1], NodeB: e[0]})
+		weights.Add(e[2])
+		weights.Add(e[2])
+	}
+	base := comps.NewGraphBase(nodes, Array[structs.Edge](edges))
+	weight := comps.NewTCWeighting(base)
+	for i, w := range weights {
+		weight.SetEdgeWeight(int32(i), w)
+	}
+	if restricted {
+		weight.SetTurnCost(0, 1, 2, comps.TURN_RESTRICTED)
+	}
+	return graph.BuildTCGraph(base, weight)
+}
+
+func TestAvoidDijkstraTC(t *testing.T) {
+	// polygon around node 3
+	area := geo.NewPolygon([][]geo.Coord{{{-0.5, 0.5}, {0.5, 0.5}, {0.5, 1.5}, {-0.5, 1.5}, {-0.5, 0.5}}})
+	tests := []struct {
+		name       string
+		restricted bool
+		max_range  int32
+		avoid_area Optional[geo.Feature]
+		expected   []int32
+	}{
+		{"unrestricted", false, 100, None[geo.Feature](), []int32{0, 10, 20, 15}},
+		{"restricted", true, 100, None[geo.Feature](), []int32{0, 10, 25, 15}},
+		{"max range", true, 20, None[geo.Feature](), []int32{0, 10, 1000000, 15}},
+		{"avoid area", true, 100, Some(geo.NewFeature(&area, nil)), []int32{0, 10, 1000000, 1000000}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := create_turn_graph(tt.restricted)
+			alg := NewAvoidDijkstraTC(g, tt.max_range, nil, None[[]attr.RoadType](), None[[]attr.AvoidFeature](), tt.avoid_area)
+			solver := alg.CreateSolver()
+			solver.CalcDistanceFromStart(Array[Tuple[int32, int32]]{MakeTuple(int32(0), int32(0))})
+			for node, expected := range tt.expected {
+				if dist := solver.GetDistance(int32(node)); dist != expected {
+					t.Errorf("node %v: expected %v, got %v", node, expected, dist)
+				}
+			}
+			if tt.avoid_area.HasValue() {
+				return
+			}
+			// without avoid options the distances equal the range-dijkstra
+			range_solver := NewRangeDijkstraTC(g, tt.max_range).CreateSolver()
+			range_solver.CalcDistanceFromStart(Array[Tuple[int32, int32]]{MakeTuple(int32(0), int32(0))})
+			for node := range tt.expected {
+				if a, b := solver.GetDistance(int32(node)), range_solver.GetDistance(int32(node)); a != b {
+					t.Errorf("node %v: avoid-dijkstra %v, range-dijkstra %v", node, a, b)
+				}
+			}
+		})
+	}
+}
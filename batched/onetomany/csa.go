@@ -0,0 +1,223 @@
+package onetomany
+
+import (
+	"slices"
+
+	"github.com/ttpr0/go-routing/graph"
+	. "github.com/ttpr0/go-routing/util"
+)
+
+// Creates a connection-scan computing the minimum travel-times of all departures within the time-window [from, to].
+//
+// All connections departing within [from, to+max_range] are collected into a departure-sorted array once and shared by all solvers.
+func NewCSA(g *graph.TransitGraph, max_range int32, from, to int32) *CSA {
+	explorer := g.GetTransitExplorer()
+	connections := NewList[csa_connection](g.ConnectionCount())
+	trip_count := int32(0)
+	for i := 0; i < g.StopCount(); i++ {
+		explorer.ForAdjacentEdges(int32(i), graph.FORWARD, graph.ADJACENT_ALL, func(ref graph.EdgeRef) {
+			if ref.IsShortcut() {
+				return
+			}
+			for _, w := range explorer.GetConnectionWeights(ref, from, to+max_range) {
+				connections.Add(csa_connection{
+					departure: w.Departure,
+					arrival:   w.Arrival,
+					from:      int32(i),
+					to:        ref.OtherID,
+					trip:      w.Trip,
+				})
+				trip_count = max(trip_count, w.Trip+1)
+			}
+		})
+	}
+	slices.SortFunc(connections, func(a, b csa_connection) int {
+		if a.departure != b.departure {
+			return int(a.departure - b.departure)
+		}
+		return int(a.arrival - b.arrival)
+	})
+	return &CSA{
+		g:           g,
+		connections: Array[csa_connection](connections),
+		trip_count:  trip_count,
+		max_range:   max_range,
+		from:        from,
+		to:          to,
+	}
+}
+
+type CSA struct {
+	g           *graph.TransitGraph
+	connections Array[csa_connection]
+	trip_count  int32
+	max_range   int32
+	from        int32
+	to          int32
+}
+
+type csa_connection struct {
+	departure int32
+	arrival   int32
+	from      int32
+	to        int32
+	trip      int32
+}
+
+func (self *CSA) CreateSolver() ISolver {
+	node_flags := NewFlags[DistFlag](int32(self.g.NodeCount()), DistFlag{1000000})
+	edge_flags := NewFlags[DistFlag](int32(self.g.EdgeCount()), DistFlag{1000000})
+	stop_flags := NewFlags[TransitFlag](int32(self.g.StopCount()), TransitFlag{})
+	board_flags := NewFlags[TransitFlag](int32(self.g.StopCount()), TransitFlag{})
+	return &CSASolver{
+		g:           self.g,
+		connections: self.connections,
+		node_flags:  node_flags,
+		edge_flags:  edge_flags,
+		stop_flags:  stop_flags,
+		board_flags: board_flags,
+		trip_starts: NewArray[int32](int(self.trip_count)),
+		max_range:   self.max_range,
+		from:        self.from,
+		to:          self.to,
+	}
+}
+
+type CSASolver struct {
+	g           *graph.TransitGraph
+	connections Array[csa_connection]
+	node_flags  Flags[DistFlag]
+	edge_flags  Flags[DistFlag]
+	stop_flags  Flags[TransitFlag]
+	// labels for boarding other vehicles (including the transfer-time of stops reached by a ride)
+	board_flags Flags[TransitFlag]
+	// latest departure at the start every trip can be reached with
+	trip_starts Array[int32]
+	max_range   int32
+	from        int32
+	to          int32
+}
+
+// CalcDiatanceFromStarts implements ISolver.
+func (self *CSASolver) CalcDistanceFromStart(starts Array[Tuple[int32, int32]]) error {
+	self.node_flags.Reset()
+	self.edge_flags.Reset()
+	self.stop_flags.Reset()
+	self.board_flags.Reset()
+	for i := 0; i < self.trip_starts.Length(); i++ {
+		self.trip_starts[i] = -1000000000
+	}
+	_CalcCSA(self.g, starts, self.connections, self.node_flags, self.edge_flags, self.stop_flags, self.board_flags, self.trip_starts, self.max_range, self.from, self.to)
+	return nil
+}
+
+// GetDistance implements ISolver.
+func (self *CSASolver) GetDistance(node int32) int32 {
+	return self.node_flags.Get(node).Dist
+}
+
+// computes one-to-many distances using a profile connection-scan
+//
+// Every stop keeps a pareto-set of (arrival, departure at start) labels.
+func _CalcCSA(g *graph.TransitGraph, starts Array[Tuple[int32, int32]], connections Array[csa_connection], node_flags Flags[DistFlag], edge_flags Flags[DistFlag], stop_flags, board_flags Flags[TransitFlag], trip_starts Array[int32], max_range int32, from, to int32) {
+	// step 1: range-dijkstra from start
+	_CalcRangeDijkstraTC(g, starts, node_flags, edge_flags, max_range)
+	access := NewArray[int32](g.StopCount())
+	for i := 0; i < g.StopCount(); i++ {
+		access[i] = 1000000000
+		base_node := g.MapStopToNode(int32(i))
+		if base_node == -1 {
+			continue
+		}
+		dist := node_flags.Get(base_node).Dist
+		if dist <= max_range {
+			access[i] = dist
+			// walking to the stop without riding
+			_AddCSALabel(stop_flags, int32(i), to+dist, to)
+			_AddCSALabel(board_flags, int32(i), to+dist, to)
+		}
+	}
+
+	// step 2: scan all connections ordered by departure
+	explorer := g.GetTransitExplorer()
+	for _, conn := range connections {
+		start := trip_starts[conn.trip]
+		// board at a stop reached by walking from the start
+		if walk := access[conn.from]; walk <= max_range && conn.departure-walk >= from {
+			start = max(start, min(conn.departure-walk, to))
+		}
+		// board at a stop reached by transit
+		for _, label := range board_flags.Get(conn.from).trips {
+			if label.A <= conn.departure && label.B > start {
+				start = label.B
+			}
+		}
+		if start < from {
+			continue
+		}
+		trip_starts[conn.trip] = start
+		if conn.arrival-start > max_range {
+			continue
+		}
+		if !_AddCSALabel(stop_flags, conn.to, conn.arrival, start) {
+			continue
+		}
+		// changing vehicles requires the transfer-time of the stop
+		_AddCSALabel(board_flags, conn.to, conn.arrival+g.GetTransferTime(conn.to), start)
+		explorer.ForAdjacentEdges(conn.to, graph.FORWARD, graph.ADJACENT_ALL, func(ref graph.EdgeRef) {
+			if !ref.IsShortcut() {
+				return
+			}
+			arrival := conn.arrival + explorer.GetShortcutWeight(ref)
+			if arrival-start > max_range {
+				return
+			}
+			_AddCSALabel(stop_flags, ref.OtherID, arrival, start)
+			_AddCSALabel(board_flags, ref.OtherID, arrival, start)
+		})
+	}
+
+	// step 3: range-dijkstra from all stops
+	starts_ := NewList[Tuple[int32, int32]](10)
+	for i := 0; i < g.StopCount(); i++ {
+		flag := stop_flags.Get(int32(i))
+		if flag.trips == nil {
+			continue
+		}
+		dist := int32(100000000)
+		for _, label := range flag.trips {
+			d := label.A - label.B
+			if d < dist {
+				dist = d
+			}
+		}
+		base_node := g.MapStopToNode(int32(i))
+		if base_node == -1 {
+			continue
+		}
+		starts_.Add(MakeTuple(base_node, dist))
+	}
+	_CalcRangeDijkstraTC(g, Array[Tuple[int32, int32]](starts_), node_flags, edge_flags, max_range)
+}
+
+// Adds the (arrival, departure) label to the pareto-set of the stop.
+//
+// Returns false if the label is dominated by an existing one.
+func _AddCSALabel(stop_flags Flags[TransitFlag], stop int32, arrival, departure int32) bool {
+	flag := stop_flags.Get(stop)
+	for _, label := range flag.trips {
+		if label.A <= arrival && label.B >= departure {
+			return false
+		}
+	}
+	labels := NewList[Tuple[int32, int32]](max(flag.trips.Length(), 4))
+	for _, label := range flag.trips {
+		if label.A >= arrival && label.B <= departure {
+			continue
+		}
+		labels.Add(label)
+	}
+	labels.Add(MakeTuple(arrival, departure))
+	flag.trips = labels
+	return true
+}
@@ -1,6 +1,7 @@
 package onetomany
 
 import (
+	"github.com/ttpr0/go-routing/comps"
 	"github.com/ttpr0/go-routing/graph"
 	. "github.com/ttpr0/go-routing/util"
 )
@@ -89,7 +90,11 @@ func _CalcRangeDijkstraTC(g graph.IGraph, starts Array[Tuple[int32, int32]], nod
 			other_id := ref.EdgeID
 			other_node_b := ref.OtherID
 			other_flag := edge_flags.Get(other_id)
-			new_length := curr_flag.Dist + explorer.GetEdgeWeight(ref) + explorer.GetTurnCost(curr_ref, curr_edge.NodeB, ref)
+			turn_cost := explorer.GetTurnCost(curr_ref, curr_edge.NodeB, ref)
+			if turn_cost >= comps.TURN_RESTRICTED {
+				return
+			}
+			new_length := curr_flag.Dist + explorer.GetEdgeWeight(ref) + turn_cost
 			if new_length > max_range {
 				return
 			}
@@ -0,0 +1,250 @@
+package onetomany
+
+import (
+	"slices"
+
+	"github.com/ttpr0/go-routing/comps"
+	"github.com/ttpr0/go-routing/graph"
+	. "github.com/ttpr0/go-routing/util"
+)
+
+// Creates a range-RAPTOR computing the minimum travel-times of all departures within the time-window [from, to].
+//
+// Journeys use at most max_transfers transfers between rides.
+func NewRAPTOR(g *graph.TransitGraph, max_range int32, from, to int32, max_transfers int32) *RAPTOR {
+	return &RAPTOR{
+		g:             g,
+		max_range:     max_range,
+		from:          from,
+		to:            to,
+		max_transfers: max_transfers,
+	}
+}
+
+type RAPTOR struct {
+	g             *graph.TransitGraph
+	max_range     int32
+	from          int32
+	to            int32
+	max_transfers int32
+}
+
+func (self *RAPTOR) CreateSolver() ISolver {
+	node_flags := NewFlags[DistFlag](int32(self.g.NodeCount()), DistFlag{1000000})
+	edge_flags := NewFlags[DistFlag](int32(self.g.EdgeCount()), DistFlag{1000000})
+	max_rounds := int(self.max_transfers) + 1
+	arrivals := make([]Array[int32], max_rounds+1)
+	for k := 0; k <= max_rounds; k++ {
+		arrivals[k] = NewArray[int32](self.g.StopCount())
+	}
+	return &RAPTORSolver{
+		g:           self.g,
+		node_flags:  node_flags,
+		edge_flags:  edge_flags,
+		arrivals:    arrivals,
+		best:        NewArray[int32](self.g.StopCount()),
+		travel_time: NewArray[int32](self.g.StopCount()),
+		max_range:   self.max_range,
+		from:        self.from,
+		to:          self.to,
+	}
+}
+
+type RAPTORSolver struct {
+	g          *graph.TransitGraph
+	node_flags Flags[DistFlag]
+	edge_flags Flags[DistFlag]
+	// earliest arrival at every stop per round
+	arrivals    []Array[int32]
+	best        Array[int32]
+	travel_time Array[int32]
+	max_range   int32
+	from        int32
+	to          int32
+}
+
+// CalcDiatanceFromStarts implements ISolver.
+func (self *RAPTORSolver) CalcDistanceFromStart(starts Array[Tuple[int32, int32]]) error {
+	self.node_flags.Reset()
+	self.edge_flags.Reset()
+	for k := 0; k < len(self.arrivals); k++ {
+		for i := 0; i < self.arrivals[k].Length(); i++ {
+			self.arrivals[k][i] = 1000000000
+		}
+	}
+	for i := 0; i < self.best.Length(); i++ {
+		self.best[i] = 1000000000
+		self.travel_time[i] = 1000000000
+	}
+	_CalcRangeRAPTOR(self.g, starts, self.node_flags, self.edge_flags, self.arrivals, self.best, self.travel_time, self.max_range, self.from, self.to)
+	return nil
+}
+
+// GetDistance implements ISolver.
+func (self *RAPTORSolver) GetDistance(node int32) int32 {
+	return self.node_flags.Get(node).Dist
+}
+
+// computes one-to-many travel-times using range-RAPTOR
+func _CalcRangeRAPTOR(g *graph.TransitGraph, starts Array[Tuple[int32, int32]], node_flags Flags[DistFlag], edge_flags Flags[DistFlag], arrivals []Array[int32], best Array[int32], travel_time Array[int32], max_range int32, from, to int32) {
+	// step 1: range-dijkstra from start
+	_CalcRangeDijkstraTC(g, starts, node_flags, edge_flags, max_range)
+
+	// step 2: collect all departures within the time-window
+	access, departures := _CollectRAPTORDepartures(g, node_flags, max_range, from, to)
+
+	// step 3: raptor for every departure starting with the latest, labels of later departures remain valid
+	for d := departures.Length() - 1; d >= 0; d-- {
+		departure := departures[d]
+		touched := _CalcRAPTORRun(g, access, departure, arrivals, best, max_range)
+		// travel-times only improve at stops reached during this run
+		for _, stop := range touched {
+			arrival := int32(1000000000)
+			for k := 1; k < len(arrivals); k++ {
+				arrival = min(arrival, arrivals[k][stop])
+			}
+			if arrival-departure < travel_time[stop] {
+				travel_time[stop] = arrival - departure
+			}
+		}
+	}
+
+	// step 4: range-dijkstra from all stops
+	starts_ := NewList[Tuple[int32, int32]](10)
+	for i := 0; i < g.StopCount(); i++ {
+		dist := travel_time[i]
+		if dist > max_range {
+			continue
+		}
+		base_node := g.MapStopToNode(int32(i))
+		starts_.Add(MakeTuple(base_node, dist))
+	}
+	_CalcRangeDijkstraTC(g, Array[Tuple[int32, int32]](starts_), node_flags, edge_flags, max_range)
+}
+
+// Collects all stops reachable by walking (as (stop, walking time) tuples) and
+// all distinct departure times at the start (sorted ascending) within the time-window.
+func _CollectRAPTORDepartures(g *graph.TransitGraph, node_flags Flags[DistFlag], max_range int32, from, to int32) (List[Tuple[int32, int32]], List[int32]) {
+	explorer := g.GetTransitExplorer()
+	access := NewList[Tuple[int32, int32]](10)
+	departures := NewList[int32](10)
+	for i := 0; i < g.StopCount(); i++ {
+		base_node := g.MapStopToNode(int32(i))
+		if base_node == -1 {
+			continue
+		}
+		dist := node_flags.Get(base_node).Dist
+		if dist > max_range {
+			continue
+		}
+		access.Add(MakeTuple(int32(i), dist))
+		explorer.ForAdjacentEdges(int32(i), graph.FORWARD, graph.ADJACENT_ALL, func(ref graph.EdgeRef) {
+			if ref.IsShortcut() {
+				return
+			}
+			for _, w := range explorer.GetConnectionWeights(ref, from+dist, to+dist) {
+				departures.Add(w.Departure - dist)
+			}
+		})
+	}
+	slices.Sort(departures)
+	departures = slices.Compact(departures)
+	return access, departures
+}
+
+// Runs the rounds of a single RAPTOR departure.
+//
+// Access is given as (stop, walking time) tuples. Labels in arrivals and best are only improved,
+// therefore runs have to be done starting with the latest departure. Returns all stops improved by a ride or transfer.
+func _CalcRAPTORRun(g *graph.TransitGraph, access List[Tuple[int32, int32]], departure int32, arrivals []Array[int32], best Array[int32], max_range int32) List[int32] {
+	explorer := g.GetTransitExplorer()
+	touched := NewList[int32](10)
+	marked := NewList[int32](access.Length())
+	for _, item := range access {
+		stop := item.A
+		arrival := departure + item.B
+		if arrival < arrivals[0][stop] {
+			arrivals[0][stop] = arrival
+			if arrival < best[stop] {
+				best[stop] = arrival
+			}
+			marked.Add(stop)
+		}
+	}
+	// earliest time vehicles can be changed at the stops reached by a ride in the last round
+	ready := NewDict[int32, int32](0)
+	for k := 1; k < len(arrivals); k++ {
+		curr := arrivals[k]
+		prev := arrivals[k-1]
+		is_marked := NewDict[int32, bool](10)
+		ride_marked := NewList[int32](10)
+		for _, stop := range marked {
+			board_time := prev[stop]
+			if time, ok := ready[stop]; ok {
+				board_time = time
+			}
+			explorer.ForAdjacentEdges(stop, graph.FORWARD, graph.ADJACENT_ALL, func(ref graph.EdgeRef) {
+				if ref.IsShortcut() {
+					return
+				}
+				weight := explorer.GetConnectionWeight(ref, board_time)
+				if !weight.HasValue() {
+					return
+				}
+				g.FollowTrip(ref, weight.Value, func(ref graph.EdgeRef, conn comps.ConnectionWeight) bool {
+					other := ref.OtherID
+					arrival := conn.Arrival
+					if arrival-departure > max_range {
+						return false
+					}
+					if arrival < best[other] && arrival < curr[other] {
+						curr[other] = arrival
+						best[other] = arrival
+						if !is_marked.ContainsKey(other) {
+							is_marked[other] = true
+							ride_marked.Add(other)
+						}
+					}
+					return true
+				})
+			})
+		}
+		marked = NewList[int32](ride_marked.Length())
+		ready = NewDict[int32, int32](ride_marked.Length())
+		for _, stop := range ride_marked {
+			marked.Add(stop)
+			ready[stop] = curr[stop] + g.GetTransferTime(stop)
+		}
+		for _, stop := range ride_marked {
+			arrival := curr[stop]
+			explorer.ForAdjacentEdges(stop, graph.FORWARD, graph.ADJACENT_ALL, func(ref graph.EdgeRef) {
+				if !ref.IsShortcut() {
+					return
+				}
+				other := ref.OtherID
+				new_arrival := arrival + explorer.GetShortcutWeight(ref)
+				if new_arrival-departure > max_range {
+					return
+				}
+				if time, ok := ready[other]; ok && new_arrival < time {
+					ready[other] = new_arrival
+				}
+				if new_arrival < best[other] && new_arrival < curr[other] {
+					curr[other] = new_arrival
+					best[other] = new_arrival
+					if !is_marked.ContainsKey(other) {
+						is_marked[other] = true
+						marked.Add(other)
+					}
+				}
+			})
+		}
+		for _, stop := range marked {
+			touched.Add(stop)
+		}
+		if marked.Length() == 0 {
+			break
+		}
+	}
+	return touched
+}
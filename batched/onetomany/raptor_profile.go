@@ -0,0 +1,199 @@
+package onetomany
+
+import (
+	"slices"
+
+	"github.com/ttpr0/go-routing/graph"
+	. "github.com/ttpr0/go-routing/util"
+)
+
+// Creates a profile-RAPTOR computing the travel-times of every departure minute within the time-window [from, to].
+//
+// The travel-times of every target are summarized by the given percentile (0 = minimum, 50 = median, 100 = maximum).
+// Unreachable departures count as infinite travel-times.
+func NewProfileRAPTOR(g *graph.TransitGraph, target_nodes Array[int32], max_range int32, from, to int32, max_transfers int32, percentile int32) *ProfileRAPTOR {
+	targets := NewDict[int32, int32](target_nodes.Length())
+	for i, node := range target_nodes {
+		if node == -1 {
+			continue
+		}
+		targets[node] = int32(i)
+	}
+	return &ProfileRAPTOR{
+		g:             g,
+		target_nodes:  target_nodes,
+		targets:       targets,
+		max_range:     max_range,
+		from:          from,
+		to:            to,
+		max_transfers: max_transfers,
+		percentile:    percentile,
+	}
+}
+
+type ProfileRAPTOR struct {
+	g            *graph.TransitGraph
+	target_nodes Array[int32]
+	// maps target nodes to their index
+	targets       Dict[int32, int32]
+	max_range     int32
+	from          int32
+	to            int32
+	max_transfers int32
+	percentile    int32
+}
+
+func (self *ProfileRAPTOR) CreateSolver() ISolver {
+	max_rounds := int(self.max_transfers) + 1
+	arrivals := make([]Array[int32], max_rounds+1)
+	for k := 0; k <= max_rounds; k++ {
+		arrivals[k] = NewArray[int32](self.g.StopCount())
+	}
+	return &ProfileRAPTORSolver{
+		g:                 self.g,
+		target_nodes:      self.target_nodes,
+		targets:           self.targets,
+		node_flags:        NewFlags[DistFlag](int32(self.g.NodeCount()), DistFlag{1000000}),
+		edge_flags:        NewFlags[DistFlag](int32(self.g.EdgeCount()), DistFlag{1000000}),
+		egress_node_flags: NewFlags[DistFlag](int32(self.g.NodeCount()), DistFlag{1000000}),
+		egress_edge_flags: NewFlags[DistFlag](int32(self.g.EdgeCount()), DistFlag{1000000}),
+		arrivals:          arrivals,
+		best:              NewArray[int32](self.g.StopCount()),
+		distances:         NewArray[int32](self.target_nodes.Length()),
+		max_range:         self.max_range,
+		from:              self.from,
+		to:                self.to,
+		percentile:        self.percentile,
+	}
+}
+
+type ProfileRAPTORSolver struct {
+	g            *graph.TransitGraph
+	target_nodes Array[int32]
+	targets      Dict[int32, int32]
+	// flags of the access search
+	node_flags Flags[DistFlag]
+	edge_flags Flags[DistFlag]
+	// flags of the egress search of a single departure
+	egress_node_flags Flags[DistFlag]
+	egress_edge_flags Flags[DistFlag]
+	// earliest arrival at every stop per round
+	arrivals []Array[int32]
+	best     Array[int32]
+	// summarized travel-time of every target
+	distances  Array[int32]
+	max_range  int32
+	from       int32
+	to         int32
+	percentile int32
+}
+
+// CalcDiatanceFromStarts implements ISolver.
+func (self *ProfileRAPTORSolver) CalcDistanceFromStart(starts Array[Tuple[int32, int32]]) error {
+	self.node_flags.Reset()
+	self.edge_flags.Reset()
+	for k := 0; k < len(self.arrivals); k++ {
+		for i := 0; i < self.arrivals[k].Length(); i++ {
+			self.arrivals[k][i] = 1000000000
+		}
+	}
+	for i := 0; i < self.best.Length(); i++ {
+		self.best[i] = 1000000000
+	}
+	_CalcProfileRAPTOR(self.g, starts, self.target_nodes, self.node_flags, self.edge_flags, self.egress_node_flags, self.egress_edge_flags, self.arrivals, self.best, self.distances, self.max_range, self.from, self.to, self.percentile)
+	return nil
+}
+
+// GetDistance implements ISolver.
+func (self *ProfileRAPTORSolver) GetDistance(node int32) int32 {
+	index, ok := self.targets[node]
+	if !ok {
+		return 1000000000
+	}
+	return self.distances[index]
+}
+
+// Interval (in s) between two departures of a profile.
+const PROFILE_STEP = 60
+
+// computes the travel-time percentile of every target over all departure minutes using range-RAPTOR
+func _CalcProfileRAPTOR(g *graph.TransitGraph, starts Array[Tuple[int32, int32]], target_nodes Array[int32], node_flags, edge_flags, egress_node_flags, egress_edge_flags Flags[DistFlag], arrivals []Array[int32], best Array[int32], distances Array[int32], max_range int32, from, to int32, percentile int32) {
+	// step 1: range-dijkstra from start
+	_CalcRangeDijkstraTC(g, starts, node_flags, edge_flags, max_range)
+	walk := NewArray[int32](target_nodes.Length())
+	for t, node := range target_nodes {
+		walk[t] = 1000000000
+		if node == -1 {
+			continue
+		}
+		dist := node_flags.Get(node).Dist
+		if dist <= max_range {
+			walk[t] = dist
+		}
+	}
+
+	// step 2: collect all departures within the time-window
+	access, departures := _CollectRAPTORDepartures(g, node_flags, max_range, from, to)
+
+	// step 3: raptor for every departure starting with the latest,
+	// arrivals at the targets are only recomputed if a stop has been improved
+	target_arrivals := make([]Array[int32], departures.Length())
+	for d := departures.Length() - 1; d >= 0; d-- {
+		departure := departures[d]
+		touched := _CalcRAPTORRun(g, access, departure, arrivals, best, max_range)
+		if touched.Length() == 0 && d < departures.Length()-1 {
+			target_arrivals[d] = target_arrivals[d+1]
+			continue
+		}
+		egress_node_flags.Reset()
+		egress_edge_flags.Reset()
+		starts_ := NewList[Tuple[int32, int32]](10)
+		for i := 0; i < g.StopCount(); i++ {
+			arrival := int32(1000000000)
+			for k := 1; k < len(arrivals); k++ {
+				arrival = min(arrival, arrivals[k][i])
+			}
+			if arrival-departure > max_range {
+				continue
+			}
+			base_node := g.MapStopToNode(int32(i))
+			if base_node == -1 {
+				continue
+			}
+			starts_.Add(MakeTuple(base_node, arrival-departure))
+		}
+		_CalcRangeDijkstraTC(g, Array[Tuple[int32, int32]](starts_), egress_node_flags, egress_edge_flags, max_range)
+		curr := NewArray[int32](target_nodes.Length())
+		for t, node := range target_nodes {
+			curr[t] = 1000000000
+			if node == -1 || starts_.Length() == 0 {
+				continue
+			}
+			dist := egress_node_flags.Get(node).Dist
+			if dist <= max_range {
+				curr[t] = departure + dist
+			}
+		}
+		target_arrivals[d] = curr
+	}
+
+	// step 4: travel-times of every departure minute using the next departure at the start
+	samples := NewList[int32](int((to-from)/PROFILE_STEP) + 1)
+	for t := range target_nodes {
+		samples.Clear()
+		next := 0
+		for time := from; time <= to; time += PROFILE_STEP {
+			for next < departures.Length() && departures[next] < time {
+				next += 1
+			}
+			dist := walk[t]
+			if next < departures.Length() {
+				dist = min(dist, target_arrivals[next][t]-time)
+			}
+			samples.Add(dist)
+		}
+		slices.Sort(samples)
+		index := int(percentile) * (samples.Length() - 1) / 100
+		distances[t] = samples[index]
+	}
+}
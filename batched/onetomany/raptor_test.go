@@ -0,0 +1,58 @@
+package onetomany
+
+import (
+	"testing"
+
+	"github.com/ttpr0/go-routing/comps"
+	"github.com/ttpr0/go-routing/geo"
+	"github.com/ttpr0/go-routing/graph"
+	"github.com/ttpr0/go-routing/structs"
+	. "github.com/ttpr0/go-routing/util"
+)
+
+// Creates two stops (nodes 0 and 1) too far apart to walk, connected by a 10 minute ride every headway seconds from 8h on.
+func create_headway_graph(headway int32) *graph.TransitGraph {
+	nodes := Array[structs.Node]{{Loc: geo.Coord{0, 0}}, {Loc: geo.Coord{0.1, 0}}}
+	edges := Array[structs.Edge]{{NodeA: 0, NodeB: 1}, {NodeA: 1, NodeB: 0}}
+	base := comps.NewGraphBase(nodes, edges)
+	weight := comps.NewDefaultWeighting(base)
+	weight.SetEdgeWeight(0, 100000)
+	weight.SetEdgeWeight(1, 100000)
+
+	connections := Array[structs.Connection]{{StopA: 0, StopB: 1}}
+	transit := comps.NewTransit(structs.NewIdendityMapping(2), nodes, connections, structs.NewShortcutStore(0, false), NewArray[int32](2))
+	transit_weight := comps.NewTransitWeighting(transit)
+	schedule := []comps.ConnectionWeight{}
+	for departure := int32(28800); departure < 36000; departure += headway {
+		schedule = append(schedule, comps.ConnectionWeight{Departure: departure, Arrival: departure + 600, Trip: int32(len(schedule))})
+	}
+	transit_weight.SetWeights(0, schedule)
+	return graph.BuildTransitGraph(base, weight, transit, transit_weight)
+}
+
+func TestProfileRAPTORPercentiles(t *testing.T) {
+	// departure minutes 8:00-8:20 wait 0-9 minutes for a ride every 10 minutes,
+	// sorted travel-times are 10, 10, 10, 11, 11, ..., 19, 19 minutes
+	tests := []struct {
+		name       string
+		percentile int32
+		expected   int32
+	}{
+		{"minimum", 0, 600},
+		{"median", 50, 840},
+		{"maximum", 100, 1140},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := create_headway_graph(600)
+			solver := NewProfileRAPTOR(g, Array[int32]{0, 1}, 3600, 28800, 30000, 2, tt.percentile).CreateSolver()
+			solver.CalcDistanceFromStart(Array[Tuple[int32, int32]]{MakeTuple(int32(0), int32(0))})
+			if d := solver.GetDistance(1); d != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, d)
+			}
+			if d := solver.GetDistance(0); d != 0 {
+				t.Errorf("expected start at 0, got %v", d)
+			}
+		})
+	}
+}
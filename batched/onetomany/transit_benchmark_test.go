@@ -0,0 +1,146 @@
+package onetomany
+
+import (
+	"testing"
+
+	"github.com/ttpr0/go-routing/comps"
+	"github.com/ttpr0/go-routing/geo"
+	"github.com/ttpr0/go-routing/graph"
+	"github.com/ttpr0/go-routing/structs"
+	. "github.com/ttpr0/go-routing/util"
+)
+
+// Creates a grid of size x size nodes with transit lines along every fifth row and column.
+//
+// Vehicles run every interval seconds between 6h and 12h, lines start with an offset if offsets is set.
+// If transfers is set, stops get transfer-times of 0-3 minutes and neighbouring stops are connected by walking transfers.
+func create_transit_graph(size int, interval int32, offsets bool, transfers bool) *graph.TransitGraph {
+	node_id := func(r, c int) int32 { return int32(r*size + c) }
+	nodes := NewList[structs.Node](size * size)
+	for r := 0; r < size; r++ {
+		for c := 0; c < size; c++ {
+			nodes.Add(structs.Node{Loc: geo.Coord{float32(c) * 0.001, float32(r) * 0.001}})
+		}
+	}
+	edges := NewList[structs.Edge](4 * size * size)
+	for r := 0; r < size; r++ {
+		for c := 0; c < size; c++ {
+			if c+1 < size {
+				edges.Add(structs.Edge{NodeA: node_id(r, c), NodeB: node_id(r, c+1)})
+				edges.Add(structs.Edge{NodeA: node_id(r, c+1), NodeB: node_id(r, c)})
+			}
+			if r+1 < size {
+				edges.Add(structs.Edge{NodeA: node_id(r, c), NodeB: node_id(r+1, c)})
+				edges.Add(structs.Edge{NodeA: node_id(r+1, c), NodeB: node_id(r, c)})
+			}
+		}
+	}
+	base := comps.NewGraphBase(Array[structs.Node](nodes), Array[structs.Edge](edges))
+	weight := comps.NewDefaultWeighting(base)
+	for i := 0; i < base.EdgeCount(); i++ {
+		weight.SetEdgeWeight(int32(i), 60)
+	}
+
+	// stops at every crossing of two lines
+	mapping := NewArray[[2]int32](size * size)
+	for i := 0; i < mapping.Length(); i++ {
+		mapping[i] = [2]int32{-1, -1}
+	}
+	stops := NewList[structs.Node](size * size)
+	stop_id := func(r, c int) int32 { return mapping[node_id(r, c)][0] }
+	for r := 0; r < size; r += 5 {
+		for c := 0; c < size; c += 5 {
+			node := node_id(r, c)
+			mapping[node][0] = int32(stops.Length())
+			mapping[stops.Length()][1] = node
+			stops.Add(nodes[node])
+		}
+	}
+	connections := NewList[structs.Connection](4 * stops.Length())
+	lines := NewList[[]int32](4)
+	for i := 0; i < size; i += 5 {
+		row := make([]int32, 0, size/5+1)
+		col := make([]int32, 0, size/5+1)
+		for j := 0; j < size; j += 5 {
+			row = append(row, stop_id(i, j))
+			col = append(col, stop_id(j, i))
+		}
+		row_r := make([]int32, len(row))
+		col_r := make([]int32, len(col))
+		for j := range row {
+			row_r[len(row)-1-j] = row[j]
+			col_r[len(col)-1-j] = col[j]
+		}
+		lines.Add(row)
+		lines.Add(row_r)
+		lines.Add(col)
+		lines.Add(col_r)
+	}
+	line_conns := NewList[[]int32](lines.Length())
+	for _, line := range lines {
+		conns := make([]int32, 0, len(line))
+		for j := 0; j+1 < len(line); j++ {
+			conns = append(conns, int32(connections.Length()))
+			connections.Add(structs.Connection{StopA: line[j], StopB: line[j+1]})
+		}
+		line_conns.Add(conns)
+	}
+	shortcuts := structs.NewShortcutStore(0, false)
+	transfer_times := NewArray[int32](stops.Length())
+	if transfers {
+		// walking between neighbouring stops takes 5 edges
+		for r := 0; r < size; r += 5 {
+			for c := 0; c+5 < size; c += 5 {
+				shortcuts.AddShortcut(structs.NewShortcut(stop_id(r, c), stop_id(r, c+5), 300), nil)
+				shortcuts.AddShortcut(structs.NewShortcut(stop_id(r, c+5), stop_id(r, c), 300), nil)
+				shortcuts.AddShortcut(structs.NewShortcut(stop_id(c, r), stop_id(c+5, r), 300), nil)
+				shortcuts.AddShortcut(structs.NewShortcut(stop_id(c+5, r), stop_id(c, r), 300), nil)
+			}
+		}
+		for i := range transfer_times {
+			transfer_times[i] = int32(i%4) * 60
+		}
+	}
+	transit := comps.NewTransit(structs.NewIDMapping(mapping), Array[structs.Node](stops), Array[structs.Connection](connections), shortcuts, transfer_times)
+
+	// every hop takes 2 minutes
+	schedules := NewArray[[]comps.ConnectionWeight](connections.Length())
+	trip := int32(0)
+	for l, conns := range line_conns {
+		offset := int32(0)
+		if offsets {
+			offset = int32(l*97) % interval
+		}
+		for start := 21600 + offset; start < 43200; start += interval {
+			for j, conn := range conns {
+				departure := start + int32(j)*120
+				schedules[conn] = append(schedules[conn], comps.ConnectionWeight{Departure: departure, Arrival: departure + 120, Trip: trip})
+			}
+			trip += 1
+		}
+	}
+	transit_weight := comps.NewTransitWeighting(transit)
+	for i, schedule := range schedules {
+		transit_weight.SetWeights(int32(i), schedule)
+	}
+	return graph.BuildTransitGraph(base, weight, transit, transit_weight)
+}
+
+func benchmark_transit_one_to_many(b *testing.B, g *graph.TransitGraph, otm IOneToMany) {
+	solver := otm.CreateSolver()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		start := Array[Tuple[int32, int32]]{MakeTuple(int32(i%g.NodeCount()), int32(0))}
+		solver.CalcDistanceFromStart(start)
+	}
+}
+
+func Benchmark_TransitDijkstra(b *testing.B) {
+	g := create_transit_graph(100, 300, false, false)
+	benchmark_transit_one_to_many(b, g, NewTransitDijkstra(g, 3600, 28800, 32400))
+}
+
+func Benchmark_CSA(b *testing.B) {
+	g := create_transit_graph(100, 300, false, false)
+	benchmark_transit_one_to_many(b, g, NewCSA(g, 3600, 28800, 32400))
+}
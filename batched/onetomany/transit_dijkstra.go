@@ -68,6 +68,8 @@ func _CalcTransitDijkstra(g *graph.TransitGraph, starts Array[Tuple[int32, int32
 	// step 2: transit-dijkstra from all found stops
 	heap := NewPriorityQueue[TransitItem, int32](100)
 	explorer := g.GetTransitExplorer()
+	// latest departure at the start every trip has been boarded with at a connection
+	trip_departures := NewDict[Tuple[int32, int32], int32](100)
 
 	for i := 0; i < g.StopCount(); i++ {
 		base_node := g.MapStopToNode(int32(i))
@@ -80,14 +82,14 @@ func _CalcTransitDijkstra(g *graph.TransitGraph, starts Array[Tuple[int32, int32
 		}
 		dist := flag.Dist
 		time := to + dist
-		heap.Enqueue(TransitItem{time, to, int32(i)}, time)
+		heap.Enqueue(TransitItem{time, to, int32(i), -1}, time)
 		explorer.ForAdjacentEdges(int32(i), graph.FORWARD, graph.ADJACENT_ALL, func(ref graph.EdgeRef) {
 			if ref.IsShortcut() {
 				return
 			}
 			weights := explorer.GetConnectionWeights(ref, from+dist, to+dist)
 			for _, w := range weights {
-				heap.Enqueue(TransitItem{w.Departure, w.Departure - dist, int32(i)}, w.Departure)
+				heap.Enqueue(TransitItem{w.Departure, w.Departure - dist, int32(i), -1}, w.Departure)
 			}
 		})
 	}
@@ -96,6 +98,20 @@ func _CalcTransitDijkstra(g *graph.TransitGraph, starts Array[Tuple[int32, int32
 		if !ok {
 			break
 		}
+		// changing vehicles requires the transfer-time of the stop
+		ready := item.time
+		if item.trip != -1 {
+			ready += g.GetTransferTime(item.stop)
+			// staying on the vehicle is always possible
+			explorer.ForAdjacentEdges(item.stop, graph.FORWARD, graph.ADJACENT_ALL, func(ref graph.EdgeRef) {
+				for _, w := range explorer.GetConnectionWeights(ref, item.time, item.time+graph.MAX_DWELL_TIME) {
+					if w.Trip != item.trip || w.Arrival-item.departure > max_range {
+						continue
+					}
+					heap.Enqueue(TransitItem{w.Arrival, item.departure, ref.OtherID, w.Trip}, w.Arrival)
+				}
+			})
+		}
 		curr_flag := stop_flags.Get(item.stop)
 		prune := false
 		for _, trip := range curr_flag.trips {
@@ -118,14 +134,27 @@ func _CalcTransitDijkstra(g *graph.TransitGraph, starts Array[Tuple[int32, int32
 			if ref.IsShortcut() {
 				weight := explorer.GetShortcutWeight(ref)
 				new_time := item.time + weight
-				heap.Enqueue(TransitItem{new_time, item.departure, other_id}, new_time)
+				if new_time-item.departure > max_range {
+					return
+				}
+				heap.Enqueue(TransitItem{new_time, item.departure, other_id, -1}, new_time)
 			} else {
-				weight := explorer.GetConnectionWeight(ref, item.time)
+				weight := explorer.GetConnectionWeight(ref, ready)
 				if !weight.HasValue() {
 					return
 				}
 				new_time := weight.Value.Arrival
-				heap.Enqueue(TransitItem{new_time, item.departure, other_id}, new_time)
+				if new_time-item.departure > max_range {
+					return
+				}
+				// trips already boarded at the same connection with a later departure reach all following stops earlier
+				trip := weight.Value.Trip
+				key := MakeTuple(trip, ref.EdgeID)
+				if departure, ok := trip_departures[key]; ok && departure >= item.departure {
+					return
+				}
+				trip_departures[key] = item.departure
+				heap.Enqueue(TransitItem{new_time, item.departure, other_id, trip}, new_time)
 			}
 		})
 	}
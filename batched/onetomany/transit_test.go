@@ -0,0 +1,44 @@
+package onetomany
+
+import (
+	"testing"
+
+	. "github.com/ttpr0/go-routing/util"
+)
+
+func TestTransitOneToManyEqual(t *testing.T) {
+	tests := []struct {
+		name      string
+		interval  int32
+		offsets   bool
+		transfers bool
+	}{
+		{"synchronized", 600, false, false},
+		{"offsets", 600, true, false},
+		{"transfers", 600, true, true},
+		{"frequent transfers", 300, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := create_transit_graph(26, tt.interval, tt.offsets, tt.transfers)
+			dijkstra := NewTransitDijkstra(g, 5400, 28800, 30600).CreateSolver()
+			csa := NewCSA(g, 5400, 28800, 30600).CreateSolver()
+			raptor := NewRAPTOR(g, 5400, 28800, 30600, 20).CreateSolver()
+			for _, start := range []int32{0, 27, 130, 338, 675} {
+				starts := Array[Tuple[int32, int32]]{MakeTuple(start, int32(0))}
+				dijkstra.CalcDistanceFromStart(starts)
+				csa.CalcDistanceFromStart(starts)
+				raptor.CalcDistanceFromStart(starts)
+				for node := int32(0); node < int32(g.NodeCount()); node++ {
+					expected := dijkstra.GetDistance(node)
+					if d := csa.GetDistance(node); d != expected {
+						t.Errorf("start %v, node %v: csa %v, dijkstra %v", start, node, d, expected)
+					}
+					if d := raptor.GetDistance(node); d != expected {
+						t.Errorf("start %v, node %v: raptor %v, dijkstra %v", start, node, d, expected)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package onetomany
+
+import (
+	"github.com/ttpr0/go-routing/graph"
+	"github.com/ttpr0/go-routing/structs"
+	. "github.com/ttpr0/go-routing/util"
+)
+
+// Range-RPHAST on the contraction hierarchy of an edge-based graph respecting turn-costs.
+//
+// g is the edge-based hierarchy, edge_mapping maps the edges of the (node-based) graph g_base to nodes of g.
+// Distances to nodes of g_base are the minimum distances of their incoming edges.
+func NewTurnRPHAST(g graph.ICHGraph, g_base graph.IGraph, edge_mapping structs.IDMapping, target_nodes Array[int32], max_range int32) *TurnRPHAST {
+	target_edges := NewList[int32](target_nodes.Length() * 2)
+	explorer := g_base.GetGraphExplorer()
+	for _, node := range target_nodes {
+		if node == -1 {
+			continue
+		}
+		explorer.ForAdjacentEdges(node, graph.BACKWARD, graph.ADJACENT_ALL, func(ref graph.EdgeRef) {
+			target_edges.Add(edge_mapping.GetTarget(ref.EdgeID))
+		})
+	}
+	return &TurnRPHAST{
+		g:                 g,
+		g_base:            g_base,
+		edge_mapping:      edge_mapping,
+		max_range:         max_range,
+		down_edges_subset: _TargetSelection(g, Array[int32](target_edges)),
+	}
+}
+
+type TurnRPHAST struct {
+	g                 graph.ICHGraph
+	g_base            graph.IGraph
+	edge_mapping      structs.IDMapping
+	max_range         int32
+	down_edges_subset List[structs.Shortcut]
+}
+
+func (self *TurnRPHAST) CreateSolver() ISolver {
+	node_flags := NewFlags[DistFlag](int32(self.g.NodeCount()), DistFlag{1000000})
+	return &TurnRPHASTSolver{
+		g:                 self.g,
+		explorer:          self.g_base.GetGraphExplorer(),
+		edge_mapping:      self.edge_mapping,
+		max_range:         self.max_range,
+		node_flags:        node_flags,
+		down_edges_subset: self.down_edges_subset,
+	}
+}
+
+type TurnRPHASTSolver struct {
+	g                 graph.ICHGraph
+	explorer          graph.IGraphExplorer
+	edge_mapping      structs.IDMapping
+	max_range         int32
+	down_edges_subset List[structs.Shortcut]
+	node_flags        Flags[DistFlag]
+	starts            Array[Tuple[int32, int32]]
+}
+
+// CalcDiatanceFromStarts implements ISolver.
+func (self *TurnRPHASTSolver) CalcDistanceFromStart(starts Array[Tuple[int32, int32]]) error {
+	self.node_flags.Reset()
+	self.starts = starts
+	// searches start at the outgoing edges of the start nodes
+	edge_starts := NewList[Tuple[int32, int32]](starts.Length() * 3)
+	for _, item := range starts {
+		start := item.A
+		dist := item.B
+		self.explorer.ForAdjacentEdges(start, graph.FORWARD, graph.ADJACENT_ALL, func(ref graph.EdgeRef) {
+			edge_dist := dist + self.explorer.GetEdgeWeight(ref)
+			if edge_dist > self.max_range {
+				return
+			}
+			edge_starts.Add(MakeTuple(self.edge_mapping.GetTarget(ref.EdgeID), edge_dist))
+		})
+	}
+	_CalcRangeRPHAST(self.g, Array[Tuple[int32, int32]](edge_starts), self.max_range, self.node_flags, self.down_edges_subset)
+	return nil
+}
+
+// GetDistance implements ISolver.
+func (self *TurnRPHASTSolver) GetDistance(node int32) int32 {
+	dist := int32(1000000)
+	for _, item := range self.starts {
+		if item.A == node && item.B < dist {
+			dist = item.B
+		}
+	}
+	self.explorer.ForAdjacentEdges(node, graph.BACKWARD, graph.ADJACENT_ALL, func(ref graph.EdgeRef) {
+		edge_dist := self.node_flags.Get(self.edge_mapping.GetTarget(ref.EdgeID)).Dist
+		if edge_dist < dist {
+			dist = edge_dist
+		}
+	})
+	return dist
+}
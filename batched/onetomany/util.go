@@ -21,6 +21,8 @@ type TransitItem struct {
 	time      int32
 	departure int32
 	stop      int32
+	// trip the stop has been reached with (-1 if reached by walking)
+	trip int32
 }
 
 type TransitFlag struct {
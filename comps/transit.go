@@ -11,7 +11,7 @@ import (
 // overlay-data
 //*******************************************
 
-func NewTransit(id_mapping structs.IDMapping, stops Array[structs.Node], connections Array[structs.Connection], shortcuts structs.ShortcutStore) *Transit {
+func NewTransit(id_mapping structs.IDMapping, stops Array[structs.Node], connections Array[structs.Connection], shortcuts structs.ShortcutStore, transfer_times Array[int32]) *Transit {
 	topology := structs.NewAdjacencyList(stops.Length())
 	for i := 0; i < connections.Length(); i++ {
 		conn := connections[i]
@@ -22,11 +22,12 @@ func NewTransit(id_mapping structs.IDMapping, stops Array[structs.Node], connect
 		topology.AddEdgeEntries(shc.From, shc.To, int32(i), 100)
 	}
 	return &Transit{
-		id_mapping:  id_mapping,
-		stops:       stops,
-		connections: connections,
-		shortcuts:   shortcuts,
-		topology:    *structs.AdjacencyListToArray(&topology),
+		id_mapping:     id_mapping,
+		stops:          stops,
+		connections:    connections,
+		shortcuts:      shortcuts,
+		transfer_times: transfer_times,
+		topology:       *structs.AdjacencyListToArray(&topology),
 	}
 }
 
@@ -35,7 +36,9 @@ type Transit struct {
 	stops       Array[structs.Node]
 	connections Array[structs.Connection]
 	shortcuts   structs.ShortcutStore
-	topology    structs.AdjacencyArray
+	// minimum time (in s) required to change vehicles without leaving the stop
+	transfer_times Array[int32]
+	topology       structs.AdjacencyArray
 }
 
 func (self *Transit) MapNodeToStop(node int32) int32 {
@@ -65,6 +68,9 @@ func (self *Transit) GetShortcut(shortcut int32) structs.Shortcut {
 func (self *Transit) GetEdgesFromShortcut(shortcut int32, reversed bool, callback func(int32)) {
 	self.shortcuts.GetEdgesFromShortcut(shortcut, reversed, callback)
 }
+func (self *Transit) GetTransferTime(stop int32) int32 {
+	return self.transfer_times[stop]
+}
 func (self *Transit) GetAccessor() structs.IAdjAccessor {
 	acc := self.topology.GetAccessor()
 	return &acc
@@ -79,13 +85,20 @@ func (self *Transit) _Load(path string) {
 	connections := ReadArrayFromFile[structs.Connection](path + "-connections")
 	shortcuts := structs.LoadShortcuts(path + "-shortcut")
 	topology := structs.LoadAdjacency(path+"-transit_graph", true)
+	var transfer_times Array[int32]
+	if _, err := os.Stat(path + "-transfer_times"); err == nil {
+		transfer_times = ReadArrayFromFile[int32](path + "-transfer_times")
+	} else {
+		transfer_times = NewArray[int32](stops.Length())
+	}
 
 	*self = Transit{
-		id_mapping:  id_mapping,
-		stops:       stops,
-		connections: connections,
-		shortcuts:   shortcuts,
-		topology:    *topology,
+		id_mapping:     id_mapping,
+		stops:          stops,
+		connections:    connections,
+		shortcuts:      shortcuts,
+		transfer_times: transfer_times,
+		topology:       *topology,
 	}
 }
 func (self *Transit) _Store(path string) {
@@ -94,6 +107,7 @@ func (self *Transit) _Store(path string) {
 	structs.StoreAdjacency(&self.topology, true, path+"-transit_graph")
 	WriteArrayToFile(self.stops, path+"-stops")
 	WriteArrayToFile(self.connections, path+"-connections")
+	WriteArrayToFile(self.transfer_times, path+"-transfer_times")
 }
 func (self *Transit) _Remove(path string) {
 	os.Remove(path + "-id_mapping")
@@ -101,4 +115,5 @@ func (self *Transit) _Remove(path string) {
 	os.Remove(path + "-transit_graph")
 	os.Remove(path + "-stops")
 	os.Remove(path + "-connections")
+	os.Remove(path + "-transfer_times")
 }
@@ -142,6 +142,11 @@ func (self *DynamicWeighting) GetTurnCost(from, via, to int32) int32 {
 // weighting with turn costs
 //*******************************************
 
+// Turn cost of forbidden turns, exceeds every distance searched for.
+//
+// Other turn costs are limited to 254.
+const TURN_RESTRICTED int32 = 1000000000
+
 type TCWeighting struct {
 	edge_weights List[int32]
 	edge_indices List[Tuple[byte, byte]]
@@ -198,7 +203,11 @@ func (self *TCWeighting) GetTurnCost(from, via, to int32) int32 {
 	tc_ref := self.turn_refs[via]
 	cols := tc_ref.C
 	loc := tc_ref.A
-	return int32(self.turn_weights[loc+int(cols*bwd_index)+int(fwd_index)])
+	weight := self.turn_weights[loc+int(cols)*int(bwd_index)+int(fwd_index)]
+	if weight == 255 {
+		return TURN_RESTRICTED
+	}
+	return int32(weight)
 }
 func (self *TCWeighting) SetTurnCost(from, via, to int32, weight int32) {
 	bwd_index := self.edge_indices[from].B
@@ -206,7 +215,7 @@ func (self *TCWeighting) SetTurnCost(from, via, to int32, weight int32) {
 	tc_ref := self.turn_refs[via]
 	cols := tc_ref.C
 	loc := tc_ref.A
-	self.turn_weights[loc+int(cols*bwd_index)+int(fwd_index)] = byte(weight)
+	self.turn_weights[loc+int(cols)*int(bwd_index)+int(fwd_index)] = byte(min(weight, 255))
 }
 
 func (self *TCWeighting) _New() *TCWeighting {
@@ -228,8 +237,8 @@ func (self *TCWeighting) _Load(path string) {
 	edge_weights := NewArray[int32](int(edgecount))
 	edge_indices := NewArray[Tuple[byte, byte]](int(edgecount))
 	for i := 0; i < int(edgecount); i++ {
-		edge_weight := Read[uint8](reader)
-		edge_weights[i] = int32(edge_weight)
+		edge_weight := Read[int32](reader)
+		edge_weights[i] = edge_weight
 		ei_a := Read[uint8](reader)
 		ei_b := Read[uint8](reader)
 		edge_indices[i] = MakeTuple(ei_a, ei_b)
@@ -261,7 +270,7 @@ func (self *TCWeighting) _Store(path string) {
 
 	for i := 0; i < edgecount; i++ {
 		edge_weight := self.GetEdgeWeight(int32(i))
-		Write(writer, uint8(edge_weight))
+		Write(writer, int32(edge_weight))
 		edge_indices := self.edge_indices[i]
 		Write(writer, uint8(edge_indices.A))
 		Write(writer, uint8(edge_indices.B))
@@ -337,9 +346,27 @@ func (self *TransitWeighting) GetWeightsInRange(connection int32, from, to int32
 	}
 	return conn_weights[start:end]
 }
+func (self *TransitWeighting) GetWeights(connection int32) []ConnectionWeight {
+	return self.transit_weights[connection]
+}
 func (self *TransitWeighting) SetWeights(connection int32, schedule []ConnectionWeight) {
 	self.transit_weights[connection] = List[ConnectionWeight](schedule)
 }
+func (self *TransitWeighting) ConnectionCount() int {
+	return self.transit_weights.Length()
+}
+
+// Returns a copy sharing the schedules of all connections.
+//
+// Schedules of the copy have to be replaced using SetWeights instead of being modified in place,
+// this way graphs using the original weighting are not affected.
+func (self *TransitWeighting) Copy() *TransitWeighting {
+	transit_weights := NewArray[List[ConnectionWeight]](self.transit_weights.Length())
+	copy(transit_weights, self.transit_weights)
+	return &TransitWeighting{
+		transit_weights: transit_weights,
+	}
+}
 
 func (self *TransitWeighting) _New() *TransitWeighting {
 	return &TransitWeighting{}
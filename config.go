@@ -33,12 +33,51 @@ type Config struct {
 	} `yaml:"build"`
 	BuildGraphs bool `yaml:"build-graphs"`
 	Services    struct {
+		Matrix struct {
+			Workers int `yaml:"workers"`
+		} `yaml:"matrix"`
+		Jobs struct {
+			Directory string `yaml:"directory"`
+			Workers   int    `yaml:"workers"`
+			QueueSize int    `yaml:"queue-size"`
+			// time (in s) finished jobs and their results are kept
+			TTL int `yaml:"ttl"`
+		} `yaml:"jobs"`
+		Scenarios struct {
+			// time (in s) transit scenarios are kept
+			TTL int `yaml:"ttl"`
+			// maximum number of scenarios per profile, the oldest are removed first
+			MaxCount int `yaml:"max-count"`
+		} `yaml:"scenarios"`
+		Isochrones struct {
+			Population struct {
+				File      string `yaml:"file"`
+				Delimiter string `yaml:"delimiter"`
+			} `yaml:"population"`
+		} `yaml:"isochrones"`
 	} `yaml:"services"`
 }
 
 type SourceOptions struct {
-	OSM  string `yaml:"osm"`
-	GTFS string `yaml:"gtfs"`
+	OSM string `yaml:"osm"`
+	// one or more GTFS feeds (directories or zip-files)
+	GTFS GTFSSources `yaml:"gtfs"`
+}
+
+// Paths of the GTFS feeds, given as a single path or a list of paths.
+type GTFSSources []string
+
+func (self *GTFSSources) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*self = GTFSSources{value.Value}
+		return nil
+	}
+	paths := []string{}
+	if err := value.Decode(&paths); err != nil {
+		return err
+	}
+	*self = GTFSSources(paths)
+	return nil
 }
 
 //**********************************************************
@@ -82,9 +121,13 @@ type IProfileOptions interface {
 }
 
 type DrivingOptions struct {
-	Vehicle     VehicleType `yaml:"vehicle"`
-	Metric      MetricType  `yaml:"metric"`
-	Preparation struct {
+	Vehicle VehicleType `yaml:"vehicle"`
+	Metric  MetricType  `yaml:"metric"`
+	// optional yaml/json file declaring the osm-decoder (see parser.DecoderConfig)
+	Decoder string `yaml:"decoder"`
+	// if true turn-restrictions are respected (not supported with overlay)
+	TurnRestrictions bool `yaml:"turn-restrictions"`
+	Preparation      struct {
 		Contraction     bool   `yaml:"contraction"`
 		Overlay         bool   `yaml:"overlay"`
 		MaxNodesPerCell int    `yaml:"max-nodes-per-cell"`
@@ -99,6 +142,8 @@ func (self DrivingOptions) Type() ProfileType {
 type WalkingOptions struct {
 	Vehicle VehicleType `yaml:"vehicle"`
 	Metric  MetricType  `yaml:"metric"`
+	// optional yaml/json file declaring the osm-decoder (see parser.DecoderConfig)
+	Decoder string `yaml:"decoder"`
 }
 
 func (self WalkingOptions) Type() ProfileType {
@@ -108,6 +153,8 @@ func (self WalkingOptions) Type() ProfileType {
 type CyclingOptions struct {
 	Vehicle VehicleType `yaml:"vehicle"`
 	Metric  MetricType  `yaml:"metric"`
+	// optional yaml/json file declaring the osm-decoder (see parser.DecoderConfig)
+	Decoder string `yaml:"decoder"`
 }
 
 func (self CyclingOptions) Type() ProfileType {
@@ -117,9 +164,27 @@ func (self CyclingOptions) Type() ProfileType {
 type TransitOptions struct {
 	Vehicle VehicleType `yaml:"vehicle"`
 	// Metric      MetricType  `yaml:"metric"`
+	// one-to-many algorithm used by matrix and accessibility requests; one of ["dijkstra", "csa"] (defaults to "dijkstra")
+	Algorithm   string `yaml:"algorithm"`
 	Preparation struct {
-		FilterPolygon    string `yaml:"filter-polygon"`
-		MaxTransferRange int32  `yaml:"max-transfer-range"`
+		// optional geojson file, stops outside of all its (multi-)polygons are removed
+		FilterPolygon string `yaml:"filter-polygon"`
+		// optional bounding-box [min_lon, min_lat, max_lon, max_lat] stops are clipped to
+		FilterBBox []float32 `yaml:"filter-bbox"`
+		// clips stops to the extent of the OSM data
+		ClipToOSM        bool  `yaml:"clip-to-osm"`
+		MaxTransferRange int32 `yaml:"max-transfer-range"`
+		// minimum time (in s) of every transfer between two vehicles
+		MinTransferTime int32 `yaml:"min-transfer-time"`
+		// time (in s) added to every transfer to penalize changing vehicles
+		TransferPenalty int32 `yaml:"transfer-penalty"`
+		// distance (in m) within which stops of different GTFS feeds are merged (disabled if not set)
+		StopMergeDistance float32 `yaml:"stop-merge-distance"`
+		// optional range of ISO-dates (e.g. "2024-05-01") schedules are built for, otherwise one schedule per weekday is built
+		ScheduleDates struct {
+			From string `yaml:"from"`
+			To   string `yaml:"to"`
+		} `yaml:"schedule-dates"`
 	} `yaml:"preparation"`
 }
 
@@ -35,3 +35,38 @@ func EuclideanDistance(a, b Coord) float64 {
 	d_lat := float64(a[1]) - float64(b[1])
 	return math.Sqrt(math.Pow(d_lon, 2) + math.Pow(d_lat, 2))
 }
+
+// Returns the area of the polygon (in m²) on the sphere.
+//
+// The first ring is the outer boundary, all following rings are subtracted as holes.
+func PolygonArea(polygon [][]Coord) float64 {
+	area := float64(0)
+	for i, ring := range polygon {
+		ring_area := math.Abs(_RingArea(ring))
+		if i == 0 {
+			area += ring_area
+		} else {
+			area -= ring_area
+		}
+	}
+	return area
+}
+
+// Computes the signed area of a ring using the spherical excess approximation.
+func _RingArea(ring []Coord) float64 {
+	r := 6378137.0
+	if len(ring) < 3 {
+		return 0
+	}
+	area := float64(0)
+	for i := 0; i < len(ring); i++ {
+		p1 := ring[i]
+		p2 := ring[(i+1)%len(ring)]
+		lon1 := float64(p1[0]) * math.Pi / 180
+		lon2 := float64(p2[0]) * math.Pi / 180
+		lat1 := float64(p1[1]) * math.Pi / 180
+		lat2 := float64(p2[1]) * math.Pi / 180
+		area += (lon2 - lon1) * (2 + math.Sin(lat1) + math.Sin(lat2))
+	}
+	return area * r * r / 2
+}
@@ -0,0 +1,22 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPolygonArea(t *testing.T) {
+	// 1°x1° square at the equator (~12364 km²)
+	square := [][]Coord{{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {0, 0}}}
+	area := PolygonArea(square)
+	if math.Abs(area-12364e6) > 50e6 {
+		t.Errorf("expected area of ~12364 km², got %v km²", area/1e6)
+	}
+
+	// hole of a quarter of the square
+	holed := [][]Coord{square[0], {{0, 0}, {0, 0.5}, {0.5, 0.5}, {0.5, 0}, {0, 0}}}
+	holed_area := PolygonArea(holed)
+	if math.Abs(holed_area-0.75*area) > 10e6 {
+		t.Errorf("expected area of ~%v km², got %v km²", 0.75*area/1e6, holed_area/1e6)
+	}
+}
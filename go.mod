@@ -7,6 +7,7 @@ toolchain go1.24.1
 require (
 	github.com/paulmach/osm v0.8.0
 	golang.org/x/exp v0.0.0-20250305212735-054e65f0b394
+	google.golang.org/protobuf v1.27.1
 	gopkg.in/yaml.v3 v3.0.1
 )
 
@@ -14,5 +15,4 @@ require (
 	github.com/datadog/czlib v0.0.0-20160811164712-4bc9a24e37f2 // indirect
 	github.com/paulmach/orb v0.1.3 // indirect
 	github.com/paulmach/protoscan v0.2.1 // indirect
-	google.golang.org/protobuf v1.27.1 // indirect
 )
@@ -66,6 +66,14 @@ func (self *TransitGraph) ConnectionCount() int {
 func (self *TransitGraph) GetConnection(connection int32) structs.Connection {
 	return self.transit.GetConnection(connection)
 }
+func (self *TransitGraph) GetTransferTime(stop int32) int32 {
+	return self.transit.GetTransferTime(stop)
+}
+
+// Calls the handler with every edge walked by the transfer.
+func (self *TransitGraph) GetEdgesFromShortcut(shortcut int32, handler func(int32)) {
+	self.transit.GetEdgesFromShortcut(shortcut, false, handler)
+}
 func (self *TransitGraph) GetTransitExplorer() *TransitGraphExplorer {
 	return &TransitGraphExplorer{
 		graph:            self,
@@ -74,6 +82,42 @@ func (self *TransitGraph) GetTransitExplorer() *TransitGraphExplorer {
 	}
 }
 
+// Maximum time (in s) a vehicle is expected to wait at a stop.
+const MAX_DWELL_TIME = 3600
+
+// Follows the trip of the weight starting at the connection ref, calls callback for every stop reached.
+//
+// Following is stopped if callback returns false.
+func (self *TransitGraph) FollowTrip(ref EdgeRef, weight comps.ConnectionWeight, callback func(ref EdgeRef, weight comps.ConnectionWeight) bool) {
+	// own explorer since trips are usually followed while iterating the adjacent edges of the boarding stop
+	explorer := self.GetTransitExplorer()
+	// trips are finite, the limit only guards against malformed schedules
+	for i := 0; i < 10000; i++ {
+		if !callback(ref, weight) {
+			return
+		}
+		stop := ref.OtherID
+		time := weight.Arrival
+		found := false
+		explorer.ForAdjacentEdges(stop, FORWARD, ADJACENT_ALL, func(next EdgeRef) {
+			if found || next.IsShortcut() {
+				return
+			}
+			for _, w := range explorer.GetConnectionWeights(next, time, time+MAX_DWELL_TIME) {
+				if w.Trip == weight.Trip {
+					ref = next
+					weight = w
+					found = true
+					return
+				}
+			}
+		})
+		if !found {
+			return
+		}
+	}
+}
+
 //*******************************************
 // transit-graph explorer
 //*******************************************
@@ -1,8 +1,14 @@
 package main
 
 import (
+	"errors"
+	"math"
+	"slices"
+
+	"github.com/ttpr0/go-routing/geo"
 	"github.com/ttpr0/go-routing/isochrone"
 	"github.com/ttpr0/go-routing/routing"
+	. "github.com/ttpr0/go-routing/util"
 )
 
 //**********************************************************
@@ -14,6 +20,27 @@ type IsochroneRequest struct {
 	Range     []int32     `json:"range"`
 	Profile   string      `json:"profile"`
 	Metric    string      `json:"metric"`
+	// if true isochrones of all locations are merged into one polygon per range
+	Union bool `json:"union"`
+	// public-transit only: weekday (e.g. "monday") or ISO-date (e.g. "2024-05-01") of travel (defaults to "monday")
+	ScheduleDay string `json:"schedule_day"`
+	// public-transit only: id of a scenario adding hypothetical lines
+	Scenario string `json:"scenario"`
+	// public-transit only: time-span during which routes are allowed to start (defaults to 10h - 12h)
+	TimeWindow [2]int32 `json:"time_window"`
+	// public-transit only: exact departure time; overrides the time_window
+	DepartureTime *int32 `json:"departure_time"`
+	// public-transit only: one of ["best", "median"]; median computes isochrones for every departure minute within the time_window
+	Aggregation string `json:"aggregation"`
+	// size of the raster cells (in m) used to build the isochrones; defaults depend on the profile (e.g. 400m for cars, 50m for pedestrians)
+	Resolution float32 `json:"resolution"`
+	// additional properties of every isochrone, any of ["area", "reachfactor", "total_pop"]
+	Attributes []string `json:"attributes"`
+	// if true the intersections between isochrones of different locations are added
+	Intersections bool `json:"intersections"`
+
+	// profile from the request path
+	PathProfile string `json:"-" path:"profile"`
 }
 
 //**********************************************************
@@ -21,33 +48,236 @@ type IsochroneRequest struct {
 //**********************************************************
 
 func HandleIsochroneRequest(req IsochroneRequest) Result {
+	if len(req.Locations) == 0 {
+		return BadRequest("No locations specified")
+	}
+	locs := make([][2]float32, len(req.Locations))
+	for i, loc := range req.Locations {
+		if len(loc) < 2 {
+			return BadRequest("Invalid location")
+		}
+		locs[i] = [2]float32{loc[0], loc[1]}
+	}
+	if len(req.Range) == 0 {
+		return BadRequest("No range specified")
+	}
 	// get profile
-	loc := [2]float32{req.Locations[0][0], req.Locations[0][1]}
+	if req.PathProfile != "" {
+		if req.Profile != "" && req.Profile != req.PathProfile {
+			return BadRequest("Profile in body differs from path")
+		}
+		req.Profile = req.PathProfile
+	}
 	if req.Profile == "" {
 		req.Profile = "driving-car"
 	}
 	if req.Metric == "" {
 		req.Metric = "time"
 	}
+	if req.ScheduleDay == "" {
+		req.ScheduleDay = "monday"
+	}
+	if req.TimeWindow[0] == 0 && req.TimeWindow[1] == 0 {
+		req.TimeWindow = [2]int32{36000, 43200}
+	}
+	if req.DepartureTime != nil {
+		req.TimeWindow = [2]int32{*req.DepartureTime, *req.DepartureTime}
+	}
+	if req.TimeWindow[0] > req.TimeWindow[1] {
+		return BadRequest("Invalid time window")
+	}
+	switch req.Aggregation {
+	case "", "best", "median":
+	default:
+		return BadRequest("Invalid aggregation")
+	}
+	if req.Resolution != 0 && req.Resolution < 10 {
+		return BadRequest("Resolution must be at least 10m")
+	}
+	if req.Intersections && req.Union {
+		return BadRequest("Intersections can not be computed for unioned isochrones")
+	}
+	for _, attribute := range req.Attributes {
+		switch attribute {
+		case "area", "reachfactor":
+		case "total_pop":
+			if !POPULATION.HasValue() {
+				return BadRequest("No population data configured")
+			}
+		default:
+			return BadRequest("Invalid attribute " + attribute)
+		}
+	}
 	profile_, res := GetRequestProfile(MANAGER, req.Profile, req.Metric)
 	if !profile_.HasValue() {
 		return res
 	}
 	profile := profile_.Value
 	att := profile.GetAttributes()
-	var spt routing.IShortestPathTree
-	g_ := profile.GetTransitGraph("monday")
+	max_range := slices.Max(req.Range)
+	// the raster is shared by all locations to allow intersecting them
+	resolution := req.Resolution
+	if resolution == 0 {
+		resolution = _GetDefaultIsoResolution(profile)
+	}
+	options := isochrone.NewIsoOptions(locs, resolution, _GetMaxReachableDistance(profile, int(max_range)))
+	var compute func([][2]float32) *isochrone.IsoRaster
+	g_, res := GetRequestTransitGraph(profile, req.Scenario, req.ScheduleDay)
+	if req.Scenario != "" && !g_.HasValue() {
+		return res
+	}
 	if g_.HasValue() {
 		g := g_.Value
-		spt = routing.NewShortestPathTree4(g, 36000, 43200)
+		spt := routing.NewShortestPathTree4(g, req.TimeWindow[0], req.TimeWindow[1])
+		if req.Aggregation == "median" {
+			// one shortest-path-tree per departure minute
+			spts := func(yield func(routing.IShortestPathTree) bool) {
+				for t := req.TimeWindow[0]; t <= req.TimeWindow[1]; t += 60 {
+					spt.SetTimeWindow(t, t)
+					if !yield(spt) {
+						return
+					}
+				}
+			}
+			compute = func(locs [][2]float32) *isochrone.IsoRaster {
+				return isochrone.CalcMedianIsoRaster(spts, att, locs, max_range, options)
+			}
+		} else {
+			compute = func(locs [][2]float32) *isochrone.IsoRaster {
+				return isochrone.CalcIsoRaster(spt, att, locs, max_range, options)
+			}
+		}
 	} else {
+		if profile.Profile() == TRANSIT {
+			return BadRequest("Schedule not found")
+		}
 		g_ := profile.GetGraph()
 		if !g_.HasValue() {
 			return BadRequest("Graph not found")
 		}
 		g := g_.Value
-		spt = routing.NewShortestPathTree5(g)
+		var spt routing.IShortestPathTree
+		if HasTurnCosts(g) {
+			spt = routing.NewShortestPathTreeTC(g)
+		} else {
+			spt = routing.NewShortestPathTree5(g)
+		}
+		compute = func(locs [][2]float32) *isochrone.IsoRaster {
+			return isochrone.CalcIsoRaster(spt, att, locs, max_range, options)
+		}
+	}
+	if req.Union {
+		resp := compute(locs).BuildIsochrone(req.Range)
+		if err := _AddIsochroneAttributes(resp.Features(), req.Attributes, profile, req.Range); err != nil {
+			return BadRequest(err.Error())
+		}
+		return OK(resp)
+	}
+	// compute isochrones of every location tagged by its index
+	rasters := make([]*isochrone.IsoRaster, len(locs))
+	features := NewList[geo.Feature](len(locs) * len(req.Range))
+	for i, loc := range locs {
+		rasters[i] = compute([][2]float32{loc})
+		fc := rasters[i].BuildIsochrone(req.Range)
+		for _, feature := range fc.Features() {
+			feature.Properties()["group_index"] = i
+			features.Add(feature)
+		}
+	}
+	if req.Intersections {
+		range_indices := NewDict[int, int](len(req.Range))
+		for k, r := range req.Range {
+			range_indices[int(r)] = k
+		}
+		for i := 0; i < len(rasters); i++ {
+			for j := i + 1; j < len(rasters); j++ {
+				inter := isochrone.IntersectIsoRasters(rasters[i], rasters[j])
+				if !inter.HasValue() {
+					continue
+				}
+				fc := inter.Value.BuildIsochrone(req.Range)
+				for _, feature := range fc.Features() {
+					k := range_indices[feature.Properties()["value"].(int)]
+					feature.Properties()["contours"] = [][2]int{{i, k}, {j, k}}
+					features.Add(feature)
+				}
+			}
+		}
+	}
+	if err := _AddIsochroneAttributes(features, req.Attributes, profile, req.Range); err != nil {
+		return BadRequest(err.Error())
 	}
-	resp := isochrone.ComputeIsochrone(spt, att, loc, req.Range)
+	resp := geo.NewFeatureCollection(features)
 	return OK(&resp)
 }
+
+// Sets the requested attributes as properties of every isochrone feature.
+func _AddIsochroneAttributes(features List[geo.Feature], attributes []string, profile IRoutingProfile, ranges []int32) error {
+	for _, attribute := range attributes {
+		for _, feature := range features {
+			polygon := feature.Geometry().(*geo.Polygon).Coordinates()
+			props := feature.Properties()
+			switch attribute {
+			case "area":
+				props["area"] = geo.PolygonArea(polygon)
+			case "reachfactor":
+				max_area, err := _GetMaxReachableArea(profile, props["value"].(int))
+				if err != nil {
+					return err
+				}
+				props["reachfactor"] = geo.PolygonArea(polygon) / max_area
+			case "total_pop":
+				props["total_pop"] = POPULATION.Value.SumInPolygon(polygon)
+			}
+		}
+	}
+	return nil
+}
+
+// Returns the area of the circle reachable within value moving at the maximum speed of the profile.
+func _GetMaxReachableArea(profile IRoutingProfile, value int) (float64, error) {
+	if profile.Profile() == TRANSIT {
+		return 0, errors.New("reachfactor is not supported for public-transit")
+	}
+	radius := float64(_GetMaxReachableDistance(profile, value))
+	if radius <= 0 {
+		return 0, errors.New("reachfactor requires ranges larger than 0")
+	}
+	return math.Pi * radius * radius, nil
+}
+
+// Returns the distance (in m) reachable within value moving at the maximum speed of the profile.
+func _GetMaxReachableDistance(profile IRoutingProfile, value int) float32 {
+	if profile.Metric() == SHORTEST {
+		return float32(value)
+	}
+	var speed float32
+	if profile.Profile() == TRANSIT {
+		speed = 100
+	} else {
+		switch profile.Vehicle() {
+		case CAR:
+			speed = 130
+		case BIKE:
+			speed = 18
+		case FOOT:
+			speed = 5
+		}
+	}
+	return float32(value) * speed / 3.6
+}
+
+// Returns the default size of the isochrone raster cells (in m).
+func _GetDefaultIsoResolution(profile IRoutingProfile) float32 {
+	if profile.Profile() == TRANSIT {
+		return 200
+	}
+	switch profile.Vehicle() {
+	case CAR:
+		return 400
+	case BIKE:
+		return 100
+	default:
+		return 50
+	}
+}
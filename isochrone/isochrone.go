@@ -3,6 +3,7 @@ package isochrone
 import (
 	"fmt"
 	"math"
+	"slices"
 
 	"github.com/ttpr0/go-routing/attr"
 	"github.com/ttpr0/go-routing/geo"
@@ -15,35 +16,193 @@ import (
 // isochrone handler
 //**********************************************************
 
-func ComputeIsochrone(spt routing.IShortestPathTree, att attr.IAttributes, location [2]float32, ranges []int32) *geo.FeatureCollection {
-	start := geo.Coord{location[0], location[1]}
-	cellsize := int32(400)
+// Computes isochrones reachable from any of the locations.
+//
+// Isochrones of multiple locations are merged into a single polygon per range.
+func ComputeIsochrone(spt routing.IShortestPathTree, att attr.IAttributes, locations [][2]float32, ranges []int32, options IsoOptions) *geo.FeatureCollection {
+	raster := CalcIsoRaster(spt, att, locations, ranges[len(ranges)-1], options)
+	slog.Debug("start building isochrone")
+	resp := raster.BuildIsochrone(ranges)
+	slog.Debug("reponse build")
+	return resp
+}
+
+//**********************************************************
+// isochrone options
+//**********************************************************
+
+// Resolution and extent of the isochrone raster.
+type IsoOptions struct {
+	// size of a raster cell (in web-mercator units)
+	CellSize int32
+	// number of cells between a location and the raster border
+	IsoSize int32
+}
+
+// Creates options for rasters of cellsize meters covering max_dist meters around all locations.
+//
+// Web-mercator distorts distances by 1/cos(lat), cellsize is scaled to the mean latitude of the locations and the extent to the most distorted one.
+func NewIsoOptions(locations [][2]float32, cellsize float32, max_dist float32) IsoOptions {
+	mean_scale := float64(0)
+	max_scale := float64(1)
+	for _, location := range locations {
+		scale := 1 / math.Cos(float64(location[1])*math.Pi/180)
+		mean_scale += scale
+		max_scale = max(max_scale, scale)
+	}
+	if len(locations) > 0 {
+		mean_scale /= float64(len(locations))
+	} else {
+		mean_scale = 1
+	}
+	proj_cellsize := max(int32(float64(cellsize)*mean_scale), 1)
+	// add a margin to keep the isochrones off the border
+	isosize := int32(math.Ceil(float64(max_dist)*max_scale/float64(proj_cellsize))) + 2
+	return IsoOptions{
+		CellSize: proj_cellsize,
+		IsoSize:  isosize,
+	}
+}
+
+//**********************************************************
+// isochrone raster
+//**********************************************************
+
+// Raster of the minimum travel-times reaching every cell.
+type IsoRaster struct {
+	points     *IsoTree[int]
+	extent     [4]int32
+	rasterizer IRasterizer
+	projection IProjection
+}
+
+// Computes the travel-times reachable from any of the locations.
+func CalcIsoRaster(spt routing.IShortestPathTree, att attr.IAttributes, locations [][2]float32, max_range int32, options IsoOptions) *IsoRaster {
+	projection := &WebMercatorProjection{}
+	rasterizer := NewRasterizer(options.CellSize)
+	starts, extent := _GetIsoExtent(locations, options.IsoSize, rasterizer, projection)
+	points := _CalcIsoPoints(spt, att, starts, max_range, extent, rasterizer, projection)
+	return &IsoRaster{
+		points:     points,
+		extent:     extent,
+		rasterizer: rasterizer,
+		projection: projection,
+	}
+}
+
+// Computes the median travel-times of multiple shortest-path-trees.
+func CalcMedianIsoRaster(spts func(yield func(routing.IShortestPathTree) bool), att attr.IAttributes, locations [][2]float32, max_range int32, options IsoOptions) *IsoRaster {
 	projection := &WebMercatorProjection{}
-	rasterizer := NewRasterizer(cellsize)
-	centerx, centery := rasterizer.PointToIndex(projection.Proj(start))
-	isosize := int32(2000)
+	rasterizer := NewRasterizer(options.CellSize)
+	starts, extent := _GetIsoExtent(locations, options.IsoSize, rasterizer, projection)
+	// collect the travel-times of all trees per cell
+	values := NewIsoTree[List[int]](extent)
+	count := 0
+	for spt := range spts {
+		points := _CalcIsoPoints(spt, att, starts, max_range, extent, rasterizer, projection)
+		for cell := range points.Traverse() {
+			value := cell.C
+			values.Insert(cell.A, cell.B, func(other List[int]) List[int] {
+				other.Add(value)
+				return other
+			})
+		}
+		count += 1
+	}
+	slog.Debug(fmt.Sprintf("computed %v shortest-path-trees", count))
+	points := NewIsoTree[int](extent)
+	for cell := range values.Traverse() {
+		cell_values := cell.C
+		// missing values are unreachable and sorted behind all others
+		median_index := (count - 1) / 2
+		if median_index >= cell_values.Length() {
+			continue
+		}
+		slices.Sort(cell_values)
+		points.InsertValue(cell.A, cell.B, cell_values[median_index])
+	}
+	return &IsoRaster{
+		points:     points,
+		extent:     extent,
+		rasterizer: rasterizer,
+		projection: projection,
+	}
+}
+
+// Returns the raster of cells reached in both rasters.
+//
+// Cells are set to the larger of both travel-times. Both rasters have to share the same cell-size.
+func IntersectIsoRasters(a, b *IsoRaster) Optional[*IsoRaster] {
+	extent := [4]int32{max(a.extent[0], b.extent[0]), max(a.extent[1], b.extent[1]), min(a.extent[2], b.extent[2]), min(a.extent[3], b.extent[3])}
+	if extent[0] > extent[2] || extent[1] > extent[3] {
+		return None[*IsoRaster]()
+	}
+	points := NewIsoTree[int](extent)
+	for cell := range a.points.Traverse() {
+		other, ok := b.points.Get(cell.A, cell.B)
+		if !ok {
+			continue
+		}
+		points.InsertValue(cell.A, cell.B, max(cell.C, other))
+	}
+	return Some(&IsoRaster{
+		points:     points,
+		extent:     extent,
+		rasterizer: a.rasterizer,
+		projection: a.projection,
+	})
+}
+
+// Extracts the isochrone polygons of all ranges.
+func (self *IsoRaster) BuildIsochrone(ranges []int32) *geo.FeatureCollection {
+	return _BuildIsochrone(self.points, ranges, self.extent, self.rasterizer, self.projection)
+}
+
+// Returns the start coordinates and the raster extent covering isosize cells around every location.
+func _GetIsoExtent(locations [][2]float32, isosize int32, rasterizer IRasterizer, projection IProjection) ([]geo.Coord, [4]int32) {
+	starts := make([]geo.Coord, len(locations))
+	extent := [4]int32{math.MaxInt32, math.MaxInt32, math.MinInt32, math.MinInt32}
+	for i, location := range locations {
+		start := geo.Coord{location[0], location[1]}
+		starts[i] = start
+		centerx, centery := rasterizer.PointToIndex(projection.Proj(start))
+		extent[0] = min(extent[0], centerx-isosize+1)
+		extent[1] = min(extent[1], centery-isosize+1)
+		extent[2] = max(extent[2], centerx+isosize-1)
+		extent[3] = max(extent[3], centery+isosize-1)
+	}
+	return starts, extent
+}
+
+// Computes the shortest-path-trees from all starts and rasterizes the minimum travel-times.
+func _CalcIsoPoints(spt routing.IShortestPathTree, att attr.IAttributes, starts []geo.Coord, max_range int32, extent [4]int32, rasterizer IRasterizer, projection IProjection) *IsoTree[int] {
 	consumer := &SPTIsochroneConsumer{
-		points:     NewIsoTree[int]([4]int32{centerx - isosize + 1, centery - isosize + 1, centerx + isosize - 1, centery + isosize - 1}),
+		points:     NewIsoTree[int](extent),
 		rasterizer: rasterizer,
 		projection: projection,
 		att:        att,
 	}
-	s_node, _ := att.GetClosestNode(start)
-	slog.Debug(fmt.Sprintf("Start Caluclating shortest-path-tree from %v", start))
-	spt.CalcShortestPathTree(s_node, ranges[len(ranges)-1], consumer)
-	slog.Debug("shortest-path-tree finished")
-	slog.Debug("start building isochrone")
+	for _, start := range starts {
+		s_node, _ := att.GetClosestNode(start)
+		slog.Debug(fmt.Sprintf("Start Caluclating shortest-path-tree from %v", start))
+		spt.CalcShortestPathTree(s_node, max_range, consumer)
+		slog.Debug("shortest-path-tree finished")
+	}
+	return consumer.points
+}
+
+// Extracts the isochrone polygons of all ranges from the rasterized travel-times.
+func _BuildIsochrone(points *IsoTree[int], ranges []int32, extent [4]int32, rasterizer IRasterizer, projection IProjection) *geo.FeatureCollection {
 	// create tree containing marching square cells
-	mq_tree := NewIsoTree[Square]([4]int32{centerx - isosize, centery - isosize, centerx + isosize, centery + isosize})
+	mq_tree := NewIsoTree[Square]([4]int32{extent[0] - 1, extent[1] - 1, extent[2] + 1, extent[3] + 1})
 	features := NewList[geo.Feature](len(ranges))
 	for i := len(ranges) - 1; i >= 0; i-- {
-		_features := _ExtractIsochrone(consumer.points, mq_tree, int(ranges[i]), rasterizer, projection)
+		_features := _ExtractIsochrone(points, mq_tree, int(ranges[i]), rasterizer, projection)
 		for _, feature := range _features {
 			features.Add(feature)
 		}
 	}
 	resp := geo.NewFeatureCollection(features)
-	slog.Debug("reponse build")
 	return &resp
 }
 
@@ -105,7 +264,8 @@ func (self *SPTIsochroneConsumer) ConsumeEdge(edge int32, start_value int, end_v
 		}
 		self.points.Insert(x, y, valuefunc)
 	}
-	_SampleAlongLine(geom, 50, callback)
+	// sample densely enough to hit every cell along the edge
+	_SampleAlongLine(geom, min(50, self.rasterizer.GetCellSize()/2), callback)
 }
 
 type IProjection interface {
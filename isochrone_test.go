@@ -0,0 +1,15 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestIsochroneRequestWithoutRange(t *testing.T) {
+	for _, r := range [][]int32{nil, {}} {
+		res := HandleIsochroneRequest(IsochroneRequest{Locations: [][]float32{{8.6, 49.4}}, Range: r})
+		if res.status != http.StatusBadRequest {
+			t.Errorf("range %v: expected status %v, got %v", r, http.StatusBadRequest, res.status)
+		}
+	}
+}
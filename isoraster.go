@@ -84,10 +84,13 @@ func HandleIsoRasterRequest(req IsoRasterRequest) Result {
 		rasterizer: NewDefaultRasterizer(req.Precession),
 	}
 	s_node, _ := att.GetClosestNode(start)
-	spt := routing.NewShortestPathTree(g, s_node, req.Range, consumer)
 
 	slog.Debug(fmt.Sprintf("Start Caluclating shortest-path-tree from %v", start))
-	spt.CalcShortestPathTree()
+	if HasTurnCosts(g) {
+		routing.NewShortestPathTreeTC(g).CalcShortestPathTree(s_node, req.Range, consumer)
+	} else {
+		routing.NewShortestPathTree(g, s_node, req.Range, consumer).CalcShortestPathTree()
+	}
 	slog.Debug("shortest-path-tree finished")
 	slog.Debug("start building response")
 	resp := NewIsoRasterResponse(consumer.points.ToSlice(), consumer.rasterizer)
@@ -0,0 +1,402 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/ttpr0/go-routing/util"
+	"golang.org/x/exp/slog"
+)
+
+//**********************************************************
+// job requests and responses
+//**********************************************************
+
+type JobResponse struct {
+	ID       string    `json:"id"`
+	Type     string    `json:"type"`
+	Status   JobStatus `json:"status"`
+	Done     int32     `json:"done"`
+	Total    int32     `json:"total"`
+	Error    string    `json:"error,omitempty"`
+	Created  time.Time `json:"created"`
+	Finished time.Time `json:"finished"`
+}
+
+//**********************************************************
+// job handlers
+//**********************************************************
+
+func HandleMatrixJobRequest(req MatrixRequest) Result {
+	slog.Info("Run Matrix Job Request")
+
+	// validate request before queuing it
+	if _, res := GetRequestProfile(MANAGER, req.Profile, req.Metric); res.status != http.StatusOK {
+		return res
+	}
+	job := NewJob("matrix", int32(req.Sources.Length()), func(job *Job) (any, error) {
+		task_, res := PrepareMatrixTask(req)
+		if !task_.HasValue() {
+			return nil, fmt.Errorf("%v", res.result)
+		}
+		task := task_.Value
+		workers := _GetMatrixWorkerCount(MANAGER._GetServiceConfig(), task.source_nodes.Length())
+		matrix := NewMatrix[float32](task.source_nodes.Length(), task.target_nodes.Length())
+		err := CalcMatrixRows(task.otm, task.source_nodes, task.target_nodes, task.max_range, workers, func(s int, row Array[float32]) error {
+			if job.IsCanceled() {
+				return ErrJobCanceled
+			}
+			for t, dist := range row {
+				matrix.Set(s, t, dist)
+			}
+			job.done.Add(1)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return MatrixResponse{Distances: matrix}, nil
+	})
+	if err := JOBS.Submit(job); err != nil {
+		return BadRequest(err.Error())
+	}
+	return OK(job.Info())
+}
+
+func HandleAccessibilityJobRequest(req AccessibilityRequest) Result {
+	slog.Info("Run Accessibility Job Request")
+
+	// validate request before queuing it
+	if _, err := NewDistanceDecay(req.Decay); err != nil {
+		return BadRequest(err.Error())
+	}
+	if _, res := GetRequestProfile(MANAGER, req.Profile, req.Metric); res.status != http.StatusOK {
+		return res
+	}
+	job := NewJob("accessibility", _GetAccessibilityRowCount(req.Measure, req.SupplyLocations.Length()), func(job *Job) (any, error) {
+		task_, res := PrepareAccessibilityTask(req)
+		if !task_.HasValue() {
+			return nil, fmt.Errorf("%v", res.result)
+		}
+		task := task_.Value
+		workers := _GetMatrixWorkerCount(MANAGER._GetServiceConfig(), task.supply_nodes.Length())
+		access, err := CalcAccessibility(task, workers, func() error {
+			if job.IsCanceled() {
+				return ErrJobCanceled
+			}
+			job.done.Add(1)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return AccessibilityResponse{Access: access}, nil
+	})
+	if err := JOBS.Submit(job); err != nil {
+		return BadRequest(err.Error())
+	}
+	return OK(job.Info())
+}
+
+func HandleGetJobRequest(id string) Result {
+	job_ := JOBS.GetJob(id)
+	if !job_.HasValue() {
+		return NotFound("Job not found")
+	}
+	return OK(job_.Value)
+}
+
+func HandleGetJobResultRequest(id string) Result {
+	job_ := JOBS.GetJob(id)
+	if !job_.HasValue() {
+		return NotFound("Job not found")
+	}
+	job := job_.Value
+	if job.Status != JOB_FINISHED {
+		return BadRequest(fmt.Sprintf("Job is %v", job.Status))
+	}
+	data, err := os.ReadFile(JOBS.ResultFile(job.ID))
+	if err != nil {
+		return NotFound("Job result not found")
+	}
+	return OK(json.RawMessage(data))
+}
+
+func HandleDeleteJobRequest(id string) Result {
+	job_ := JOBS.CancelJob(id)
+	if !job_.HasValue() {
+		return NotFound("Job not found")
+	}
+	return OK(job_.Value)
+}
+
+//**********************************************************
+// job manager
+//**********************************************************
+
+var ErrJobCanceled = errors.New("job canceled")
+
+type JobStatus string
+
+const (
+	JOB_QUEUED   JobStatus = "queued"
+	JOB_RUNNING  JobStatus = "running"
+	JOB_FINISHED JobStatus = "finished"
+	JOB_FAILED   JobStatus = "failed"
+	JOB_CANCELED JobStatus = "canceled"
+)
+
+type Job struct {
+	id      string
+	typ     string
+	total   int32
+	done    atomic.Int32
+	created time.Time
+	run     func(*Job) (any, error)
+
+	lock     sync.Mutex
+	status   JobStatus
+	err      string
+	finished time.Time
+	canceled atomic.Bool
+}
+
+// Creates a new queued job computing its result using run.
+//
+// Total denotes the number of work items (e.g. sources) the job has to process.
+func NewJob(typ string, total int32, run func(*Job) (any, error)) *Job {
+	return &Job{
+		id:      _NewJobID(),
+		typ:     typ,
+		total:   total,
+		created: time.Now(),
+		run:     run,
+		status:  JOB_QUEUED,
+	}
+}
+
+// Returns true if cancellation of the job has been requested.
+func (self *Job) IsCanceled() bool {
+	return self.canceled.Load()
+}
+
+func (self *Job) Info() JobResponse {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	return JobResponse{
+		ID:       self.id,
+		Type:     self.typ,
+		Status:   self.status,
+		Done:     self.done.Load(),
+		Total:    self.total,
+		Error:    self.err,
+		Created:  self.created,
+		Finished: self.finished,
+	}
+}
+
+func (self *Job) _SetStatus(status JobStatus, err string) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.status = status
+	self.err = err
+	if status != JOB_RUNNING {
+		self.finished = time.Now()
+	}
+}
+
+// Creates a new job manager running jobs in the background.
+//
+// Results of finished jobs are persisted to directory, jobs finished during earlier runs are loaded from there.
+// Finished, failed and canceled jobs are removed together with their results once they are older than ttl (defaults to one day).
+func NewJobManager(directory string, workers int, queue_size int, ttl time.Duration) *JobManager {
+	if workers <= 0 {
+		workers = 1
+	}
+	if queue_size <= 0 {
+		queue_size = 100
+	}
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	os.MkdirAll(directory, os.ModePerm)
+	manager := &JobManager{
+		directory: directory,
+		queue:     make(chan *Job, queue_size),
+		jobs:      NewDict[string, *Job](10),
+		ttl:       ttl,
+	}
+	manager._LoadFinishedJobs()
+	manager._RemoveExpiredJobs(time.Now())
+	for i := 0; i < workers; i++ {
+		go manager._RunWorker()
+	}
+	go manager._RunCleanup()
+	return manager
+}
+
+type JobManager struct {
+	directory string
+	queue     chan *Job
+	lock      sync.Mutex
+	jobs      Dict[string, *Job]
+	// time jobs are kept after they finished
+	ttl time.Duration
+}
+
+// Adds the job to the queue, fails if the queue is full.
+func (self *JobManager) Submit(job *Job) error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	select {
+	case self.queue <- job:
+		self.jobs.Set(job.id, job)
+		return nil
+	default:
+		return errors.New("job queue is full")
+	}
+}
+
+func (self *JobManager) GetJob(id string) Optional[JobResponse] {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if !self.jobs.ContainsKey(id) {
+		return None[JobResponse]()
+	}
+	return Some(self.jobs.Get(id).Info())
+}
+
+// Cancels a queued or running job.
+//
+// Finished jobs are removed together with their persisted result.
+func (self *JobManager) CancelJob(id string) Optional[JobResponse] {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if !self.jobs.ContainsKey(id) {
+		return None[JobResponse]()
+	}
+	job := self.jobs.Get(id)
+	job.canceled.Store(true)
+	info := job.Info()
+	switch info.Status {
+	case JOB_QUEUED:
+		job._SetStatus(JOB_CANCELED, "")
+	case JOB_FINISHED, JOB_FAILED, JOB_CANCELED:
+		self.jobs.Delete(id)
+		os.Remove(self.ResultFile(id))
+		os.Remove(self._InfoFile(id))
+	}
+	return Some(job.Info())
+}
+
+func (self *JobManager) ResultFile(id string) string {
+	return self.directory + "/" + id + ".json"
+}
+func (self *JobManager) _InfoFile(id string) string {
+	return self.directory + "/" + id + "-info.json"
+}
+
+func (self *JobManager) _RunWorker() {
+	for job := range self.queue {
+		if job.IsCanceled() {
+			continue
+		}
+		job._SetStatus(JOB_RUNNING, "")
+		slog.Info(fmt.Sprintf("Running %v job %v", job.typ, job.id))
+		result, err := job.run(job)
+		if err == nil {
+			err = _WriteJobResult(result, self.ResultFile(job.id))
+		}
+		switch {
+		case job.IsCanceled():
+			os.Remove(self.ResultFile(job.id))
+			job._SetStatus(JOB_CANCELED, "")
+		case err != nil:
+			slog.Error(fmt.Sprintf("%v job %v failed: %v", job.typ, job.id, err.Error()))
+			job._SetStatus(JOB_FAILED, err.Error())
+		default:
+			slog.Info(fmt.Sprintf("Finished %v job %v", job.typ, job.id))
+			job._SetStatus(JOB_FINISHED, "")
+			WriteJSONToFile(job.Info(), self._InfoFile(job.id))
+		}
+	}
+}
+
+func (self *JobManager) _RunCleanup() {
+	ticker := time.NewTicker(min(self.ttl, time.Hour))
+	defer ticker.Stop()
+	for now := range ticker.C {
+		self._RemoveExpiredJobs(now)
+	}
+}
+
+// Removes the jobs finished before now-ttl and their persisted results.
+func (self *JobManager) _RemoveExpiredJobs(now time.Time) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	for id, job := range self.jobs {
+		info := job.Info()
+		switch info.Status {
+		case JOB_FINISHED, JOB_FAILED, JOB_CANCELED:
+		default:
+			continue
+		}
+		if now.Before(info.Finished.Add(self.ttl)) {
+			continue
+		}
+		slog.Info(fmt.Sprintf("Removing expired %v job %v", info.Type, id))
+		self.jobs.Delete(id)
+		os.Remove(self.ResultFile(id))
+		os.Remove(self._InfoFile(id))
+	}
+}
+
+func (self *JobManager) _LoadFinishedJobs() {
+	files, err := os.ReadDir(self.directory)
+	if err != nil {
+		return
+	}
+	for _, file := range files {
+		name := file.Name()
+		if !strings.HasSuffix(name, "-info.json") {
+			continue
+		}
+		info := ReadJSONFromFile[JobResponse](self.directory + "/" + name)
+		if info.ID == "" {
+			continue
+		}
+		job := &Job{
+			id:       info.ID,
+			typ:      info.Type,
+			total:    info.Total,
+			created:  info.Created,
+			status:   info.Status,
+			finished: info.Finished,
+		}
+		job.done.Store(info.Done)
+		self.jobs.Set(job.id, job)
+	}
+}
+
+func _WriteJobResult(result any, file string) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0644)
+}
+
+func _NewJobID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRemoveExpiredJobs(t *testing.T) {
+	manager := NewJobManager(t.TempDir(), 1, 10, time.Hour)
+	finished := NewJob("test", 1, func(job *Job) (any, error) {
+		return []int{1}, nil
+	})
+	release := make(chan bool)
+	running := NewJob("test", 1, func(job *Job) (any, error) {
+		<-release
+		return nil, nil
+	})
+	defer close(release)
+	manager.Submit(finished)
+	manager.Submit(running)
+	for i := 0; manager.GetJob(finished.id).Value.Status != JOB_FINISHED; i++ {
+		if i == 100 {
+			t.Fatal("job not finished")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := os.Stat(manager.ResultFile(finished.id)); err != nil {
+		t.Fatalf("expected the result to be persisted: %v", err)
+	}
+
+	manager._RemoveExpiredJobs(time.Now().Add(30 * time.Minute))
+	if job := manager.GetJob(finished.id); !job.HasValue() {
+		t.Errorf("expected the job to be kept within its ttl")
+	}
+	manager._RemoveExpiredJobs(time.Now().Add(2 * time.Hour))
+	if job := manager.GetJob(finished.id); job.HasValue() {
+		t.Errorf("expected the expired job to be removed")
+	}
+	for _, file := range []string{manager.ResultFile(finished.id), manager._InfoFile(finished.id)} {
+		if _, err := os.Stat(file); !os.IsNotExist(err) {
+			t.Errorf("expected %v to be removed", file)
+		}
+	}
+	if job := manager.GetJob(running.id); !job.HasValue() {
+		t.Errorf("expected the running job to be kept")
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"github.com/ttpr0/go-routing/batched/knearest"
+	"github.com/ttpr0/go-routing/geo"
+	. "github.com/ttpr0/go-routing/util"
+	"golang.org/x/exp/slog"
+)
+
+//**********************************************************
+// k-nearest request and response
+//**********************************************************
+
+type KNearestRequest struct {
+	Facilities Array[geo.Coord] `json:"facilities"`
+	Demands    Array[geo.Coord] `json:"demands"`
+	K          int              `json:"k"`
+	Profile    string           `json:"profile"`
+	Metric     string           `json:"metric"`
+	MaxRange   int32            `json:"max_range"`
+}
+
+type KNearestResponse struct {
+	// indices of the k nearest facilities for every demand point ordered by travel time (-1 if not reachable)
+	Facilities Matrix[int32] `json:"facilities"`
+	// travel times to the k nearest facilities for every demand point (-1 if not reachable)
+	Distances Matrix[float32] `json:"distances"`
+}
+
+//**********************************************************
+// k-nearest handler
+//**********************************************************
+
+func HandleKNearestRequest(req KNearestRequest) Result {
+	slog.Info("Run K-Nearest Request")
+
+	var max_range int32
+	if req.MaxRange > 0 {
+		max_range = req.MaxRange
+	} else {
+		max_range = 100000000
+	}
+	k := req.K
+	if k <= 0 {
+		k = 1
+	}
+	if k > 127 {
+		return BadRequest("k must not be larger than 127")
+	}
+	// get profile
+	profile_, res := GetRequestProfile(MANAGER, req.Profile, req.Metric)
+	if !profile_.HasValue() {
+		return res
+	}
+	profile := profile_.Value
+	// map coords to nodes
+	att := profile.GetAttributes()
+	facility_nodes := MapCoordsToNodes(att, req.Facilities)
+	demand_nodes := MapCoordsToNodes(att, req.Demands)
+
+	// get graph
+	var alg knearest.IKNearest
+	ch_g := profile.GetCHGraph()
+	if ch_g.HasValue() {
+		slog.Info("Using K-RPHAST")
+		alg = knearest.NewKRPHAST(ch_g.Value, demand_nodes, max_range, k)
+	} else {
+		g := profile.GetGraph()
+		if !g.HasValue() {
+			return BadRequest("Graph not found")
+		}
+		if HasTurnCosts(g.Value) {
+			slog.Info("Using K-Many-Dijkstra with turn-costs")
+			alg = knearest.NewKManyDijkstraTC(g.Value, max_range, k)
+		} else {
+			slog.Info("Using K-Many-Dijkstra")
+			alg = knearest.NewKManyDijkstra(g.Value, max_range, k)
+		}
+	}
+
+	// every facility is its own source, unmapped facilities are skipped
+	sources := NewList[Array[Tuple[int32, int32]]](facility_nodes.Length())
+	for _, node := range facility_nodes {
+		if node == -1 {
+			sources.Add(Array[Tuple[int32, int32]]{})
+			continue
+		}
+		sources.Add(Array[Tuple[int32, int32]]{MakeTuple(node, int32(0))})
+	}
+	solver := alg.CreateSolver()
+	solver.CalcKNearestNeighbours(sources)
+
+	facilities := NewMatrix[int32](demand_nodes.Length(), k)
+	distances := NewMatrix[float32](demand_nodes.Length(), k)
+	for i, node := range demand_nodes {
+		for j := 0; j < k; j++ {
+			if node == -1 {
+				facilities.Set(i, j, -1)
+				distances.Set(i, j, -1)
+				continue
+			}
+			neighbour := solver.GetNeighbour(node, int8(j))
+			dist := solver.GetDistance(node, int8(j))
+			if neighbour == -1 || dist > max_range {
+				facilities.Set(i, j, -1)
+				distances.Set(i, j, -1)
+				continue
+			}
+			facilities.Set(i, j, neighbour)
+			distances.Set(i, j, float32(dist))
+		}
+	}
+
+	resp := KNearestResponse{
+		Facilities: facilities,
+		Distances:  distances,
+	}
+	slog.Info("K-Nearest reponse build")
+	return OK(resp)
+}
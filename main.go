@@ -3,11 +3,15 @@ package main
 import (
 	"net/http"
 	"os"
+	"time"
 
+	. "github.com/ttpr0/go-routing/util"
 	"golang.org/x/exp/slog"
 )
 
 var MANAGER *RoutingManager
+var JOBS *JobManager
+var POPULATION Optional[*PopulationIndex]
 
 func main() {
 	logger := slog.New(NewLogHandler(os.Stderr, &slog.HandlerOptions{
@@ -19,6 +23,19 @@ func main() {
 
 	config := ReadConfig("./config.yml")
 	MANAGER = NewRoutingManager("./graphs", config)
+	jobs_config := config.Services.Jobs
+	if jobs_config.Directory == "" {
+		jobs_config.Directory = "./jobs"
+	}
+	JOBS = NewJobManager(jobs_config.Directory, jobs_config.Workers, jobs_config.QueueSize, time.Duration(jobs_config.TTL)*time.Second)
+	pop_config := config.Services.Isochrones.Population
+	if pop_config.File != "" {
+		delimiter := ','
+		if pop_config.Delimiter != "" {
+			delimiter = []rune(pop_config.Delimiter)[0]
+		}
+		POPULATION = Some(LoadPopulation(pop_config.File, delimiter))
+	}
 
 	app := http.DefaultServeMux
 
@@ -27,7 +44,25 @@ func main() {
 	MapPost(app, "/v0/routing/draw/step", HandleRoutingStepRequest)
 	MapPost(app, "/v0/isoraster", HandleIsoRasterRequest)
 	MapPost(app, "/v1/matrix", HandleMatrixRequest)
-	MapPost(app, "/v2/isochrones/driving-car/geojson", HandleIsochroneRequest)
+	MapPost(app, "/v1/nearest", HandleNearestRequest)
+	MapPost(app, "/v1/knearest", HandleKNearestRequest)
+	MapPost(app, "/v1/accessibility", HandleAccessibilityRequest)
+	MapPost(app, "/v2/isochrones/{profile}/geojson", HandleIsochroneRequest)
+	MapPost(app, "/v1/transit/route", HandleTransitRouteRequest)
+	MapPost(app, "/v1/transit/journeys", HandleTransitJourneysRequest)
+	MapPost(app, "/v1/admin/transit/realtime", HandleTransitRealtimeRequest)
+	MapPost(app, "/v1/transit/scenarios", HandleCreateScenarioRequest)
+	MapResource(app, "/v1/transit/scenarios", Dict[string, func(string) Result]{
+		"GET":    HandleGetScenarioRequest,
+		"DELETE": HandleDeleteScenarioRequest,
+	})
+	MapPost(app, "/v1/jobs/matrix", HandleMatrixJobRequest)
+	MapPost(app, "/v1/jobs/accessibility", HandleAccessibilityJobRequest)
+	MapResource(app, "/v1/jobs", Dict[string, func(string) Result]{
+		"GET":        HandleGetJobRequest,
+		"GET result": HandleGetJobResultRequest,
+		"DELETE":     HandleDeleteJobRequest,
+	})
 
 	err := http.ListenAndServe("127.0.0.1:5002", nil)
 	if err != nil {
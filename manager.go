@@ -22,11 +22,11 @@ func NewRoutingManager(path string, config Config) *RoutingManager {
 	}
 
 	profiles := NewDict[string, IRoutingProfile](10)
-	attributes := NewDict[ProfileType, attr.IAttributes](10)
+	attributes := NewDict[string, attr.IAttributes](10)
 	// build/load profiles
 	if build {
 		slog.Info("Building Profiles...")
-		prep_cache := NewDict[ProfileType, Tuple[*comps.GraphBase, *attr.GraphAttributes]](10)
+		prep_cache := NewDict[string, Tuple[*comps.GraphBase, *attr.GraphAttributes]](10)
 		profile_meta := NewDict[string, ProfileMeta](10)
 		for name, options := range config.Build.Profiles {
 			if options.Value == nil {
@@ -39,12 +39,12 @@ func NewRoutingManager(path string, config Config) *RoutingManager {
 			profiles.Set(name, profile)
 			profile_meta[name] = profile._GetMetadata()
 		}
-		attr_meta := NewList[ProfileType](4)
-		for typ, data := range prep_cache {
+		attr_meta := NewList[string](4)
+		for name, data := range prep_cache {
 			att := data.B
-			attr.Store(att, graph_path+"attr-"+typ.String())
-			attributes.Set(typ, att)
-			attr_meta.Add(typ)
+			attr.Store(att, graph_path+"attr-"+name)
+			attributes.Set(name, att)
+			attr_meta.Add(name)
 		}
 		meta := RoutingManagerMeta{
 			Profiles:   profile_meta,
@@ -60,10 +60,15 @@ func NewRoutingManager(path string, config Config) *RoutingManager {
 			handler := PROFILE_HANDLERS[item.Type]
 			profile := handler.Load(graph_path+name, item)
 			profile.SetManager(manager)
+			if options, ok := config.Build.Profiles[name]; ok && options != nil {
+				if p, ok := profile.(*TransitProfile); ok && options.Value != nil && options.Value.Type() == TRANSIT {
+					p._SetRuntimeOptions(options.Value.(TransitOptions))
+				}
+			}
 			profiles.Set(name, profile)
 		}
-		for _, typ := range meta.Attributes {
-			attributes.Set(typ, attr.Load(graph_path+"attr-"+typ.String()))
+		for _, name := range meta.Attributes {
+			attributes.Set(name, attr.Load(graph_path+"attr-"+name))
 		}
 		slog.Info("Profiles loaded successfully!")
 	}
@@ -74,14 +79,15 @@ func NewRoutingManager(path string, config Config) *RoutingManager {
 }
 
 type RoutingManagerMeta struct {
-	Profiles   Dict[string, ProfileMeta] `json:"profiles"`
-	Attributes List[ProfileType]         `json:"attributes"`
+	Profiles Dict[string, ProfileMeta] `json:"profiles"`
+	// names of the graph attributes (profile-types for default decoders)
+	Attributes List[string] `json:"attributes"`
 }
 
 type RoutingManager struct {
 	config     Config
 	profiles   Dict[string, IRoutingProfile]
-	attributes Dict[ProfileType, attr.IAttributes]
+	attributes Dict[string, attr.IAttributes]
 }
 
 func (self *RoutingManager) GetProfile(profile string) Optional[IRoutingProfile] {
@@ -108,8 +114,8 @@ func (self *RoutingManager) GetProfiles() []string {
 	return profiles
 }
 
-func (self *RoutingManager) _GetAttributes(profile ProfileType) attr.IAttributes {
-	return self.attributes.Get(profile)
+func (self *RoutingManager) _GetAttributes(graph string) attr.IAttributes {
+	return self.attributes.Get(graph)
 }
 
 func (self *RoutingManager) _GetServiceConfig() Config {
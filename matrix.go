@@ -1,11 +1,21 @@
 package main
 
 import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/ttpr0/go-routing/attr"
 	"github.com/ttpr0/go-routing/batched/onetomany"
 	"github.com/ttpr0/go-routing/geo"
+	"github.com/ttpr0/go-routing/graph"
+	"github.com/ttpr0/go-routing/structs"
 	. "github.com/ttpr0/go-routing/util"
 	"golang.org/x/exp/slog"
 )
@@ -22,14 +32,30 @@ type MatrixRequest struct {
 	MaxRange     int32            `json:"max_range"`
 	TimeWindow   [2]int32         `json:"time_window"`
 	ScheduleDay  string           `json:"schedule_day"`
-	AvoidRoads   []attr.RoadType  `json:"avoid_roads"`
-	AvoidArea    geo.Feature      `json:"avoid_area"`
+	// public-transit only: id of a scenario adding hypothetical lines
+	Scenario string `json:"scenario"`
+	// maximum number of transfers between rides (uses RAPTOR if set)
+	MaxTransfers *int32 `json:"max_transfers"`
+	// public-transit only: summarizes the travel-times of every departure minute within the time_window;
+	// one of ["min", "median", "max"] or a percentile (e.g. "p90")
+	Statistic     string              `json:"statistic"`
+	AvoidRoads    []attr.RoadType     `json:"avoid_roads"`
+	AvoidFeatures []attr.AvoidFeature `json:"avoid_features"`
+	AvoidArea     geo.Feature         `json:"avoid_area"`
+	// output format; one of ["json", "ndjson", "binary"] (defaults to "json")
+	Format string `json:"format"`
 }
 
 type MatrixResponse struct {
 	Distances Matrix[float32] `json:"distances"`
 }
 
+// Single row of a streamed ndjson matrix response.
+type MatrixRowResponse struct {
+	Source    int       `json:"source"`
+	Distances []float32 `json:"distances"`
+}
+
 //**********************************************************
 // matrix handler
 //**********************************************************
@@ -37,78 +63,254 @@ type MatrixResponse struct {
 func HandleMatrixRequest(req MatrixRequest) Result {
 	slog.Info("Run Matrix Request")
 
+	switch req.Format {
+	case "", "json", "ndjson", "binary":
+	default:
+		return BadRequest("Invalid output format")
+	}
+	task_, res := PrepareMatrixTask(req)
+	if !task_.HasValue() {
+		return res
+	}
+	task := task_.Value
+	otm := task.otm
+	source_nodes := task.source_nodes
+	target_nodes := task.target_nodes
+	max_range := task.max_range
+	workers := _GetMatrixWorkerCount(MANAGER._GetServiceConfig(), source_nodes.Length())
+	slog.Info(fmt.Sprintf("Computing matrix using %v workers", workers))
+
+	switch req.Format {
+	case "ndjson":
+		return Stream("application/x-ndjson", func(w io.Writer) error {
+			writer := bufio.NewWriter(w)
+			return CalcMatrixRows(otm, source_nodes, target_nodes, max_range, workers, func(s int, row Array[float32]) error {
+				data, err := json.Marshal(MatrixRowResponse{Source: s, Distances: row})
+				if err != nil {
+					return err
+				}
+				writer.Write(data)
+				writer.WriteByte('\n')
+				return _FlushRow(writer, w)
+			})
+		})
+	case "binary":
+		return Stream("application/octet-stream", func(w io.Writer) error {
+			writer := bufio.NewWriter(w)
+			// header: number of rows and cols of the matrix
+			header := [2]uint32{uint32(source_nodes.Length()), uint32(target_nodes.Length())}
+			if err := binary.Write(writer, binary.LittleEndian, header); err != nil {
+				return err
+			}
+			// every row is prefixed with the index of its source since rows are written as they finish
+			return CalcMatrixRows(otm, source_nodes, target_nodes, max_range, workers, func(s int, row Array[float32]) error {
+				if err := binary.Write(writer, binary.LittleEndian, uint32(s)); err != nil {
+					return err
+				}
+				if err := binary.Write(writer, binary.LittleEndian, []float32(row)); err != nil {
+					return err
+				}
+				return _FlushRow(writer, w)
+			})
+		})
+	}
+
+	// every worker writes only the rows of the sources it computes
+	matrix := NewMatrix[float32](source_nodes.Length(), target_nodes.Length())
+	CalcMatrixRows(otm, source_nodes, target_nodes, max_range, workers, func(s int, row Array[float32]) error {
+		for t, dist := range row {
+			matrix.Set(s, t, dist)
+		}
+		return nil
+	})
+
+	resp := MatrixResponse{Distances: matrix}
+	slog.Info("Matrix reponse build")
+	return OK(resp)
+}
+
+//**********************************************************
+// matrix utilities
+//**********************************************************
+
+// Mapped nodes and one-to-many algorithm of a matrix request.
+type MatrixTask struct {
+	otm          onetomany.IOneToMany
+	source_nodes Array[int32]
+	target_nodes Array[int32]
+	max_range    int32
+}
+
+// Maps the request locations to nodes and selects the algorithm used to compute the matrix.
+func PrepareMatrixTask(req MatrixRequest) (Optional[MatrixTask], Result) {
 	var max_range int32
 	if req.MaxRange > 0 {
 		max_range = req.MaxRange
 	} else {
 		max_range = 100000000
 	}
+	if req.MaxTransfers != nil && *req.MaxTransfers < 0 {
+		return None[MatrixTask](), BadRequest("max_transfers must not be negative")
+	}
 	// get profile
 	profile_, res := GetRequestProfile(MANAGER, req.Profile, req.Metric)
 	if !profile_.HasValue() {
-		return res
+		return None[MatrixTask](), res
 	}
 	profile := profile_.Value
 	// map coords to nodes
 	att := profile.GetAttributes()
 	source_nodes := MapCoordsToNodes(att, req.Sources)
 	target_nodes := MapCoordsToNodes(att, req.Destinations)
-	source_chan := make(chan Tuple[int, int32], source_nodes.Length())
-	for i := 0; i < source_nodes.Length(); i++ {
-		source_chan <- MakeTuple(i, source_nodes[i])
-	}
-	close(source_chan)
 
 	// get graph
+	otm, res := GetMatrixOneToMany(profile, req, target_nodes, max_range)
+	if !otm.HasValue() {
+		return None[MatrixTask](), res
+	}
+	return Some(MatrixTask{
+		otm:          otm.Value,
+		source_nodes: source_nodes,
+		target_nodes: target_nodes,
+		max_range:    max_range,
+	}), OK("")
+}
+
+// Selects the one-to-many algorithm used to compute a matrix request.
+func GetMatrixOneToMany(profile IRoutingProfile, req MatrixRequest, target_nodes Array[int32], max_range int32) (Optional[onetomany.IOneToMany], Result) {
+	att := profile.GetAttributes()
 	var otm onetomany.IOneToMany
-	{
-		if req.AvoidRoads != nil || req.AvoidArea.Geometry() != nil {
-			s_g := profile.GetGraph()
-			if s_g.HasValue() {
-				slog.Info("Using Range-Dijkstra")
-				var a_r Optional[[]attr.RoadType]
-				if req.AvoidRoads != nil {
-					a_r = Some(req.AvoidRoads)
-				} else {
-					a_r = None[[]attr.RoadType]()
-				}
-				var a_a Optional[geo.Feature]
-				if req.AvoidArea.Geometry() != nil {
-					a_a = Some(req.AvoidArea)
-				} else {
-					a_a = None[geo.Feature]()
-				}
-				otm = onetomany.NewAvoidDijkstra(s_g.Value, max_range, att, a_r, a_a)
+	if req.AvoidRoads != nil || req.AvoidFeatures != nil || req.AvoidArea.Geometry() != nil {
+		s_g := profile.GetGraph()
+		if s_g.HasValue() {
+			var a_r Optional[[]attr.RoadType]
+			if req.AvoidRoads != nil {
+				a_r = Some(req.AvoidRoads)
+			} else {
+				a_r = None[[]attr.RoadType]()
+			}
+			var a_f Optional[[]attr.AvoidFeature]
+			if req.AvoidFeatures != nil {
+				a_f = Some(req.AvoidFeatures)
+			} else {
+				a_f = None[[]attr.AvoidFeature]()
+			}
+			var a_a Optional[geo.Feature]
+			if req.AvoidArea.Geometry() != nil {
+				a_a = Some(req.AvoidArea)
+			} else {
+				a_a = None[geo.Feature]()
+			}
+			if HasTurnCosts(s_g.Value) {
+				slog.Info("Using Avoid-Dijkstra with turn-costs")
+				otm = onetomany.NewAvoidDijkstraTC(s_g.Value, max_range, att, a_r, a_f, a_a)
+			} else {
+				slog.Info("Using Avoid-Dijkstra")
+				otm = onetomany.NewAvoidDijkstra(s_g.Value, max_range, att, a_r, a_f, a_a)
 			}
 		}
-		if otm == nil {
-			transit_g := profile.GetTransitGraph(req.ScheduleDay)
-			if transit_g.HasValue() {
-				slog.Info("Using Transit-Dijkstra")
-				otm = onetomany.NewTransitDijkstra(transit_g.Value, max_range, req.TimeWindow[0], req.TimeWindow[1])
+	}
+	if otm == nil {
+		transit_g, res := GetRequestTransitGraph(profile, req.Scenario, req.ScheduleDay)
+		if req.Scenario != "" && !transit_g.HasValue() {
+			return None[onetomany.IOneToMany](), res
+		}
+		if transit_g.HasValue() && req.Statistic != "" {
+			percentile, ok := _ParseMatrixStatistic(req.Statistic)
+			if !ok {
+				return None[onetomany.IOneToMany](), BadRequest("Invalid statistic")
+			}
+			if req.TimeWindow[1] < req.TimeWindow[0] {
+				return None[onetomany.IOneToMany](), BadRequest("Invalid time_window")
+			}
+			max_transfers := int32(5)
+			if req.MaxTransfers != nil {
+				max_transfers = *req.MaxTransfers
+			}
+			slog.Info("Using Profile-RAPTOR")
+			otm = onetomany.NewProfileRAPTOR(transit_g.Value, target_nodes, max_range, req.TimeWindow[0], req.TimeWindow[1], max_transfers, percentile)
+		} else if transit_g.HasValue() && req.MaxTransfers != nil {
+			slog.Info("Using RAPTOR")
+			otm = onetomany.NewRAPTOR(transit_g.Value, max_range, req.TimeWindow[0], req.TimeWindow[1], *req.MaxTransfers)
+		} else if p, ok := profile.(*TransitProfile); ok && transit_g.HasValue() && p.GetAlgorithm() == "csa" {
+			slog.Info("Using CSA")
+			otm = onetomany.NewCSA(transit_g.Value, max_range, req.TimeWindow[0], req.TimeWindow[1])
+		} else if transit_g.HasValue() {
+			slog.Info("Using Transit-Dijkstra")
+			otm = onetomany.NewTransitDijkstra(transit_g.Value, max_range, req.TimeWindow[0], req.TimeWindow[1])
+		} else if req.Statistic != "" {
+			return None[onetomany.IOneToMany](), BadRequest("statistic is only supported for public-transit profiles")
+		} else {
+			ch_g := profile.GetCHGraph()
+			var tc_ch_g Optional[Tuple[graph.ICHGraph, structs.IDMapping]]
+			if p, ok := profile.(*DrivingProfile); ok {
+				tc_ch_g = p.GetTurnCHGraph()
+			}
+			s_g := profile.GetGraph()
+			if ch_g.HasValue() {
+				slog.Info("Using Range-RPHAST")
+				otm = onetomany.NewRangeRPHAST(ch_g.Value, target_nodes, max_range)
+			} else if tc_ch_g.HasValue() && s_g.HasValue() {
+				slog.Info("Using Turn-RPHAST")
+				otm = onetomany.NewTurnRPHAST(tc_ch_g.Value.A, s_g.Value, tc_ch_g.Value.B, target_nodes, max_range)
 			} else {
-				ch_g := profile.GetCHGraph()
-				if ch_g.HasValue() {
-					slog.Info("Using Range-RPHAST")
-					otm = onetomany.NewRangeRPHAST(ch_g.Value, target_nodes, max_range)
+				if !s_g.HasValue() {
+					return None[onetomany.IOneToMany](), BadRequest("Graph not found")
+				}
+				if HasTurnCosts(s_g.Value) {
+					slog.Info("Using Range-Dijkstra with turn-costs")
+					otm = onetomany.NewRangeDijkstraTC(s_g.Value, max_range)
 				} else {
-					s_g := profile.GetGraph()
-					if !s_g.HasValue() {
-						return BadRequest("Graph not found")
-					}
 					slog.Info("Using Range-Dijkstra")
 					otm = onetomany.NewRangeDijkstra(s_g.Value, max_range)
 				}
 			}
 		}
 	}
+	return Some(otm), OK("")
+}
 
-	matrix := NewMatrix[float32](source_nodes.Length(), target_nodes.Length())
+// Parses the statistic of a matrix request into a percentile.
+func _ParseMatrixStatistic(statistic string) (int32, bool) {
+	switch statistic {
+	case "min":
+		return 0, true
+	case "median":
+		return 50, true
+	case "max":
+		return 100, true
+	}
+	if !strings.HasPrefix(statistic, "p") {
+		return 0, false
+	}
+	percentile, err := strconv.Atoi(statistic[1:])
+	if err != nil || percentile < 0 || percentile > 100 {
+		return 0, false
+	}
+	return int32(percentile), true
+}
+
+// Computes the matrix rows of all sources in parallel.
+//
+// Every worker creates its own solver and calls handle with each finished row.
+// Calls to handle are serialized, the row passed is reused by the worker and must not be retained.
+// Distances of unreachable or unmapped targets are set to -1.
+func CalcMatrixRows(otm onetomany.IOneToMany, source_nodes, target_nodes Array[int32], max_range int32, workers int, handle func(int, Array[float32]) error) error {
+	source_chan := make(chan Tuple[int, int32], source_nodes.Length())
+	for i := 0; i < source_nodes.Length(); i++ {
+		source_chan <- MakeTuple(i, source_nodes[i])
+	}
+	close(source_chan)
+
+	var handle_err error
+	handle_lock := sync.Mutex{}
 	wg := sync.WaitGroup{}
-	for i := 0; i < 1; i++ {
+	for i := 0; i < workers; i++ {
 		wg.Add(1)
 		go func() {
+			defer wg.Done()
 			solver := otm.CreateSolver()
+			row := NewArray[float32](target_nodes.Length())
 			for {
 				// read supply entry from chan
 				temp, ok := <-source_chan
@@ -120,34 +322,66 @@ func HandleMatrixRequest(req MatrixRequest) Result {
 				// if no node set all distances to -1
 				if s_node == -1 {
 					for i := 0; i < target_nodes.Length(); i++ {
-						matrix.Set(s, i, -1)
+						row[i] = -1
 					}
-					continue
-				}
-
-				start := [1]Tuple[int32, int32]{{s_node, 0}}
-				solver.CalcDistanceFromStart(start[:])
+				} else {
+					start := [1]Tuple[int32, int32]{{s_node, 0}}
+					solver.CalcDistanceFromStart(start[:])
 
-				// set distances in matrix
-				for t, t_node := range target_nodes {
-					if t_node == -1 {
-						matrix.Set(s, t, -1)
-						continue
+					for t, t_node := range target_nodes {
+						if t_node == -1 {
+							row[t] = -1
+							continue
+						}
+						dist := solver.GetDistance(t_node)
+						if dist > int32(max_range) {
+							row[t] = -1
+							continue
+						}
+						row[t] = float32(dist)
 					}
-					dist := solver.GetDistance(t_node)
-					if dist > int32(max_range) {
-						matrix.Set(s, t, -1)
-						continue
-					}
-					matrix.Set(s, t, float32(dist))
+				}
+
+				handle_lock.Lock()
+				if handle_err == nil {
+					handle_err = handle(s, row)
+				}
+				failed := handle_err != nil
+				handle_lock.Unlock()
+				if failed {
+					break
 				}
 			}
-			wg.Done()
 		}()
 	}
 	wg.Wait()
+	return handle_err
+}
 
-	resp := MatrixResponse{Distances: matrix}
-	slog.Info("Matrix reponse build")
-	return OK(resp)
+// Returns the number of workers used to compute a matrix.
+//
+// Defaults to GOMAXPROCS if no worker count is configured and is never larger than the number of sources.
+func _GetMatrixWorkerCount(config Config, source_count int) int {
+	workers := config.Services.Matrix.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > source_count {
+		workers = source_count
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	return workers
+}
+
+// Flushes the buffered row and the underlying response so clients receive rows as soon as they are finished.
+func _FlushRow(writer *bufio.Writer, w io.Writer) error {
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	if flusher, ok := w.(interface{ Flush() }); ok {
+		flusher.Flush()
+	}
+	return nil
 }
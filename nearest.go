@@ -0,0 +1,132 @@
+package main
+
+import (
+	"github.com/ttpr0/go-routing/attr"
+	"github.com/ttpr0/go-routing/batched/nearest"
+	"github.com/ttpr0/go-routing/geo"
+	. "github.com/ttpr0/go-routing/util"
+	"golang.org/x/exp/slog"
+)
+
+//**********************************************************
+// nearest request and response
+//**********************************************************
+
+type NearestRequest struct {
+	Facilities    Array[geo.Coord]    `json:"facilities"`
+	Demands       Array[geo.Coord]    `json:"demands"`
+	Profile       string              `json:"profile"`
+	Metric        string              `json:"metric"`
+	MaxRange      int32               `json:"max_range"`
+	AvoidRoads    []attr.RoadType     `json:"avoid_roads"`
+	AvoidFeatures []attr.AvoidFeature `json:"avoid_features"`
+	AvoidArea     geo.Feature         `json:"avoid_area"`
+}
+
+type NearestResponse struct {
+	// index of the nearest facility for every demand point (-1 if none is reachable)
+	Facilities Array[int32] `json:"facilities"`
+	// travel time to the nearest facility for every demand point (-1 if none is reachable)
+	Distances Array[float32] `json:"distances"`
+}
+
+//**********************************************************
+// nearest handler
+//**********************************************************
+
+func HandleNearestRequest(req NearestRequest) Result {
+	slog.Info("Run Nearest Request")
+
+	var max_range int32
+	if req.MaxRange > 0 {
+		max_range = req.MaxRange
+	} else {
+		max_range = 100000000
+	}
+	// get profile
+	profile_, res := GetRequestProfile(MANAGER, req.Profile, req.Metric)
+	if !profile_.HasValue() {
+		return res
+	}
+	profile := profile_.Value
+	// map coords to nodes
+	att := profile.GetAttributes()
+	facility_nodes := MapCoordsToNodes(att, req.Facilities)
+	demand_nodes := MapCoordsToNodes(att, req.Demands)
+
+	// get graph
+	g_ := profile.GetGraph()
+	if !g_.HasValue() {
+		return BadRequest("Graph not found")
+	}
+	g := g_.Value
+	var alg nearest.INearest
+	if req.AvoidRoads != nil || req.AvoidFeatures != nil || req.AvoidArea.Geometry() != nil || HasTurnCosts(g) {
+		var a_r Optional[[]attr.RoadType]
+		if req.AvoidRoads != nil {
+			a_r = Some(req.AvoidRoads)
+		} else {
+			a_r = None[[]attr.RoadType]()
+		}
+		var a_f Optional[[]attr.AvoidFeature]
+		if req.AvoidFeatures != nil {
+			a_f = Some(req.AvoidFeatures)
+		} else {
+			a_f = None[[]attr.AvoidFeature]()
+		}
+		var a_a Optional[geo.Feature]
+		if req.AvoidArea.Geometry() != nil {
+			a_a = Some(req.AvoidArea)
+		} else {
+			a_a = None[geo.Feature]()
+		}
+		if HasTurnCosts(g) {
+			slog.Info("Using Avoid-Many-Dijkstra with turn-costs")
+			alg = nearest.NewAvoidManyDijkstraTC(g, max_range, att, a_r, a_f, a_a)
+		} else {
+			slog.Info("Using Avoid-Many-Dijkstra")
+			alg = nearest.NewAvoidManyDijkstra(g, max_range, att, a_r, a_f, a_a)
+		}
+	} else {
+		slog.Info("Using Many-Dijkstra")
+		alg = nearest.NewManyDijkstra(g, max_range)
+	}
+
+	// every facility is its own source, unmapped facilities are skipped
+	sources := NewList[Array[Tuple[int32, int32]]](facility_nodes.Length())
+	for _, node := range facility_nodes {
+		if node == -1 {
+			sources.Add(Array[Tuple[int32, int32]]{})
+			continue
+		}
+		sources.Add(Array[Tuple[int32, int32]]{MakeTuple(node, int32(0))})
+	}
+	solver := alg.CreateSolver()
+	solver.CalcNearestNeighbours(sources)
+
+	facilities := NewArray[int32](demand_nodes.Length())
+	distances := NewArray[float32](demand_nodes.Length())
+	for i, node := range demand_nodes {
+		if node == -1 {
+			facilities[i] = -1
+			distances[i] = -1
+			continue
+		}
+		neighbour := solver.GetNeighbour(node)
+		dist := solver.GetDistance(node)
+		if neighbour == -1 || dist > max_range {
+			facilities[i] = -1
+			distances[i] = -1
+			continue
+		}
+		facilities[i] = neighbour
+		distances[i] = float32(dist)
+	}
+
+	resp := NearestResponse{
+		Facilities: facilities,
+		Distances:  distances,
+	}
+	slog.Info("Nearest reponse build")
+	return OK(resp)
+}
@@ -0,0 +1,229 @@
+package parser
+
+import (
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ttpr0/go-routing/attr"
+	. "github.com/ttpr0/go-routing/util"
+	"gopkg.in/yaml.v3"
+)
+
+//*******************************************
+// config decoder
+//*******************************************
+
+// Declarative decoder definition read from a yaml- or json-file.
+type DecoderConfig struct {
+	// highway-types accessible by default and their speed (in km/h)
+	Highways map[string]int32 `yaml:"highways"`
+	// highway-types only accessible if explicitly allowed by any of the allow-tags or the most specific access-key
+	RestrictedHighways map[string]int32 `yaml:"restricted-highways"`
+	// access-keys from the most general to the most specific (e.g. ["access", "vehicle", "motor_vehicle", "hgv"])
+	Access []string `yaml:"access"`
+	// tags making restricted highways accessible regardless of the access-keys (e.g. {"sidewalk": ["both", "left", "right", "yes"]})
+	AllowTags map[string][]string `yaml:"allow-tags"`
+	// tags excluding ways regardless of their access (e.g. {"motorroad": ["yes"]})
+	ExcludeTags map[string][]string `yaml:"exclude-tags"`
+	// maximum speed of the vehicle (in km/h), 0 if unlimited
+	MaxSpeed int32 `yaml:"max-speed"`
+	// if true the maxspeed-tag (reduced by 10%) replaces the speed of the highway-type
+	UseMaxspeed bool `yaml:"use-maxspeed"`
+	// maximum speeds per surface and tracktype
+	SurfaceSpeeds   map[string]int32 `yaml:"surface-speeds"`
+	TracktypeSpeeds map[string]int32 `yaml:"tracktype-speeds"`
+	Oneway          struct {
+		// oneway-keys from the most general to the most specific (e.g. ["oneway", "oneway:bicycle"])
+		Keys []string `yaml:"keys"`
+		// highway-types that are oneway if not tagged otherwise
+		Implied []string `yaml:"implied"`
+		// if true roundabouts are oneway if not tagged otherwise
+		Roundabout bool `yaml:"roundabout"`
+		// tags allowing to travel against the oneway of the first key (e.g. {"cycleway": ["opposite", "opposite_lane"]})
+		Opposite map[string][]string `yaml:"opposite"`
+	} `yaml:"oneway"`
+	Restrictions struct {
+		// restriction-vehicles from the most general to the most specific (e.g. ["motor_vehicle", "hgv"]), turn-restrictions are skipped if empty
+		Vehicles []string `yaml:"vehicles"`
+		// if true conditional restrictions are applied regardless of their condition where no unconditional restriction is tagged, conditional exceptions are ignored
+		Conditional bool `yaml:"conditional"`
+	} `yaml:"restrictions"`
+}
+
+// Reads the decoder definition, json-files are read as yaml.
+func ReadDecoderConfig(file string) DecoderConfig {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		panic(err.Error())
+	}
+	config := DecoderConfig{}
+	err = yaml.Unmarshal(data, &config)
+	if err != nil {
+		panic(err.Error())
+	}
+	return config
+}
+
+// Decoder compiled from a declarative definition.
+type ConfigDecoder struct {
+	config DecoderConfig
+}
+
+// Creates the decoder, panics if the definition is invalid.
+func NewConfigDecoder(config DecoderConfig) *ConfigDecoder {
+	if len(config.Highways) == 0 && len(config.RestrictedHighways) == 0 {
+		panic("decoder doesn't contain any highway-types")
+	}
+	for _, speeds := range []map[string]int32{config.Highways, config.RestrictedHighways, config.SurfaceSpeeds, config.TracktypeSpeeds} {
+		for key, speed := range speeds {
+			if speed <= 0 || speed > 255 {
+				panic("invalid speed of " + key + ": " + strconv.Itoa(int(speed)))
+			}
+		}
+	}
+	if config.MaxSpeed < 0 || config.MaxSpeed > 255 {
+		panic("invalid max-speed: " + strconv.Itoa(int(config.MaxSpeed)))
+	}
+	return &ConfigDecoder{
+		config: config,
+	}
+}
+
+func (self *ConfigDecoder) IsValidHighway(tags Dict[string, string]) bool {
+	if !tags.ContainsKey("highway") {
+		return false
+	}
+	if _HasAnyTag(tags, self.config.ExcludeTags) {
+		return false
+	}
+	str_type := tags.Get("highway")
+	if _, ok := self.config.Highways[str_type]; ok {
+		access := _GetAccess(tags, self.config.Access...)
+		if access.HasValue() {
+			return access.Value
+		}
+		return true
+	}
+	if _, ok := self.config.RestrictedHighways[str_type]; ok {
+		// allow-tags may use values the access-keys deny (e.g. hgv=agricultural)
+		if _HasAnyTag(tags, self.config.AllowTags) {
+			return true
+		}
+		if len(self.config.Access) > 0 {
+			access := _GetAccess(tags, self.config.Access[len(self.config.Access)-1])
+			if access.HasValue() {
+				return access.Value
+			}
+		}
+		return false
+	}
+	return false
+}
+func (self *ConfigDecoder) DecodeNode(tags Dict[string, string]) attr.NodeAttribs {
+	return attr.NodeAttribs{Type: 0}
+}
+func (self *ConfigDecoder) DecodeEdge(tags Dict[string, string]) attr.EdgeAttribs {
+	str_type := tags.Get("highway")
+	e := attr.EdgeAttribs{}
+	e.Type = _GetType(str_type)
+	e.Maxspeed = byte(self._GetSpeed(tags))
+	return e
+}
+func (self *ConfigDecoder) DecodeNodeDetails(tags Dict[string, string]) attr.NodeDetails {
+	return _GetNodeDetails(tags)
+}
+func (self *ConfigDecoder) DecodeEdgeDetails(tags Dict[string, string]) attr.EdgeDetails {
+	return _GetEdgeDetails(tags, self.config.Access...)
+}
+func (self *ConfigDecoder) DecodeDirection(tags Dict[string, string]) (bool, bool) {
+	oneway := None[int]()
+	specific := false
+	for i, key := range self.config.Oneway.Keys {
+		value := _GetOnewayValue(tags.Get(key))
+		if value.HasValue() {
+			oneway = value
+			specific = i > 0
+		}
+	}
+	if !oneway.HasValue() {
+		if slices.Contains(self.config.Oneway.Implied, tags.Get("highway")) {
+			oneway = Some(1)
+		} else if self.config.Oneway.Roundabout && tags.Get("junction") == "roundabout" {
+			oneway = Some(1)
+		} else {
+			return true, true
+		}
+	}
+	if oneway.Value == 0 {
+		return true, true
+	}
+	// opposite-tags don't override the more specific oneway-keys
+	if !specific && _HasAnyTag(tags, self.config.Oneway.Opposite) {
+		return true, true
+	}
+	return oneway.Value != -1, oneway.Value != 1
+}
+func (self *ConfigDecoder) DecodeRestriction(tags Dict[string, string]) Optional[string] {
+	if len(self.config.Restrictions.Vehicles) == 0 {
+		return None[string]()
+	}
+	return _GetRestriction(tags, self.config.Restrictions.Vehicles, self.config.Restrictions.Conditional)
+}
+
+func (self *ConfigDecoder) _GetSpeed(tags Dict[string, string]) int32 {
+	str_type := tags.Get("highway")
+	speed, ok := self.config.Highways[str_type]
+	if !ok {
+		speed = self.config.RestrictedHighways[str_type]
+	}
+	if self.config.UseMaxspeed {
+		maxspeed := _ParseMaxspeed(tags.Get("maxspeed"))
+		if maxspeed.HasValue() {
+			speed = max(int32(0.9*float32(maxspeed.Value)), 1)
+		}
+	}
+	if s, ok := self.config.SurfaceSpeeds[tags.Get("surface")]; ok {
+		speed = min(speed, s)
+	}
+	if s, ok := self.config.TracktypeSpeeds[tags.Get("tracktype")]; ok {
+		speed = min(speed, s)
+	}
+	if self.config.MaxSpeed > 0 {
+		speed = min(speed, self.config.MaxSpeed)
+	}
+	return min(speed, 255)
+}
+
+// Checks if any of the tags is set to one of its values.
+func _HasAnyTag(tags Dict[string, string], values map[string][]string) bool {
+	for key, vals := range values {
+		if slices.Contains(vals, tags.Get(key)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Parses the maxspeed-tag (in km/h), None if it isn't set or not a fixed speed.
+func _ParseMaxspeed(maxspeed string) Optional[int32] {
+	if maxspeed == "" || maxspeed == "none" {
+		return None[int32]()
+	}
+	if maxspeed == "walk" {
+		return Some(int32(10))
+	}
+	if strings.HasSuffix(maxspeed, " mph") {
+		t, err := strconv.Atoi(strings.TrimSuffix(maxspeed, " mph"))
+		if err != nil {
+			return None[int32]()
+		}
+		return Some(int32(float32(t) * 1.609))
+	}
+	t, err := strconv.Atoi(maxspeed)
+	if err != nil {
+		return None[int32]()
+	}
+	return Some(int32(t))
+}
@@ -8,32 +8,87 @@ import (
 type CyclingDecoder struct {
 }
 
-var cycling_types = Dict[string, bool]{"motorway": true, "motorway_link": true, "trunk": true, "trunk_link": true,
-	"primary": true, "primary_link": true, "secondary": true, "secondary_link": true, "tertiary": true, "tertiary_link": true,
-	"residential": true, "living_street": true, "service": true, "track": true, "unclassified": true, "road": true}
+var cycling_types = Dict[string, bool]{"trunk": true, "trunk_link": true, "primary": true, "primary_link": true,
+	"secondary": true, "secondary_link": true, "tertiary": true, "tertiary_link": true, "residential": true,
+	"living_street": true, "service": true, "track": true, "unclassified": true, "road": true, "path": true, "cycleway": true}
+
+// highway-types only rideable if bicycles are explicitly allowed
+var cycling_restricted_types = Dict[string, bool]{"motorway": true, "motorway_link": true, "footway": true,
+	"pedestrian": true, "steps": true, "bridleway": true}
+
+// cycleway-values allowing bicycles against the direction of oneway roads
+var cycling_opposite_types = Dict[string, bool]{"opposite": true, "opposite_lane": true, "opposite_track": true, "opposite_share_busway": true}
 
 func (self *CyclingDecoder) IsValidHighway(tags Dict[string, string]) bool {
 	if !tags.ContainsKey("highway") {
 		return false
 	}
-	if !cycling_types.ContainsKey(tags.Get("highway")) {
+	str_type := tags.Get("highway")
+	if cycling_restricted_types.ContainsKey(str_type) {
+		access := _GetAccess(tags, "bicycle")
+		return access.HasValue() && access.Value
+	}
+	if !cycling_types.ContainsKey(str_type) {
 		return false
 	}
+	if tags.Get("motorroad") == "yes" {
+		access := _GetAccess(tags, "bicycle")
+		return access.HasValue() && access.Value
+	}
+	access := _GetAccess(tags, "access", "vehicle", "bicycle")
+	if access.HasValue() {
+		return access.Value
+	}
 	return true
 }
 func (self *CyclingDecoder) DecodeNode(tags Dict[string, string]) attr.NodeAttribs {
 	return attr.NodeAttribs{Type: 0}
 }
 func (self *CyclingDecoder) DecodeEdge(tags Dict[string, string]) attr.EdgeAttribs {
-	templimit := tags.Get("maxspeed")
 	str_type := tags.Get("highway")
-	oneway := tags.Get("oneway")
 	track_type := tags.Get("tracktype")
 	surface := tags.Get("surface")
 	e := attr.EdgeAttribs{}
 	e.Type = _GetType(str_type)
-	// e.Templimit = GetTemplimit(templimit, e.Type)
-	e.Maxspeed = byte(_GetORSTravelSpeed(e.Type, templimit, track_type, surface))
-	e.Oneway = _IsOneway(oneway, e.Type)
+	e.Maxspeed = byte(_GetCyclingSpeed(e.Type, track_type, surface))
 	return e
 }
+func (self *CyclingDecoder) DecodeNodeDetails(tags Dict[string, string]) attr.NodeDetails {
+	return _GetNodeDetails(tags)
+}
+func (self *CyclingDecoder) DecodeEdgeDetails(tags Dict[string, string]) attr.EdgeDetails {
+	return _GetEdgeDetails(tags, "access", "vehicle", "bicycle")
+}
+func (self *CyclingDecoder) DecodeDirection(tags Dict[string, string]) (bool, bool) {
+	// bicycle specific oneway overrides the oneway of the road
+	oneway := _GetOnewayValue(tags.Get("oneway:bicycle"))
+	if oneway.HasValue() {
+		return oneway.Value != -1, oneway.Value != 1
+	}
+	oneway = _GetOnewayValue(tags.Get("oneway"))
+	if !oneway.HasValue() {
+		if tags.Get("junction") != "roundabout" {
+			return true, true
+		}
+		oneway = Some(1)
+	}
+	if oneway.Value == 0 {
+		return true, true
+	}
+	// contraflow cycling on oneway roads
+	for _, key := range []string{"cycleway", "cycleway:left", "cycleway:right", "cycleway:both"} {
+		if cycling_opposite_types.ContainsKey(tags.Get(key)) {
+			return true, true
+		}
+	}
+	for _, key := range []string{"cycleway:left:oneway", "cycleway:right:oneway"} {
+		switch tags.Get(key) {
+		case "no", "-1":
+			return true, true
+		}
+	}
+	return oneway.Value != -1, oneway.Value != 1
+}
+func (self *CyclingDecoder) DecodeRestriction(tags Dict[string, string]) Optional[string] {
+	return None[string]()
+}
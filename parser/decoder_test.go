@@ -0,0 +1,186 @@
+package parser
+
+import (
+	"os"
+	"testing"
+
+	. "github.com/ttpr0/go-routing/util"
+)
+
+func TestConfigDecoderRestrictedHighways(t *testing.T) {
+	config := DecoderConfig{
+		Highways:           map[string]int32{"primary": 60},
+		RestrictedHighways: map[string]int32{"track": 10},
+		Access:             []string{"access", "vehicle", "motor_vehicle", "hgv"},
+		AllowTags:          map[string][]string{"hgv": {"agricultural"}},
+	}
+	decoder := NewConfigDecoder(config)
+	tests := []struct {
+		name     string
+		tags     Dict[string, string]
+		expected bool
+	}{
+		{"untagged", Dict[string, string]{"highway": "track"}, false},
+		{"allow-tag", Dict[string, string]{"highway": "track", "hgv": "agricultural"}, true},
+		{"most specific access-key", Dict[string, string]{"highway": "track", "motor_vehicle": "no", "hgv": "yes"}, true},
+		{"general access-key", Dict[string, string]{"highway": "track", "motor_vehicle": "yes"}, false},
+		{"denied", Dict[string, string]{"highway": "track", "hgv": "no"}, false},
+	}
+	for _, tt := range tests {
+		if valid := decoder.IsValidHighway(tt.tags); valid != tt.expected {
+			t.Errorf("%v: expected %v, got %v", tt.name, tt.expected, valid)
+		}
+	}
+}
+
+func TestGetAccess(t *testing.T) {
+	tests := []struct {
+		name     string
+		tags     Dict[string, string]
+		keys     []string
+		expected Optional[bool]
+	}{
+		{"untagged", Dict[string, string]{}, []string{"access", "foot"}, None[bool]()},
+		{"general", Dict[string, string]{"access": "no"}, []string{"access", "foot"}, Some(false)},
+		{"specific overrides general", Dict[string, string]{"access": "no", "foot": "yes"}, []string{"access", "foot"}, Some(true)},
+		{"general doesn't override specific", Dict[string, string]{"access": "yes", "foot": "private"}, []string{"access", "foot"}, Some(false)},
+		{"allowing values", Dict[string, string]{"foot": "designated"}, []string{"foot"}, Some(true)},
+		{"restricting values", Dict[string, string]{"bicycle": "dismount"}, []string{"bicycle"}, Some(false)},
+		{"agricultural", Dict[string, string]{"hgv": "agricultural"}, []string{"hgv"}, Some(false)},
+		{"unknown values are skipped", Dict[string, string]{"access": "no", "foot": "unknown"}, []string{"access", "foot"}, Some(false)},
+		{"other keys are ignored", Dict[string, string]{"motor_vehicle": "no"}, []string{"access", "foot"}, None[bool]()},
+	}
+	for _, tt := range tests {
+		access := _GetAccess(tt.tags, tt.keys...)
+		if access.HasValue() != tt.expected.HasValue() || access.Value != tt.expected.Value {
+			t.Errorf("%v: expected %v (set %v), got %v (set %v)", tt.name, tt.expected.Value, tt.expected.HasValue(), access.Value, access.HasValue())
+		}
+	}
+}
+
+func TestDecodeDirection(t *testing.T) {
+	type direction struct {
+		forward  bool
+		backward bool
+	}
+	tests := []struct {
+		name     string
+		decoder  IOSMDecoder
+		tags     Dict[string, string]
+		expected direction
+	}{
+		{"cycling two-way", &CyclingDecoder{}, Dict[string, string]{"highway": "residential"}, direction{true, true}},
+		{"cycling oneway", &CyclingDecoder{}, Dict[string, string]{"highway": "residential", "oneway": "yes"}, direction{true, false}},
+		{"cycling reversed oneway", &CyclingDecoder{}, Dict[string, string]{"highway": "residential", "oneway": "-1"}, direction{false, true}},
+		{"cycling oneway:bicycle=no", &CyclingDecoder{}, Dict[string, string]{"highway": "residential", "oneway": "yes", "oneway:bicycle": "no"}, direction{true, true}},
+		{"cycling oneway:bicycle=yes", &CyclingDecoder{}, Dict[string, string]{"highway": "residential", "oneway:bicycle": "yes"}, direction{true, false}},
+		{"cycling cycleway=opposite", &CyclingDecoder{}, Dict[string, string]{"highway": "residential", "oneway": "yes", "cycleway": "opposite"}, direction{true, true}},
+		{"cycling cycleway:left=opposite_lane", &CyclingDecoder{}, Dict[string, string]{"highway": "residential", "oneway": "yes", "cycleway:left": "opposite_lane"}, direction{true, true}},
+		{"cycling cycleway:right:oneway=no", &CyclingDecoder{}, Dict[string, string]{"highway": "residential", "oneway": "yes", "cycleway:right:oneway": "no"}, direction{true, true}},
+		{"cycling roundabout", &CyclingDecoder{}, Dict[string, string]{"highway": "residential", "junction": "roundabout"}, direction{true, false}},
+		{"cycling roundabout oneway=no", &CyclingDecoder{}, Dict[string, string]{"highway": "residential", "junction": "roundabout", "oneway": "no"}, direction{true, true}},
+		{"cycling roundabout cycleway=opposite", &CyclingDecoder{}, Dict[string, string]{"highway": "residential", "junction": "roundabout", "cycleway": "opposite"}, direction{true, true}},
+		{"walking ignores oneway", &WalkingDecoder{}, Dict[string, string]{"highway": "residential", "oneway": "yes"}, direction{true, true}},
+		{"walking oneway:foot", &WalkingDecoder{}, Dict[string, string]{"highway": "footway", "oneway:foot": "-1"}, direction{false, true}},
+		{"driving oneway", &DrivingDecoder{}, Dict[string, string]{"highway": "residential", "oneway": "yes"}, direction{true, false}},
+		{"driving motorway", &DrivingDecoder{}, Dict[string, string]{"highway": "motorway"}, direction{true, false}},
+	}
+	for _, tt := range tests {
+		forward, backward := tt.decoder.DecodeDirection(tt.tags)
+		if forward != tt.expected.forward || backward != tt.expected.backward {
+			t.Errorf("%v: expected %v, got {%v %v}", tt.name, tt.expected, forward, backward)
+		}
+	}
+}
+
+func TestConfigDecoderFromFile(t *testing.T) {
+	file := t.TempDir() + "/bike.yml"
+	os.WriteFile(file, []byte(`
+highways:
+  residential: 18
+  primary: 20
+restricted-highways:
+  footway: 10
+access: ["access", "vehicle", "bicycle"]
+allow-tags:
+  segregated: ["yes"]
+exclude-tags:
+  motorroad: ["yes"]
+max-speed: 19
+use-maxspeed: true
+surface-speeds:
+  gravel: 12
+oneway:
+  keys: ["oneway", "oneway:bicycle"]
+  implied: ["motorway"]
+  roundabout: true
+  opposite:
+    cycleway: ["opposite", "opposite_lane"]
+restrictions:
+  vehicles: ["bicycle"]
+`), 0644)
+	decoder := NewConfigDecoder(ReadDecoderConfig(file))
+
+	valid := []struct {
+		name     string
+		tags     Dict[string, string]
+		expected bool
+	}{
+		{"highway", Dict[string, string]{"highway": "residential"}, true},
+		{"unknown highway", Dict[string, string]{"highway": "motorway"}, false},
+		{"access", Dict[string, string]{"highway": "residential", "access": "no", "bicycle": "yes"}, true},
+		{"denied", Dict[string, string]{"highway": "residential", "vehicle": "no"}, false},
+		{"excluded", Dict[string, string]{"highway": "primary", "motorroad": "yes", "bicycle": "yes"}, false},
+		{"restricted", Dict[string, string]{"highway": "footway"}, false},
+		{"restricted allow-tag", Dict[string, string]{"highway": "footway", "segregated": "yes"}, true},
+		{"restricted access", Dict[string, string]{"highway": "footway", "bicycle": "designated"}, true},
+	}
+	for _, tt := range valid {
+		if v := decoder.IsValidHighway(tt.tags); v != tt.expected {
+			t.Errorf("valid %v: expected %v, got %v", tt.name, tt.expected, v)
+		}
+	}
+
+	speeds := []struct {
+		name     string
+		tags     Dict[string, string]
+		expected byte
+	}{
+		{"highway", Dict[string, string]{"highway": "residential"}, 18},
+		{"max-speed", Dict[string, string]{"highway": "primary"}, 19},
+		{"maxspeed-tag", Dict[string, string]{"highway": "primary", "maxspeed": "10"}, 9},
+		{"surface", Dict[string, string]{"highway": "residential", "surface": "gravel"}, 12},
+		{"restricted", Dict[string, string]{"highway": "footway"}, 10},
+	}
+	for _, tt := range speeds {
+		if s := decoder.DecodeEdge(tt.tags).Maxspeed; s != tt.expected {
+			t.Errorf("speed %v: expected %v, got %v", tt.name, tt.expected, s)
+		}
+	}
+
+	directions := []struct {
+		name     string
+		tags     Dict[string, string]
+		forward  bool
+		backward bool
+	}{
+		{"two-way", Dict[string, string]{"highway": "residential"}, true, true},
+		{"oneway", Dict[string, string]{"highway": "residential", "oneway": "yes"}, true, false},
+		{"specific key", Dict[string, string]{"highway": "residential", "oneway": "yes", "oneway:bicycle": "no"}, true, true},
+		{"opposite", Dict[string, string]{"highway": "residential", "oneway": "yes", "cycleway": "opposite_lane"}, true, true},
+		{"opposite doesn't override specific key", Dict[string, string]{"highway": "residential", "oneway:bicycle": "-1", "cycleway": "opposite"}, false, true},
+		{"implied", Dict[string, string]{"highway": "motorway"}, true, false},
+		{"roundabout", Dict[string, string]{"highway": "residential", "junction": "roundabout"}, true, false},
+	}
+	for _, tt := range directions {
+		forward, backward := decoder.DecodeDirection(tt.tags)
+		if forward != tt.forward || backward != tt.backward {
+			t.Errorf("direction %v: expected {%v %v}, got {%v %v}", tt.name, tt.forward, tt.backward, forward, backward)
+		}
+	}
+
+	restriction := decoder.DecodeRestriction(Dict[string, string]{"type": "restriction", "restriction:bicycle": "no_left_turn"})
+	if !restriction.HasValue() || restriction.Value != "no_left_turn" {
+		t.Errorf("expected no_left_turn, got %v", restriction.Value)
+	}
+}
@@ -27,13 +27,25 @@ func (self *DrivingDecoder) DecodeNode(tags Dict[string, string]) attr.NodeAttri
 func (self *DrivingDecoder) DecodeEdge(tags Dict[string, string]) attr.EdgeAttribs {
 	templimit := tags.Get("maxspeed")
 	str_type := tags.Get("highway")
-	oneway := tags.Get("oneway")
 	track_type := tags.Get("tracktype")
 	surface := tags.Get("surface")
 	e := attr.EdgeAttribs{}
 	e.Type = _GetType(str_type)
 	// e.Templimit = GetTemplimit(templimit, e.Type)
 	e.Maxspeed = byte(_GetORSTravelSpeed(e.Type, templimit, track_type, surface))
-	e.Oneway = _IsOneway(oneway, e.Type)
 	return e
 }
+func (self *DrivingDecoder) DecodeNodeDetails(tags Dict[string, string]) attr.NodeDetails {
+	return _GetNodeDetails(tags)
+}
+func (self *DrivingDecoder) DecodeEdgeDetails(tags Dict[string, string]) attr.EdgeDetails {
+	return _GetEdgeDetails(tags, "access", "vehicle", "motor_vehicle", "motorcar")
+}
+func (self *DrivingDecoder) DecodeDirection(tags Dict[string, string]) (bool, bool) {
+	oneway := tags.Get("oneway")
+	str_type := _GetType(tags.Get("highway"))
+	return true, !_IsOneway(oneway, str_type)
+}
+func (self *DrivingDecoder) DecodeRestriction(tags Dict[string, string]) Optional[string] {
+	return _GetRestriction(tags, []string{"motor_vehicle", "motorcar"}, false)
+}
@@ -0,0 +1,106 @@
+package parser
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/ttpr0/go-routing/util"
+)
+
+//*******************************************
+// gtfs feed
+//*******************************************
+
+// Files of a single GTFS feed, either a directory or a zip-archive (read without extracting it).
+type GTFSFeed struct {
+	name    string
+	path    string
+	archive *zip.ReadCloser
+	// files of the zip-archive by their base-name (feeds are often zipped within a sub-directory)
+	files Dict[string, *zip.File]
+}
+
+// Opens the GTFS feed at path, paths ending with ".zip" are read as zip-archives.
+func OpenGTFSFeed(path string) *GTFSFeed {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if !strings.EqualFold(filepath.Ext(path), ".zip") {
+		return &GTFSFeed{
+			name: filepath.Base(path),
+			path: path,
+		}
+	}
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		panic(err.Error())
+	}
+	files := NewDict[string, *zip.File](10)
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		base := filepath.Base(file.Name)
+		if files.ContainsKey(base) {
+			continue
+		}
+		files[base] = file
+	}
+	return &GTFSFeed{
+		name:    name,
+		path:    path,
+		archive: archive,
+		files:   files,
+	}
+}
+
+// Returns the name of the feed (file- or directory-name without extension).
+func (self *GTFSFeed) Name() string {
+	return self.name
+}
+
+func (self *GTFSFeed) HasFile(name string) bool {
+	if self.archive != nil {
+		return self.files.ContainsKey(name)
+	}
+	return _FileExists(self.path + "/" + name)
+}
+
+// Opens the file of the feed, panics if it doesn't exist.
+func (self *GTFSFeed) Open(name string) io.ReadCloser {
+	if self.archive != nil {
+		if !self.files.ContainsKey(name) {
+			panic("file not found: " + self.path + "/" + name)
+		}
+		reader, err := self.files[name].Open()
+		if err != nil {
+			panic(err.Error())
+		}
+		return reader
+	}
+	file, err := os.Open(self.path + "/" + name)
+	if err != nil {
+		panic(err.Error())
+	}
+	return file
+}
+
+func (self *GTFSFeed) Close() {
+	if self.archive != nil {
+		self.archive.Close()
+	}
+}
+
+// Reads the rows of a csv-file of the feed.
+func _ReadFeedCSV[T any](feed *GTFSFeed, name string) func(yield func(T) bool) {
+	return func(yield func(T) bool) {
+		reader := feed.Open(name)
+		defer reader.Close()
+		for value := range ReadCSVFromReader[T](reader, ',') {
+			if !yield(value) {
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package parser
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/ttpr0/go-routing/geo"
+	. "github.com/ttpr0/go-routing/util"
+)
+
+//*******************************************
+// gtfs filter
+//*******************************************
+
+// Spatial extent the stops of GTFS feeds are clipped to.
+//
+// Stops have to be within the bounding-box and any of the polygons (if set), an empty filter keeps all stops.
+type GTFSFilter struct {
+	bbox     Optional[geo.Envelope]
+	polygons List[geo.Geometry]
+}
+
+// Creates a filter keeping all stops.
+func NewGTFSFilter() GTFSFilter {
+	return GTFSFilter{
+		bbox:     None[geo.Envelope](),
+		polygons: NewList[geo.Geometry](0),
+	}
+}
+
+// Clips the filter to the bounding-box [min_lon, min_lat, max_lon, max_lat].
+func (self *GTFSFilter) ClipToBBox(bbox geo.Envelope) {
+	if self.bbox.HasValue() {
+		curr := self.bbox.Value
+		bbox = geo.Envelope{max(curr[0], bbox[0]), max(curr[1], bbox[1]), min(curr[2], bbox[2]), min(curr[3], bbox[3])}
+	}
+	self.bbox = Some(bbox)
+}
+
+// Clips the filter to the union of all (multi-)polygon features of the collection.
+func (self *GTFSFilter) ClipToPolygons(collection geo.FeatureCollection) {
+	polygons := NewList[geo.Geometry](len(collection.Features()))
+	for _, feature := range collection.Features() {
+		geom := feature.Geometry()
+		if geom == nil {
+			continue
+		}
+		switch geom.Type() {
+		case "Polygon", "MultiPolygon":
+			polygons.Add(geom)
+		}
+	}
+	if polygons.Length() == 0 {
+		panic("filter doesn't contain any polygons")
+	}
+	self.polygons = polygons
+}
+
+// Clips the filter to the polygons of the geojson feature-collection.
+func (self *GTFSFilter) ReadPolygons(file string) {
+	file_str, err := os.ReadFile(file)
+	if err != nil {
+		panic(err.Error())
+	}
+	collection := geo.FeatureCollection{}
+	err = json.Unmarshal(file_str, &collection)
+	if err != nil {
+		panic(err.Error())
+	}
+	self.ClipToPolygons(collection)
+}
+
+func (self *GTFSFilter) Contains(coord geo.Coord) bool {
+	if self.bbox.HasValue() && !self.bbox.Value.ContainsCoord(coord) {
+		return false
+	}
+	if self.polygons.Length() == 0 {
+		return true
+	}
+	point := geo.NewPoint(coord)
+	for _, polygon := range self.polygons {
+		if polygon.Contains(&point) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,40 +1,79 @@
 package parser
 
 import (
-	"encoding/json"
+	"fmt"
+	"math"
 	"os"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/ttpr0/go-routing/comps"
 	"github.com/ttpr0/go-routing/geo"
 	"github.com/ttpr0/go-routing/structs"
 	. "github.com/ttpr0/go-routing/util"
+	"golang.org/x/exp/slog"
 )
 
 //*******************************************
 // gtfs parser
 //*******************************************
 
-func ParseGtfs(gtfs_path string, filter_polygon string) (Array[structs.Node], Array[structs.Connection], Dict[string, List[[]comps.ConnectionWeight]]) {
-	file_str, err := os.ReadFile(filter_polygon)
-	if err != nil {
-		panic(err.Error())
+// Parses the GTFS feeds into stops, connections and their schedules.
+//
+// Feeds are either directories or zip-archives. Multiple feeds are merged into one graph with their ids namespaced by the feed-name (e.g. "feed:stop_id").
+// If stop_merge_distance (in m) is positive stops of later feeds are merged into the closest stop of an earlier feed within that distance.
+//
+// If dates is empty one schedule is built per weekday ("monday", ...) using the weekday flags of calendar.txt only.
+// Otherwise one schedule is built per date (keyed by its ISO-date, e.g. "2024-05-01") with the validity ranges and calendar_dates.txt exceptions applied.
+//
+// Only stops within the filter are kept. Trips are cut at stops outside of it, trips leaving and re-entering the filter don't connect the stops at its border.
+//
+// Route-ids of the connections index into the returned routes, trip-ids of the schedules into the returned trips.
+// Transfers are read from the optional transfers.txt. The GTFS stop-ids are returned with the stop they have been mapped to.
+func ParseGtfs(gtfs_paths []string, filter GTFSFilter, dates []time.Time, stop_merge_distance float32) (Array[structs.Node], Array[structs.Connection], Dict[string, List[[]comps.ConnectionWeight]], Array[structs.Route], Array[structs.Transfer], Array[structs.Trip], Dict[string, int32]) {
+	feeds := NewList[*GTFSFeed](len(gtfs_paths))
+	for _, path := range gtfs_paths {
+		feed := OpenGTFSFeed(path)
+		defer feed.Close()
+		feeds.Add(feed)
 	}
-	collection := geo.FeatureCollection{}
-	err = json.Unmarshal(file_str, &collection)
-	if err != nil {
-		panic(err.Error())
+	namespaces := _GetFeedNamespaces(feeds)
+
+	ids := NewGTFSIDTables()
+	_stops := NewDict[int, GTFSStop](100)
+	_services := NewDict[int, GTFSService](10)
+	_routes := NewDict[int, GTFSRoute](100)
+	_trips := NewDict[int, GTFSTrip](100)
+	feed_stops := NewList[Dict[int, GTFSStop]](feeds.Length())
+	for i, feed := range feeds {
+		feed_ids := ids.Namespace(namespaces[i])
+		stops := _ReadStopLocations(feed, filter, feed_ids)
+		services := _ReadCalendar(feed, feed_ids)
+		routes := _ReadRoutes(feed, feed_ids)
+		trips := _ReadTrips(feed, stops, services, feed_ids)
+		_MergeDict(_stops, stops)
+		_MergeDict(_services, services)
+		_MergeDict(_routes, routes)
+		_MergeDict(_trips, trips)
+		feed_stops.Add(stops)
+	}
+	if stop_merge_distance > 0 && feeds.Length() > 1 {
+		_MergeFeedStops(_stops, feed_stops, stop_merge_distance)
 	}
-	filter := collection.Features()[0].Geometry()
-
-	_stops := _ReadStopLocations(gtfs_path, filter)
-	_services := _ReadCalendar(gtfs_path)
-	_trips := _ReadTrips(gtfs_path, _stops, _services)
 
-	stops, conns, schedules := _BuildTransitGraph(_trips, _stops, _services)
-	return Array[structs.Node](stops), Array[structs.Connection](conns), schedules
+	stops, conns, schedules, stop_mapping := _BuildTransitGraph(_trips, _stops, _services, _GetScheduleDays(dates))
+	transfers := NewList[structs.Transfer](10)
+	for i, feed := range feeds {
+		transfers = append(transfers, _ReadTransfers(feed, stop_mapping, ids.Namespace(namespaces[i]))...)
+	}
+	stop_ids := NewDict[string, int32](stop_mapping.Length())
+	for id, stop := range stop_mapping {
+		stop_ids[ids.stops.GetID(id)] = int32(stop)
+	}
+	return Array[structs.Node](stops), Array[structs.Connection](conns), schedules, _BuildRoutes(_routes, ids), Array[structs.Transfer](transfers), _BuildTrips(_trips, stop_mapping, ids), stop_ids
 }
 
 //*******************************************
@@ -42,36 +81,184 @@ func ParseGtfs(gtfs_path string, filter_polygon string) (Array[structs.Node], Ar
 //*******************************************
 
 type GTFSCalendarEntry struct {
-	ServiceID int `csv:"service_id"`
-	Monday    int `csv:"monday"`
-	Tuesday   int `csv:"tuesday"`
-	Wednesday int `csv:"wednesday"`
-	Thursday  int `csv:"thursday"`
-	Friday    int `csv:"friday"`
-	Saturday  int `csv:"saturday"`
-	Sunday    int `csv:"sunday"`
+	ServiceID string `csv:"service_id"`
+	Monday    int    `csv:"monday"`
+	Tuesday   int    `csv:"tuesday"`
+	Wednesday int    `csv:"wednesday"`
+	Thursday  int    `csv:"thursday"`
+	Friday    int    `csv:"friday"`
+	Saturday  int    `csv:"saturday"`
+	Sunday    int    `csv:"sunday"`
+	StartDate string `csv:"start_date"`
+	EndDate   string `csv:"end_date"`
+}
+
+type GTFSCalendarDateEntry struct {
+	ServiceID     string `csv:"service_id"`
+	Date          string `csv:"date"`
+	ExceptionType int    `csv:"exception_type"`
 }
 
 type GTFSStopEntry struct {
-	StopID int     `csv:"stop_id"`
+	StopID string  `csv:"stop_id"`
 	Lon    float32 `csv:"stop_lon"`
 	Lat    float32 `csv:"stop_lat"`
-	Parent int     `csv:"parent_station"`
+	Parent string  `csv:"parent_station"`
 	Type   int     `csv:"location_type"`
 }
 
 type GTFSStopTimesEntry struct {
-	TripID    int    `csv:"trip_id"`
+	TripID    string `csv:"trip_id"`
 	Arival    string `csv:"arrival_time"`
 	Departure string `csv:"departure_time"`
-	StopID    int    `csv:"stop_id"`
+	StopID    string `csv:"stop_id"`
 	StopSeq   int    `csv:"stop_sequence"`
 }
 
 type GTFSTripsEntry struct {
-	TripID    int `csv:"trip_id"`
-	RouteID   int `csv:"route_id"`
-	ServiceID int `csv:"service_id"`
+	TripID    string `csv:"trip_id"`
+	RouteID   string `csv:"route_id"`
+	ServiceID string `csv:"service_id"`
+}
+
+type GTFSRoutesEntry struct {
+	RouteID   string `csv:"route_id"`
+	AgencyID  string `csv:"agency_id"`
+	ShortName string `csv:"route_short_name"`
+	LongName  string `csv:"route_long_name"`
+	Type      int    `csv:"route_type"`
+}
+
+type GTFSAgencyEntry struct {
+	AgencyID string `csv:"agency_id"`
+	Name     string `csv:"agency_name"`
+}
+
+type GTFSTransferEntry struct {
+	FromStopID string `csv:"from_stop_id"`
+	ToStopID   string `csv:"to_stop_id"`
+	Type       string `csv:"transfer_type"`
+	MinTime    string `csv:"min_transfer_time"`
+}
+
+type GTFSFrequencyEntry struct {
+	TripID    string `csv:"trip_id"`
+	StartTime string `csv:"start_time"`
+	EndTime   string `csv:"end_time"`
+	Headway   int    `csv:"headway_secs"`
+}
+
+//*******************************************
+// id tables
+//*******************************************
+
+// Maps the string ids of a GTFS feed to consecutive integers.
+type GTFSIDTable struct {
+	ids     *List[string]
+	mapping Dict[string, int]
+	// prefix of all string ids interned or looked up (namespaces the ids of multiple feeds)
+	prefix string
+}
+
+func NewGTFSIDTable() *GTFSIDTable {
+	ids := NewList[string](100)
+	return &GTFSIDTable{
+		ids:     &ids,
+		mapping: NewDict[string, int](100),
+	}
+}
+
+// Returns the integer id of the string id, unknown ids are added to the table.
+func (self *GTFSIDTable) Intern(id string) int {
+	id = self.prefix + id
+	if self.mapping.ContainsKey(id) {
+		return self.mapping[id]
+	}
+	index := self.ids.Length()
+	self.ids.Add(id)
+	self.mapping[id] = index
+	return index
+}
+
+// Returns the integer id of the string id or -1 if it is unknown.
+func (self *GTFSIDTable) Get(id string) int {
+	id = self.prefix + id
+	if self.mapping.ContainsKey(id) {
+		return self.mapping[id]
+	}
+	return -1
+}
+
+// Returns the (namespaced) string id of the integer id.
+func (self *GTFSIDTable) GetID(index int) string {
+	return (*self.ids)[index]
+}
+
+func (self *GTFSIDTable) Length() int {
+	return self.ids.Length()
+}
+
+// Returns a view of the table sharing its ids with all string ids prefixed by the namespace.
+func (self *GTFSIDTable) Namespace(namespace string) *GTFSIDTable {
+	return &GTFSIDTable{
+		ids:     self.ids,
+		mapping: self.mapping,
+		prefix:  namespace,
+	}
+}
+
+// Id tables of all GTFS entities (ids are only unique per entity).
+type GTFSIDTables struct {
+	stops    *GTFSIDTable
+	trips    *GTFSIDTable
+	routes   *GTFSIDTable
+	services *GTFSIDTable
+}
+
+func NewGTFSIDTables() GTFSIDTables {
+	return GTFSIDTables{
+		stops:    NewGTFSIDTable(),
+		trips:    NewGTFSIDTable(),
+		routes:   NewGTFSIDTable(),
+		services: NewGTFSIDTable(),
+	}
+}
+
+// Returns views of the tables with all string ids prefixed by the namespace.
+func (self GTFSIDTables) Namespace(namespace string) GTFSIDTables {
+	return GTFSIDTables{
+		stops:    self.stops.Namespace(namespace),
+		trips:    self.trips.Namespace(namespace),
+		routes:   self.routes.Namespace(namespace),
+		services: self.services.Namespace(namespace),
+	}
+}
+
+// Returns the id-namespace of every feed.
+//
+// Ids of a single feed are kept as they are, otherwise the feed-names are used (numbered if they are not unique).
+func _GetFeedNamespaces(feeds List[*GTFSFeed]) List[string] {
+	namespaces := NewList[string](feeds.Length())
+	if feeds.Length() == 1 {
+		namespaces.Add("")
+		return namespaces
+	}
+	used := NewDict[string, bool](feeds.Length())
+	for i, feed := range feeds {
+		name := feed.Name()
+		if used.ContainsKey(name) {
+			name = name + "-" + strconv.Itoa(i)
+		}
+		used[name] = true
+		namespaces.Add(name + ":")
+	}
+	return namespaces
+}
+
+func _MergeDict[K comparable, V any](dict Dict[K, V], other Dict[K, V]) {
+	for k, v := range other {
+		dict[k] = v
+	}
 }
 
 //*******************************************
@@ -81,46 +268,155 @@ type GTFSTripsEntry struct {
 type GTFSService struct {
 	service_id int
 	days       []int
+	start_date string
+	end_date   string
+	// dates (YYYYMMDD) added or removed by calendar_dates.txt
+	added   Dict[string, bool]
+	removed Dict[string, bool]
 }
 
 func (self *GTFSService) GetDays() []int {
 	return self.days
 }
 
-func _ReadCalendar(path string) Dict[int, GTFSService] {
+// Returns true if the service runs on the weekday (1 = monday, ..., 7 = sunday).
+func (self *GTFSService) IsActiveOnWeekday(day int) bool {
+	return slices.Contains(self.days, day)
+}
+
+// Returns true if the service runs at the date.
+//
+// Exceptions from calendar_dates.txt take precedence over the regular weekly service.
+func (self *GTFSService) IsActiveOnDate(date time.Time) bool {
+	date_str := date.Format("20060102")
+	if self.removed.ContainsKey(date_str) {
+		return false
+	}
+	if self.added.ContainsKey(date_str) {
+		return true
+	}
+	if self.start_date != "" && date_str < self.start_date {
+		return false
+	}
+	if self.end_date != "" && date_str > self.end_date {
+		return false
+	}
+	return self.IsActiveOnWeekday(_GetWeekday(date))
+}
+
+func _ReadCalendar(feed *GTFSFeed, ids GTFSIDTables) Dict[int, GTFSService] {
 	services := NewDict[int, GTFSService](100)
-	for service := range ReadCSVFromFile[GTFSCalendarEntry](path+"/calendar.txt", ',') {
-		service_id := service.ServiceID
-		days := NewList[int](3)
-		if service.Monday == 1 {
-			days.Add(1)
-		}
-		if service.Tuesday == 1 {
-			days.Add(2)
-		}
-		if service.Wednesday == 1 {
-			days.Add(3)
-		}
-		if service.Thursday == 1 {
-			days.Add(4)
-		}
-		if service.Friday == 1 {
-			days.Add(5)
-		}
-		if service.Saturday == 1 {
-			days.Add(6)
-		}
-		if service.Sunday == 1 {
-			days.Add(7)
+	if feed.HasFile("calendar.txt") {
+		for service := range _ReadFeedCSV[GTFSCalendarEntry](feed, "calendar.txt") {
+			service_id := ids.services.Intern(service.ServiceID)
+			days := NewList[int](3)
+			if service.Monday == 1 {
+				days.Add(1)
+			}
+			if service.Tuesday == 1 {
+				days.Add(2)
+			}
+			if service.Wednesday == 1 {
+				days.Add(3)
+			}
+			if service.Thursday == 1 {
+				days.Add(4)
+			}
+			if service.Friday == 1 {
+				days.Add(5)
+			}
+			if service.Saturday == 1 {
+				days.Add(6)
+			}
+			if service.Sunday == 1 {
+				days.Add(7)
+			}
+			services[service_id] = GTFSService{
+				service_id: service_id,
+				days:       days,
+				start_date: service.StartDate,
+				end_date:   service.EndDate,
+				added:      NewDict[string, bool](0),
+				removed:    NewDict[string, bool](0),
+			}
 		}
-		services[service_id] = GTFSService{
-			service_id: service_id,
-			days:       days,
+	}
+	if feed.HasFile("calendar_dates.txt") {
+		for entry := range _ReadFeedCSV[GTFSCalendarDateEntry](feed, "calendar_dates.txt") {
+			service_id := ids.services.Intern(entry.ServiceID)
+			if !services.ContainsKey(service_id) {
+				// services might be defined by calendar_dates.txt only
+				services[service_id] = GTFSService{
+					service_id: service_id,
+					days:       []int{},
+					added:      NewDict[string, bool](0),
+					removed:    NewDict[string, bool](0),
+				}
+			}
+			service := services[service_id]
+			switch entry.ExceptionType {
+			case 1:
+				service.added[entry.Date] = true
+			case 2:
+				service.removed[entry.Date] = true
+			}
 		}
 	}
 	return services
 }
 
+//*******************************************
+// schedule days
+//*******************************************
+
+// Day a schedule is built for.
+type GTFSScheduleDay struct {
+	// key of the schedule
+	name string
+	// weekday (1 = monday, ..., 7 = sunday)
+	weekday int
+	// date of the schedule (none for weekday schedules)
+	date Optional[time.Time]
+}
+
+// Returns true if the service runs at the schedule day.
+func (self *GTFSScheduleDay) IsActive(service GTFSService) bool {
+	if self.date.HasValue() {
+		return service.IsActiveOnDate(self.date.Value)
+	}
+	return service.IsActiveOnWeekday(self.weekday)
+}
+
+var WEEKDAYS = []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"}
+
+func _GetScheduleDays(dates []time.Time) List[GTFSScheduleDay] {
+	days := NewList[GTFSScheduleDay](7)
+	if len(dates) == 0 {
+		for i, name := range WEEKDAYS {
+			days.Add(GTFSScheduleDay{name: name, weekday: i + 1, date: None[time.Time]()})
+		}
+		return days
+	}
+	for _, date := range dates {
+		days.Add(GTFSScheduleDay{name: date.Format("2006-01-02"), weekday: _GetWeekday(date), date: Some(date)})
+	}
+	return days
+}
+
+// Returns the weekday of the date (1 = monday, ..., 7 = sunday).
+func _GetWeekday(date time.Time) int {
+	weekday := int(date.Weekday())
+	if weekday == 0 {
+		return 7
+	}
+	return weekday
+}
+
+func _FileExists(file string) bool {
+	_, err := os.Stat(file)
+	return err == nil
+}
+
 //*******************************************
 // parse stops
 //*******************************************
@@ -143,28 +439,26 @@ func (self *GTFSStop) GetLonLat() (float32, float32) {
 	return self.lon, self.lat
 }
 
-func _ReadStopLocations(path string, filter geo.Geometry) Dict[int, GTFSStop] {
+func _ReadStopLocations(feed *GTFSFeed, filter GTFSFilter, ids GTFSIDTables) Dict[int, GTFSStop] {
 	stops := NewDict[int, GTFSStop](100)
-	point := geo.Point{}
-	for entry := range ReadCSVFromFile[GTFSStopEntry](path+"/stops.txt", ',') {
-		id := entry.StopID
+	for entry := range _ReadFeedCSV[GTFSStopEntry](feed, "stops.txt") {
+		id := ids.stops.Intern(entry.StopID)
 		lon := entry.Lon
 		lat := entry.Lat
-		parent := entry.Parent
+		parent := -1
+		if entry.Parent != "" {
+			parent = ids.stops.Intern(entry.Parent)
+		}
 		typ := entry.Type
 		if lon == 0 || lat == 0 || typ >= 2 {
-			if parent == 0 {
+			if parent == -1 {
 				continue
 			}
 			stops[id] = GTFSStop{id, 0, 0, typ, parent}
 		} else {
-			point.SetCoordinates(geo.Coord{lon, lat})
-			if !filter.Contains(&point) {
+			if !filter.Contains(geo.Coord{lon, lat}) {
 				continue
 			}
-			if parent == 0 {
-				parent = -1
-			}
 			stops[id] = GTFSStop{id, lon, lat, typ, parent}
 		}
 	}
@@ -181,7 +475,7 @@ func _ReadStopLocations(path string, filter geo.Geometry) Dict[int, GTFSStop] {
 				parent := stops[parent_id]
 				if parent.HasParent() {
 					stop.parent_id = parent.GetParent()
-					stops[parent_id] = stop
+					stops[id] = stop
 				}
 				if !stops.ContainsKey(stop.parent_id) {
 					delete.Add(id)
@@ -198,6 +492,49 @@ func _ReadStopLocations(path string, filter geo.Geometry) Dict[int, GTFSStop] {
 
 }
 
+// Merges stations of later feeds into the closest station of an earlier feed within max_dist (in m).
+//
+// Merged stations become children of the station they are merged into, stops of the same feed are never merged.
+func _MergeFeedStops(stops Dict[int, GTFSStop], feed_stops List[Dict[int, GTFSStop]], max_dist float32) {
+	tree := NewKDTree[int](2)
+	merged := NewDict[int, int](10)
+	for f, curr_stops := range feed_stops {
+		added := NewList[int](curr_stops.Length())
+		for id, stop := range curr_stops {
+			if stop.HasParent() {
+				continue
+			}
+			if f > 0 {
+				// degrees of longitude are shorter than degrees of latitude, the radius has to cover both
+				radius := max_dist / (111320 * float32(math.Cos(float64(stop.lat)*math.Pi/180)))
+				other_id, ok := tree.GetClosest([]float32{stop.lon, stop.lat}, radius)
+				if ok {
+					other := stops[other_id]
+					if geo.HaversineDistance(geo.Coord{stop.lon, stop.lat}, geo.Coord{other.lon, other.lat}) <= float64(max_dist) {
+						merged[id] = other_id
+						continue
+					}
+				}
+			}
+			added.Add(id)
+		}
+		for _, id := range added {
+			stop := stops[id]
+			tree.Insert([]float32{stop.lon, stop.lat}, id)
+		}
+	}
+	for id, stop := range stops {
+		if merged.ContainsKey(id) {
+			stop.parent_id = merged[id]
+		} else if stop.HasParent() && merged.ContainsKey(stop.parent_id) {
+			stop.parent_id = merged[stop.parent_id]
+		} else {
+			continue
+		}
+		stops[id] = stop
+	}
+}
+
 //*******************************************
 // parse trips
 //*******************************************
@@ -231,69 +568,234 @@ func (self *GTFSTrip) OrderStops() {
 	})
 }
 
-func _ParseTime(time_str string) int {
-	tokens := strings.Split(time_str, ":")
+// Parses a GTFS time ("HH:MM:SS", hours may exceed 24) into seconds since midnight, returns false if the format is invalid.
+func ParseGTFSTime(time_str string) (int, bool) {
+	tokens := strings.Split(strings.TrimSpace(time_str), ":")
 	if len(tokens) != 3 {
-		panic("Invalid time format: " + time_str)
+		return 0, false
 	}
 	time := 0
-	dt, _ := strconv.Atoi(tokens[2])
-	time += dt
-	dt, _ = strconv.Atoi(tokens[1])
-	time += dt * 60
-	dt, _ = strconv.Atoi(tokens[0])
-	time += dt * 3600
-	return time
+	for i, token := range tokens {
+		value, err := strconv.Atoi(token)
+		if err != nil || value < 0 || (i > 0 && value >= 60) {
+			return 0, false
+		}
+		time = time*60 + value
+	}
+	return time, true
 }
 
-func _ReadTrips(path string, stops Dict[int, GTFSStop], services Dict[int, GTFSService]) Dict[int, GTFSTrip] {
+// Reads the trips of the stops, stop-times with invalid times are skipped.
+func _ReadTrips(feed *GTFSFeed, stops Dict[int, GTFSStop], services Dict[int, GTFSService], ids GTFSIDTables) Dict[int, GTFSTrip] {
 	trips := NewDict[int, GTFSTrip](10)
-	for entry := range ReadCSVFromFile[GTFSStopTimesEntry](path+"/stop_times.txt", ',') {
-		trip_id := entry.TripID
+	invalid := 0
+	for entry := range _ReadFeedCSV[GTFSStopTimesEntry](feed, "stop_times.txt") {
+		trip_id := ids.trips.Intern(entry.TripID)
 		if !trips.ContainsKey(trip_id) {
 			trips[trip_id] = GTFSTrip{
-				trip_id: trip_id,
+				trip_id:    trip_id,
+				route_id:   -1,
+				service_id: -1,
 			}
 		}
 		trip := trips[trip_id]
-		s_id := entry.StopID
+		s_id := ids.stops.Get(entry.StopID)
 		if !stops.ContainsKey(s_id) {
+			// stops outside of the filter are kept as gaps to cut the trip (times are kept for frequency-based trips)
+			a_time, a_ok := ParseGTFSTime(entry.Arival)
+			d_time, d_ok := ParseGTFSTime(entry.Departure)
+			if !a_ok || !d_ok {
+				a_time, d_time = 0, 0
+			}
+			trip.AddStop(GTFSTripStop{-1, a_time, d_time, entry.StopSeq})
+			trips[trip_id] = trip
+			continue
+		}
+		// times of stops that are not timepoints may be omitted
+		if entry.Arival == "" || entry.Departure == "" {
+			continue
+		}
+		a_time, a_ok := ParseGTFSTime(entry.Arival)
+		d_time, d_ok := ParseGTFSTime(entry.Departure)
+		if !a_ok || !d_ok {
+			invalid += 1
 			continue
 		}
-		a_time := _ParseTime(entry.Arival)
-		d_time := _ParseTime(entry.Departure)
 		s_seq := entry.StopSeq
 		trip.AddStop(GTFSTripStop{s_id, a_time, d_time, s_seq})
 		trips[trip_id] = trip
 	}
+	if invalid > 0 {
+		slog.Warn(fmt.Sprintf("skipped %v stop-times with invalid times in %v", invalid, feed.Name()))
+	}
 	for _, trip := range trips {
 		trip.OrderStops()
 	}
 
-	for entry := range ReadCSVFromFile[GTFSTripsEntry](path+"/trips.txt", ',') {
-		trip_id := entry.TripID
+	for entry := range _ReadFeedCSV[GTFSTripsEntry](feed, "trips.txt") {
+		trip_id := ids.trips.Get(entry.TripID)
 		if !trips.ContainsKey(trip_id) {
 			continue
 		}
 		trip := trips[trip_id]
-		route_id := entry.RouteID
+		route_id := ids.routes.Intern(entry.RouteID)
 		trip.SetRouteID(route_id)
-		service_id := entry.ServiceID
+		service_id := ids.services.Get(entry.ServiceID)
 		if !services.ContainsKey(service_id) {
+			trips[trip_id] = trip
 			continue
 		}
 		trip.SetServiceID(service_id)
 		trips[trip_id] = trip
 	}
 
+	if feed.HasFile("frequencies.txt") {
+		_ExpandFrequencies(feed, trips, ids)
+	}
+
 	return trips
 }
 
+// Replaces frequency-based trips by one trip per departure.
+//
+// Stop-times of frequency-based trips only define the travel-times relative to the first departure.
+// Entries with invalid times are skipped.
+func _ExpandFrequencies(feed *GTFSFeed, trips Dict[int, GTFSTrip], ids GTFSIDTables) {
+	templates := NewDict[int, GTFSTrip](10)
+	for entry := range _ReadFeedCSV[GTFSFrequencyEntry](feed, "frequencies.txt") {
+		trip_id := ids.trips.Get(entry.TripID)
+		if trip_id == -1 || entry.Headway <= 0 {
+			continue
+		}
+		if !templates.ContainsKey(trip_id) {
+			if !trips.ContainsKey(trip_id) {
+				continue
+			}
+			templates[trip_id] = trips[trip_id]
+			trips.Delete(trip_id)
+		}
+		template := templates[trip_id]
+		if template.stops.Length() == 0 {
+			continue
+		}
+		offset := template.stops[0].departure
+		start, start_ok := ParseGTFSTime(entry.StartTime)
+		end, end_ok := ParseGTFSTime(entry.EndTime)
+		if !start_ok || !end_ok {
+			slog.Warn(fmt.Sprintf("skipped frequency of trip %v with invalid times in %v", entry.TripID, feed.Name()))
+			continue
+		}
+		for t := start; t < end; t += entry.Headway {
+			new_id := ids.trips.Intern(entry.TripID + "@" + strconv.Itoa(t))
+			new_stops := NewList[GTFSTripStop](template.stops.Length())
+			for _, stop := range template.stops {
+				new_stops.Add(GTFSTripStop{
+					stop_id:   stop.stop_id,
+					arrival:   stop.arrival - offset + t,
+					departure: stop.departure - offset + t,
+					sequence:  stop.sequence,
+				})
+			}
+			trips[new_id] = GTFSTrip{
+				trip_id:    new_id,
+				route_id:   template.route_id,
+				service_id: template.service_id,
+				stops:      new_stops,
+			}
+		}
+	}
+}
+
+//*******************************************
+// parse routes
+//*******************************************
+
+type GTFSRoute struct {
+	route_id   int
+	agency     string
+	short_name string
+	long_name  string
+	typ        int
+}
+
+func _ReadRoutes(feed *GTFSFeed, ids GTFSIDTables) Dict[int, GTFSRoute] {
+	agencies := NewDict[string, string](10)
+	if feed.HasFile("agency.txt") {
+		for entry := range _ReadFeedCSV[GTFSAgencyEntry](feed, "agency.txt") {
+			agencies[entry.AgencyID] = entry.Name
+		}
+	}
+	routes := NewDict[int, GTFSRoute](100)
+	if !feed.HasFile("routes.txt") {
+		return routes
+	}
+	for entry := range _ReadFeedCSV[GTFSRoutesEntry](feed, "routes.txt") {
+		route_id := ids.routes.Intern(entry.RouteID)
+		agency := agencies[entry.AgencyID]
+		if entry.AgencyID == "" && len(agencies) == 1 {
+			// agency_id is optional for feeds containing a single agency
+			for _, name := range agencies {
+				agency = name
+			}
+		}
+		routes[route_id] = GTFSRoute{
+			route_id:   route_id,
+			agency:     agency,
+			short_name: entry.ShortName,
+			long_name:  entry.LongName,
+			typ:        entry.Type,
+		}
+	}
+	return routes
+}
+
+// Builds the routes indexed by their internal ids, routes missing in routes.txt only contain their id.
+func _BuildRoutes(routes Dict[int, GTFSRoute], ids GTFSIDTables) Array[structs.Route] {
+	routes_vec := NewArray[structs.Route](ids.routes.Length())
+	for i := 0; i < ids.routes.Length(); i++ {
+		route := routes[i]
+		routes_vec[i] = structs.Route{
+			ID:        ids.routes.GetID(i),
+			Agency:    route.agency,
+			ShortName: route.short_name,
+			LongName:  route.long_name,
+			Type:      int32(route.typ),
+		}
+	}
+	return routes_vec
+}
+
+// Builds the trips indexed by their internal ids, trips without stops (e.g. templates of frequencies) only contain their id.
+func _BuildTrips(trips Dict[int, GTFSTrip], stop_mapping Dict[int, int], ids GTFSIDTables) Array[structs.Trip] {
+	trips_vec := NewArray[structs.Trip](ids.trips.Length())
+	for i := 0; i < ids.trips.Length(); i++ {
+		trip := trips[i]
+		stops := make([]structs.TripStop, 0, trip.stops.Length())
+		for _, stop := range trip.stops {
+			if stop.stop_id == -1 {
+				continue
+			}
+			stops = append(stops, structs.TripStop{
+				Stop:     int32(stop_mapping[stop.stop_id]),
+				Sequence: int32(stop.sequence),
+			})
+		}
+		trips_vec[i] = structs.Trip{
+			ID:    ids.trips.GetID(i),
+			Stops: stops,
+		}
+	}
+	return trips_vec
+}
+
 //*******************************************
 // parse to graph
 //*******************************************
 
-func _BuildTransitGraph(trips Dict[int, GTFSTrip], stops Dict[int, GTFSStop], services Dict[int, GTFSService]) (List[structs.Node], List[structs.Connection], Dict[string, List[[]comps.ConnectionWeight]]) {
+// Builds stops, connections and their schedules, child stops are merged into their parent station.
+//
+// Also returns the mapping from GTFS stops to the built stops.
+func _BuildTransitGraph(trips Dict[int, GTFSTrip], stops Dict[int, GTFSStop], services Dict[int, GTFSService], schedule_days List[GTFSScheduleDay]) (List[structs.Node], List[structs.Connection], Dict[string, List[[]comps.ConnectionWeight]], Dict[int, int]) {
 	stops_vec := NewList[structs.Node](10)
 	stop_mapping := NewDict[int, int](10)
 	skiped := NewList[int](10)
@@ -316,14 +818,9 @@ func _BuildTransitGraph(trips Dict[int, GTFSTrip], stops Dict[int, GTFSStop], se
 
 	conns_vec := NewList[structs.Connection](10)
 	conn_mapping := NewDict[Triple[int, int, int], int](10)
-	schedules := Dict[string, List[[]comps.ConnectionWeight]]{
-		"monday":    NewList[[]comps.ConnectionWeight](10),
-		"tuesday":   NewList[[]comps.ConnectionWeight](10),
-		"wednesday": NewList[[]comps.ConnectionWeight](10),
-		"thursday":  NewList[[]comps.ConnectionWeight](10),
-		"friday":    NewList[[]comps.ConnectionWeight](10),
-		"saturday":  NewList[[]comps.ConnectionWeight](10),
-		"sunday":    NewList[[]comps.ConnectionWeight](10),
+	schedules := NewDict[string, List[[]comps.ConnectionWeight]](schedule_days.Length())
+	for _, day := range schedule_days {
+		schedules[day.name] = NewList[[]comps.ConnectionWeight](10)
 	}
 	for _, trip := range trips {
 		if trip.service_id == -1 || trip.route_id == -1 {
@@ -331,11 +828,20 @@ func _BuildTransitGraph(trips Dict[int, GTFSTrip], stops Dict[int, GTFSStop], se
 		}
 		route_id := trip.route_id
 		service := services[trip.service_id]
-		days := service.GetDays()
+		active_days := NewList[string](schedule_days.Length())
+		for _, day := range schedule_days {
+			if day.IsActive(service) {
+				active_days.Add(day.name)
+			}
+		}
 		trip_stops := trip.stops
 		for i := 0; i < len(trip_stops)-1; i++ {
 			curr_t_stop := trip_stops[i]
 			next_t_stop := trip_stops[i+1]
+			// trip leaves the filtered area
+			if curr_t_stop.stop_id == -1 || next_t_stop.stop_id == -1 {
+				continue
+			}
 			stop_a := stop_mapping[curr_t_stop.stop_id]
 			stop_b := stop_mapping[next_t_stop.stop_id]
 			dep := curr_t_stop.departure
@@ -350,43 +856,26 @@ func _BuildTransitGraph(trips Dict[int, GTFSTrip], stops Dict[int, GTFSStop], se
 				conns_vec.Add(conn)
 				conn_id = len(conns_vec) - 1
 				conn_mapping[MakeTriple(stop_a, stop_b, route_id)] = conn_id
-				for _, day := range []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"} {
-					schedule := schedules[day]
+				for _, day := range schedule_days {
+					schedule := schedules[day.name]
 					schedule.Add(NewList[comps.ConnectionWeight](2))
-					schedules[day] = schedule
+					schedules[day.name] = schedule
 				}
 			} else {
 				conn_id = conn_mapping[MakeTriple(stop_a, stop_b, route_id)]
 			}
-			for _, day := range days {
-				var sc List[[]comps.ConnectionWeight]
-				switch day {
-				case 1:
-					sc = schedules["monday"]
-				case 2:
-					sc = schedules["tuesday"]
-				case 3:
-					sc = schedules["wednesday"]
-				case 4:
-					sc = schedules["thursday"]
-				case 5:
-					sc = schedules["friday"]
-				case 6:
-					sc = schedules["saturday"]
-				case 7:
-					sc = schedules["sunday"]
-				default:
-					panic("Invalid day: " + string(day))
-				}
+			for _, day := range active_days {
+				sc := schedules[day]
 				sc[conn_id] = append(sc[conn_id], comps.ConnectionWeight{
 					Departure: int32(dep),
 					Arrival:   int32(arr),
+					Trip:      int32(trip.trip_id),
 				})
 			}
 		}
 	}
-	for _, day := range []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"} {
-		schedule := schedules[day]
+	for _, day := range schedule_days {
+		schedule := schedules[day.name]
 		for i := 0; i < len(schedule); i++ {
 			sc := schedule[i]
 			sort.Slice(sc, func(i, j int) bool {
@@ -395,5 +884,50 @@ func _BuildTransitGraph(trips Dict[int, GTFSTrip], stops Dict[int, GTFSStop], se
 			schedule[i] = sc
 		}
 	}
-	return stops_vec, conns_vec, schedules
+	return stops_vec, conns_vec, schedules, stop_mapping
+}
+
+//*******************************************
+// parse transfers
+//*******************************************
+
+// Reads the transfers between the built stops from transfers.txt.
+//
+// Transfers of stops outside the filter are skipped, multiple transfers between the same stops are merged keeping the strictest.
+func _ReadTransfers(feed *GTFSFeed, stop_mapping Dict[int, int], ids GTFSIDTables) List[structs.Transfer] {
+	transfers := NewList[structs.Transfer](10)
+	if !feed.HasFile("transfers.txt") {
+		return transfers
+	}
+	transfer_mapping := NewDict[Tuple[int, int], int](10)
+	for entry := range _ReadFeedCSV[GTFSTransferEntry](feed, "transfers.txt") {
+		from_id := ids.stops.Get(entry.FromStopID)
+		to_id := ids.stops.Get(entry.ToStopID)
+		if !stop_mapping.ContainsKey(from_id) || !stop_mapping.ContainsKey(to_id) {
+			continue
+		}
+		// transfer_type defaults to 0 (recommended)
+		typ, _ := strconv.Atoi(entry.Type)
+		min_time, _ := strconv.Atoi(entry.MinTime)
+		if typ != 2 {
+			min_time = 0
+		}
+		transfer := structs.Transfer{
+			StopA:   int32(stop_mapping[from_id]),
+			StopB:   int32(stop_mapping[to_id]),
+			Type:    int32(typ),
+			MinTime: int32(min_time),
+		}
+		key := MakeTuple(int(transfer.StopA), int(transfer.StopB))
+		if !transfer_mapping.ContainsKey(key) {
+			transfer_mapping[key] = transfers.Length()
+			transfers.Add(transfer)
+			continue
+		}
+		curr := transfers[transfer_mapping[key]]
+		if transfer.Type == 3 || (curr.Type != 3 && transfer.MinTime > curr.MinTime) {
+			transfers[transfer_mapping[key]] = transfer
+		}
+	}
+	return transfers
 }
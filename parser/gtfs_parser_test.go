@@ -0,0 +1,330 @@
+package parser
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ttpr0/go-routing/comps"
+	"github.com/ttpr0/go-routing/geo"
+	"github.com/ttpr0/go-routing/structs"
+	. "github.com/ttpr0/go-routing/util"
+)
+
+// Line S1-S2-S3-S4 (S4 north of S1) of route R1.
+//
+// T1 runs on weekdays at 08:00 except 2024-05-06, T2 only on 2024-05-08 at 09:00 and F1 every 30min from 06:00 to 07:00 on weekdays.
+var TEST_GTFS_FEED = map[string]string{
+	"agency.txt": "agency_id,agency_name\nA,Agency\n",
+	"stops.txt":  "stop_id,stop_lon,stop_lat\nS1,8.0,49.0\nS2,8.01,49.0\nS3,8.02,49.0\nS4,8.0,49.01\n",
+	"routes.txt": "route_id,agency_id,route_short_name,route_type\nR1,A,1,3\n",
+	"calendar.txt": "service_id,monday,tuesday,wednesday,thursday,friday,saturday,sunday,start_date,end_date\n" +
+		"WK,1,1,1,1,1,0,0,20240101,20241231\n",
+	"calendar_dates.txt": "service_id,date,exception_type\nWK,20240506,2\nWE,20240508,1\n",
+	"trips.txt":          "route_id,service_id,trip_id\nR1,WK,T1\nR1,WE,T2\nR1,WK,F1\n",
+	"stop_times.txt": "trip_id,arrival_time,departure_time,stop_id,stop_sequence\n" +
+		"T1,08:00:00,08:00:00,S1,1\nT1,08:05:00,08:06:00,S2,2\nT1,08:10:00,08:10:00,S3,3\nT1,08:15:00,08:15:00,S4,4\n" +
+		"T2,09:00:00,09:00:00,S1,1\nT2,09:05:00,09:05:00,S2,2\n" +
+		"F1,00:00:00,00:00:00,S1,1\nF1,00:04:00,00:04:00,S2,2\n",
+	"frequencies.txt": "trip_id,start_time,end_time,headway_secs\nF1,06:00:00,07:00:00,1800\n",
+}
+
+// Writes the files of the feed into the directory or, if path ends with ".zip", into a zip-archive (within a sub-directory).
+func write_gtfs_feed(t *testing.T, path string, files map[string]string) string {
+	if filepath.Ext(path) != ".zip" {
+		os.MkdirAll(path, 0755)
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(path, name), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return path
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	writer := zip.NewWriter(file)
+	for name, content := range files {
+		w, err := writer.Create("gtfs/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// Returns the departures on the connection between the stops (sorted) and the ids of their trips.
+func get_departures(conns Array[structs.Connection], schedule List[[]comps.ConnectionWeight], trips Array[structs.Trip], stop_a, stop_b int32) ([]int32, []string) {
+	departures := []int32{}
+	trip_ids := []string{}
+	for c, conn := range conns {
+		if conn.StopA != stop_a || conn.StopB != stop_b {
+			continue
+		}
+		for _, w := range schedule[c] {
+			departures = append(departures, w.Departure)
+			trip_ids = append(trip_ids, trips[w.Trip].ID)
+		}
+	}
+	return departures, trip_ids
+}
+
+func TestParseGtfsSchedules(t *testing.T) {
+	path := write_gtfs_feed(t, filepath.Join(t.TempDir(), "feed"), TEST_GTFS_FEED)
+	dates := []time.Time{}
+	for _, d := range []string{"2024-05-06", "2024-05-07", "2024-05-08"} {
+		date, _ := time.Parse("2006-01-02", d)
+		dates = append(dates, date)
+	}
+	tests := []struct {
+		name     string
+		dates    []time.Time
+		expected map[string][]int32
+	}{
+		{"weekdays", nil, map[string][]int32{
+			"monday":   {21600, 23400, 28800},
+			"saturday": {},
+		}},
+		{"dates", dates, map[string][]int32{
+			// removed by calendar_dates.txt
+			"2024-05-06": {},
+			"2024-05-07": {21600, 23400, 28800},
+			// service only defined by calendar_dates.txt
+			"2024-05-08": {21600, 23400, 28800, 32400},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, conns, schedules, routes, _, trips, stop_ids := ParseGtfs([]string{path}, NewGTFSFilter(), tt.dates, 0)
+			// one schedule per date or per weekday
+			schedule_count := len(tt.dates)
+			if schedule_count == 0 {
+				schedule_count = 7
+			}
+			if len(schedules) != schedule_count {
+				t.Errorf("expected %v schedules, got %v", schedule_count, len(schedules))
+			}
+			for day, expected := range tt.expected {
+				departures, _ := get_departures(conns, schedules[day], trips, stop_ids["S1"], stop_ids["S2"])
+				if !slices.Equal(departures, expected) {
+					t.Errorf("%v: expected departures %v, got %v", day, expected, departures)
+				}
+			}
+			for _, conn := range conns {
+				if route := routes[conn.RouteID]; route.ID != "R1" || route.Agency != "Agency" || route.ShortName != "1" {
+					t.Errorf("unexpected route %+v", route)
+				}
+			}
+		})
+	}
+}
+
+func TestParseGtfsFrequencies(t *testing.T) {
+	path := write_gtfs_feed(t, filepath.Join(t.TempDir(), "feed"), TEST_GTFS_FEED)
+	_, conns, schedules, _, _, trips, stop_ids := ParseGtfs([]string{path}, NewGTFSFilter(), nil, 0)
+	schedule := schedules["monday"]
+	_, trip_ids := get_departures(conns, schedule, trips, stop_ids["S1"], stop_ids["S2"])
+	if expected := []string{"F1@21600", "F1@23400", "T1"}; !slices.Equal(trip_ids, expected) {
+		t.Errorf("expected trips %v, got %v", expected, trip_ids)
+	}
+	for c, conn := range conns {
+		if conn.StopA != stop_ids["S1"] || conn.StopB != stop_ids["S2"] {
+			continue
+		}
+		// travel-times are kept relative to the first departure
+		if w := schedule[c][0]; w.Arrival-w.Departure != 240 {
+			t.Errorf("expected a travel-time of 240, got %v", w.Arrival-w.Departure)
+		}
+	}
+	for _, trip := range trips {
+		if trip.ID == "F1" && len(trip.Stops) != 0 {
+			t.Errorf("expected the frequency template to contain no stops")
+		}
+		if trip.ID == "F1@23400" && len(trip.Stops) != 2 {
+			t.Errorf("expected the expanded trip to contain 2 stops, got %v", len(trip.Stops))
+		}
+	}
+}
+
+func TestParseGtfsFeeds(t *testing.T) {
+	dir := t.TempDir()
+	// both feeds use the same ids
+	other := map[string]string{
+		"stops.txt":      "stop_id,stop_lon,stop_lat\nS1,9.0,50.0\nS2,9.01,50.0\n",
+		"routes.txt":     "route_id,route_short_name,route_type\nR1,2,0\n",
+		"calendar.txt":   "service_id,monday,tuesday,wednesday,thursday,friday,saturday,sunday,start_date,end_date\nWK,1,1,1,1,1,0,0,20240101,20241231\n",
+		"trips.txt":      "route_id,service_id,trip_id\nR1,WK,T1\n",
+		"stop_times.txt": "trip_id,arrival_time,departure_time,stop_id,stop_sequence\nT1,10:00:00,10:00:00,S1,1\nT1,10:05:00,10:05:00,S2,2\n",
+	}
+	paths := []string{
+		write_gtfs_feed(t, filepath.Join(dir, "a.zip"), TEST_GTFS_FEED),
+		write_gtfs_feed(t, filepath.Join(dir, "b"), other),
+	}
+	stops, conns, schedules, routes, _, trips, stop_ids := ParseGtfs(paths, NewGTFSFilter(), nil, 0)
+
+	if stops.Length() != 6 {
+		t.Errorf("expected 6 stops, got %v", stops.Length())
+	}
+	if stop_ids["a:S1"] == stop_ids["b:S1"] || !stop_ids.ContainsKey("a:S1") || !stop_ids.ContainsKey("b:S1") {
+		t.Errorf("expected separate stops a:S1 and b:S1, got %v", stop_ids)
+	}
+	if loc := stops[stop_ids["b:S1"]].Loc; loc != (geo.Coord{9.0, 50.0}) {
+		t.Errorf("expected b:S1 at %v, got %v", geo.Coord{9.0, 50.0}, loc)
+	}
+	departures, trip_ids := get_departures(conns, schedules["monday"], trips, stop_ids["b:S1"], stop_ids["b:S2"])
+	if !slices.Equal(departures, []int32{36000}) || !slices.Equal(trip_ids, []string{"b:T1"}) {
+		t.Errorf("expected trip b:T1 at 36000, got %v at %v", trip_ids, departures)
+	}
+	route_ids := []string{}
+	for _, conn := range conns {
+		if conn.StopA == stop_ids["b:S1"] {
+			route_ids = append(route_ids, routes[conn.RouteID].ID)
+		}
+	}
+	if !slices.Equal(route_ids, []string{"b:R1"}) {
+		t.Errorf("expected route b:R1, got %v", route_ids)
+	}
+
+	// feeds of the same name are numbered
+	feeds := List[*GTFSFeed]{OpenGTFSFeed(paths[1]), OpenGTFSFeed(paths[1])}
+	if namespaces := _GetFeedNamespaces(feeds); !slices.Equal(namespaces, List[string]{"b:", "b-1:"}) {
+		t.Errorf("expected namespaces [b: b-1:], got %v", namespaces)
+	}
+}
+
+func TestGTFSFeed(t *testing.T) {
+	for _, name := range []string{"feed", "feed.zip"} {
+		feed := OpenGTFSFeed(write_gtfs_feed(t, filepath.Join(t.TempDir(), name), TEST_GTFS_FEED))
+		if feed.Name() != "feed" {
+			t.Errorf("%v: expected name feed, got %v", name, feed.Name())
+		}
+		if !feed.HasFile("stops.txt") || feed.HasFile("shapes.txt") {
+			t.Errorf("%v: expected stops.txt but no shapes.txt", name)
+		}
+		stop_ids := []string{}
+		for stop := range _ReadFeedCSV[GTFSStopEntry](feed, "stops.txt") {
+			stop_ids = append(stop_ids, stop.StopID)
+		}
+		if !slices.Equal(stop_ids, []string{"S1", "S2", "S3", "S4"}) {
+			t.Errorf("%v: expected stops [S1 S2 S3 S4], got %v", name, stop_ids)
+		}
+		feed.Close()
+	}
+}
+
+func TestParseGtfsFilter(t *testing.T) {
+	path := write_gtfs_feed(t, filepath.Join(t.TempDir(), "feed"), TEST_GTFS_FEED)
+	// S3 is outside of the filter
+	filter := NewGTFSFilter()
+	filter.ClipToBBox(geo.Envelope{7.99, 48.99, 8.015, 49.02})
+	_, conns, _, _, _, trips, stop_ids := ParseGtfs([]string{path}, filter, nil, 0)
+
+	if stop_ids.ContainsKey("S3") || stop_ids.Length() != 3 {
+		t.Errorf("expected the stops S1, S2 and S4, got %v", stop_ids)
+	}
+	for _, conn := range conns {
+		if conn.StopA == stop_ids["S2"] && conn.StopB == stop_ids["S4"] {
+			t.Errorf("expected the trip to be cut at the filter border")
+		}
+	}
+	for _, trip := range trips {
+		if trip.ID != "T1" {
+			continue
+		}
+		expected := []structs.TripStop{{Stop: stop_ids["S1"], Sequence: 1}, {Stop: stop_ids["S2"], Sequence: 2}, {Stop: stop_ids["S4"], Sequence: 4}}
+		if !slices.Equal(trip.Stops, expected) {
+			t.Errorf("expected trip stops %v, got %v", expected, trip.Stops)
+		}
+	}
+}
+
+func TestGTFSFilter(t *testing.T) {
+	polygons := `{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "properties": {}, "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [2, 0], [2, 2], [0, 2], [0, 0]]]}},
+		{"type": "Feature", "properties": {}, "geometry": {"type": "Point", "coordinates": [5, 5]}},
+		{"type": "Feature", "properties": {}, "geometry": {"type": "Polygon", "coordinates": [[[4, 4], [6, 4], [6, 6], [4, 6], [4, 4]]]}}
+	]}`
+	file := filepath.Join(t.TempDir(), "filter.geojson")
+	os.WriteFile(file, []byte(polygons), 0644)
+
+	tests := []struct {
+		name     string
+		bboxes   []geo.Envelope
+		polygons bool
+		expected map[geo.Coord]bool
+	}{
+		{"empty", nil, false, map[geo.Coord]bool{{1, 1}: true, {10, 10}: true}},
+		{"bbox", []geo.Envelope{{0, 0, 3, 3}}, false, map[geo.Coord]bool{{1, 1}: true, {4, 1}: false}},
+		{"intersected bboxes", []geo.Envelope{{0, 0, 3, 3}, {2, 2, 5, 5}}, false, map[geo.Coord]bool{{1, 1}: false, {2.5, 2.5}: true, {4, 4}: false}},
+		{"polygons", nil, true, map[geo.Coord]bool{{1, 1}: true, {5, 5}: true, {3, 3}: false}},
+		{"bbox and polygons", []geo.Envelope{{0, 0, 3, 3}}, true, map[geo.Coord]bool{{1, 1}: true, {5, 5}: false}},
+	}
+	for _, tt := range tests {
+		filter := NewGTFSFilter()
+		for _, bbox := range tt.bboxes {
+			filter.ClipToBBox(bbox)
+		}
+		if tt.polygons {
+			filter.ReadPolygons(file)
+		}
+		for coord, expected := range tt.expected {
+			if contains := filter.Contains(coord); contains != expected {
+				t.Errorf("%v: expected %v for %v, got %v", tt.name, expected, coord, contains)
+			}
+		}
+	}
+}
+
+func TestParseGTFSTime(t *testing.T) {
+	tests := []struct {
+		time     string
+		expected int
+		valid    bool
+	}{
+		{"08:05:30", 29130, true},
+		{" 8:05:30", 29130, true},
+		{"25:00:00", 90000, true},
+		{"08:05", 0, false},
+		{"08:60:00", 0, false},
+		{"8h05:00", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		value, valid := ParseGTFSTime(tt.time)
+		if valid != tt.valid || value != tt.expected {
+			t.Errorf("%q: expected %v (valid %v), got %v (valid %v)", tt.time, tt.expected, tt.valid, value, valid)
+		}
+	}
+}
+
+func TestParseGtfsInvalidTimes(t *testing.T) {
+	files := map[string]string{}
+	for name, content := range TEST_GTFS_FEED {
+		files[name] = content
+	}
+	// the invalid stop-time of T2 is skipped, the invalid frequency of F1 is dropped
+	files["stop_times.txt"] += "T2,09:1O:00,09:10:00,S3,3\n"
+	files["frequencies.txt"] += "F1,07:00,08:00:00,1800\n"
+	path := write_gtfs_feed(t, filepath.Join(t.TempDir(), "feed"), files)
+	dates := []time.Time{time.Date(2024, 5, 8, 0, 0, 0, 0, time.UTC)}
+	_, conns, schedules, _, _, trips, stop_ids := ParseGtfs([]string{path}, NewGTFSFilter(), dates, 0)
+
+	departures, _ := get_departures(conns, schedules["2024-05-08"], trips, stop_ids["S1"], stop_ids["S2"])
+	if expected := []int32{21600, 23400, 28800, 32400}; !slices.Equal(departures, expected) {
+		t.Errorf("expected departures %v, got %v", expected, departures)
+	}
+	for _, trip := range trips {
+		if trip.ID == "T2" && len(trip.Stops) != 2 {
+			t.Errorf("expected T2 to contain 2 stops, got %v", len(trip.Stops))
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package parser
+
+import (
+	"google.golang.org/protobuf/encoding/protowire"
+
+	. "github.com/ttpr0/go-routing/util"
+)
+
+//*******************************************
+// gtfs-realtime parser
+//*******************************************
+
+// Trip-update of a GTFS-Realtime feed.
+type GTFSTripUpdate struct {
+	TripID string
+	// scheduled start-time ("HH:MM:SS") of frequency-based trips
+	StartTime string
+	// service-date ("YYYYMMDD") of the trip
+	StartDate string
+	// schedule_relationship of the trip (0: scheduled, 1: added, 2: unscheduled, 3: canceled, 7: deleted)
+	Relationship int32
+	// stop-time updates ordered by stop-sequence
+	StopTimeUpdates List[GTFSStopTimeUpdate]
+}
+
+type GTFSStopTimeUpdate struct {
+	// GTFS stop_sequence or -1 if not set
+	StopSequence int32
+	StopID       string
+	Arrival      Optional[GTFSStopTimeEvent]
+	Departure    Optional[GTFSStopTimeEvent]
+	// schedule_relationship of the stop (0: scheduled, 1: skipped, 2: no data)
+	Relationship int32
+}
+
+// Predicted arrival or departure given either by a delay (in s) or an absolute POSIX-time.
+type GTFSStopTimeEvent struct {
+	Delay Optional[int32]
+	Time  Optional[int64]
+}
+
+// Decodes the trip-updates of a GTFS-Realtime FeedMessage, all other entities (vehicle-positions, alerts) are skipped.
+func ParseTripUpdates(data []byte) (List[GTFSTripUpdate], error) {
+	updates := NewList[GTFSTripUpdate](100)
+	err := _ReadProtoMessage(data, func(num protowire.Number, typ protowire.Type, value uint64, bytes []byte) error {
+		// FeedMessage.entity
+		if num != 2 || typ != protowire.BytesType {
+			return nil
+		}
+		deleted := false
+		var update Optional[GTFSTripUpdate]
+		err := _ReadProtoMessage(bytes, func(num protowire.Number, typ protowire.Type, value uint64, bytes []byte) error {
+			switch {
+			// FeedEntity.is_deleted
+			case num == 2 && typ == protowire.VarintType:
+				deleted = value != 0
+			// FeedEntity.trip_update
+			case num == 3 && typ == protowire.BytesType:
+				trip_update, err := _ParseTripUpdate(bytes)
+				if err != nil {
+					return err
+				}
+				update = Some(trip_update)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if update.HasValue() && !deleted {
+			updates.Add(update.Value)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updates, nil
+}
+
+func _ParseTripUpdate(data []byte) (GTFSTripUpdate, error) {
+	update := GTFSTripUpdate{
+		StopTimeUpdates: NewList[GTFSStopTimeUpdate](10),
+	}
+	err := _ReadProtoMessage(data, func(num protowire.Number, typ protowire.Type, value uint64, bytes []byte) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		switch num {
+		// TripUpdate.trip
+		case 1:
+			return _ReadProtoMessage(bytes, func(num protowire.Number, typ protowire.Type, value uint64, bytes []byte) error {
+				switch {
+				case num == 1 && typ == protowire.BytesType:
+					update.TripID = string(bytes)
+				case num == 2 && typ == protowire.BytesType:
+					update.StartTime = string(bytes)
+				case num == 3 && typ == protowire.BytesType:
+					update.StartDate = string(bytes)
+				case num == 4 && typ == protowire.VarintType:
+					update.Relationship = int32(value)
+				}
+				return nil
+			})
+		// TripUpdate.stop_time_update
+		case 2:
+			stop_update, err := _ParseStopTimeUpdate(bytes)
+			if err != nil {
+				return err
+			}
+			update.StopTimeUpdates.Add(stop_update)
+		}
+		return nil
+	})
+	return update, err
+}
+
+func _ParseStopTimeUpdate(data []byte) (GTFSStopTimeUpdate, error) {
+	update := GTFSStopTimeUpdate{
+		StopSequence: -1,
+		Arrival:      None[GTFSStopTimeEvent](),
+		Departure:    None[GTFSStopTimeEvent](),
+	}
+	err := _ReadProtoMessage(data, func(num protowire.Number, typ protowire.Type, value uint64, bytes []byte) error {
+		switch {
+		case num == 1 && typ == protowire.VarintType:
+			update.StopSequence = int32(value)
+		case num == 2 && typ == protowire.BytesType:
+			event, err := _ParseStopTimeEvent(bytes)
+			if err != nil {
+				return err
+			}
+			update.Arrival = Some(event)
+		case num == 3 && typ == protowire.BytesType:
+			event, err := _ParseStopTimeEvent(bytes)
+			if err != nil {
+				return err
+			}
+			update.Departure = Some(event)
+		case num == 4 && typ == protowire.BytesType:
+			update.StopID = string(bytes)
+		case num == 5 && typ == protowire.VarintType:
+			update.Relationship = int32(value)
+		}
+		return nil
+	})
+	return update, err
+}
+
+func _ParseStopTimeEvent(data []byte) (GTFSStopTimeEvent, error) {
+	event := GTFSStopTimeEvent{
+		Delay: None[int32](),
+		Time:  None[int64](),
+	}
+	err := _ReadProtoMessage(data, func(num protowire.Number, typ protowire.Type, value uint64, bytes []byte) error {
+		if typ != protowire.VarintType {
+			return nil
+		}
+		switch num {
+		case 1:
+			event.Delay = Some(int32(value))
+		case 2:
+			event.Time = Some(int64(value))
+		}
+		return nil
+	})
+	return event, err
+}
+
+// Calls handle with every varint and length-delimited field of the protobuf message, other fields are skipped.
+func _ReadProtoMessage(data []byte, handle func(num protowire.Number, typ protowire.Type, value uint64, bytes []byte) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+		var value uint64
+		var bytes []byte
+		switch typ {
+		case protowire.VarintType:
+			value, n = protowire.ConsumeVarint(data)
+		case protowire.BytesType:
+			bytes, n = protowire.ConsumeBytes(data)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+		if typ != protowire.VarintType && typ != protowire.BytesType {
+			continue
+		}
+		if err := handle(num, typ, value, bytes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
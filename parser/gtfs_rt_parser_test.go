@@ -0,0 +1,85 @@
+package parser
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func append_varint(b []byte, num protowire.Number, value int64) []byte {
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(value))
+}
+func append_bytes(b []byte, num protowire.Number, value []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, value)
+}
+
+// Encodes a FeedEntity containing a trip-update with one stop-time update (arrival delay) at the stop-sequence.
+func encode_trip_update(trip_id string, relationship int64, deleted bool, stop_sequence int64, delay int64) []byte {
+	var trip []byte
+	trip = append_bytes(trip, 1, []byte(trip_id))
+	trip = append_bytes(trip, 3, []byte("20240506"))
+	trip = append_varint(trip, 4, relationship)
+	var event []byte
+	event = append_varint(event, 1, delay)
+	var stop_update []byte
+	stop_update = append_varint(stop_update, 1, stop_sequence)
+	stop_update = append_bytes(stop_update, 2, event)
+	stop_update = append_bytes(stop_update, 4, []byte("B"))
+	var trip_update []byte
+	trip_update = append_bytes(trip_update, 1, trip)
+	trip_update = append_bytes(trip_update, 2, stop_update)
+	// timestamp (fixed64) is skipped
+	trip_update = protowire.AppendTag(trip_update, 4, protowire.Fixed64Type)
+	trip_update = protowire.AppendFixed64(trip_update, 1715000000)
+	var entity []byte
+	entity = append_bytes(entity, 1, []byte(trip_id))
+	if deleted {
+		entity = append_varint(entity, 2, 1)
+	}
+	entity = append_bytes(entity, 3, trip_update)
+	return entity
+}
+
+func TestParseTripUpdates(t *testing.T) {
+	var header []byte
+	header = append_bytes(header, 1, []byte("2.0"))
+	var feed []byte
+	feed = append_bytes(feed, 1, header)
+	feed = append_bytes(feed, 2, encode_trip_update("T1", 0, false, 2, -30))
+	feed = append_bytes(feed, 2, encode_trip_update("T2", 3, false, 2, 0))
+	feed = append_bytes(feed, 2, encode_trip_update("T3", 0, true, 2, 60))
+
+	updates, err := ParseTripUpdates(feed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updates.Length() != 2 {
+		t.Fatalf("expected 2 updates (deleted entity skipped), got %v", updates.Length())
+	}
+	delayed := updates[0]
+	if delayed.TripID != "T1" || delayed.StartDate != "20240506" || delayed.Relationship != 0 {
+		t.Errorf("unexpected trip descriptor %+v", delayed)
+	}
+	if delayed.StopTimeUpdates.Length() != 1 {
+		t.Fatalf("expected 1 stop-time update, got %v", delayed.StopTimeUpdates.Length())
+	}
+	stop_update := delayed.StopTimeUpdates[0]
+	if stop_update.StopSequence != 2 || stop_update.StopID != "B" {
+		t.Errorf("expected stop-sequence 2 at B, got %v at %v", stop_update.StopSequence, stop_update.StopID)
+	}
+	if stop_update.Departure.HasValue() {
+		t.Errorf("expected no departure")
+	}
+	if !stop_update.Arrival.HasValue() || stop_update.Arrival.Value.Delay.Value != -30 || stop_update.Arrival.Value.Time.HasValue() {
+		t.Errorf("expected an arrival delay of -30, got %+v", stop_update.Arrival)
+	}
+	if canceled := updates[1]; canceled.TripID != "T2" || canceled.Relationship != 3 {
+		t.Errorf("expected canceled trip T2, got %v with relationship %v", canceled.TripID, canceled.Relationship)
+	}
+
+	if _, err := ParseTripUpdates(feed[:len(feed)-3]); err == nil {
+		t.Errorf("expected an error for a truncated feed")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"slices"
 
 	"github.com/paulmach/osm"
 	"github.com/paulmach/osm/osmpbf"
@@ -20,13 +21,16 @@ func ParseGraph(pbf_file string, decoder IOSMDecoder) (*comps.GraphBase, *attr.G
 	nodes := NewList[OSMNode](10000)
 	edges := NewList[OSMEdge](10000)
 	index_mapping := NewDict[int64, int](10000)
-	_ParseOsm(pbf_file, decoder, &nodes, &edges, &index_mapping)
-	print("edges: ", edges.Length(), ", nodes: ", nodes.Length())
-	base, attr := _CreateGraphBase(&nodes, &edges)
+	restrictions := NewList[OSMRestriction](100)
+	_ParseOsm(pbf_file, decoder, &nodes, &edges, &index_mapping, &restrictions)
+	rules := _ResolveRestrictions(&nodes, &edges, &index_mapping, restrictions)
+	slog.Info(fmt.Sprintf("parsed %v edges, %v nodes and %v restrictions", edges.Length(), nodes.Length(), restrictions.Length()))
+	base, attr, edge_mapping := _CreateGraphBase(&nodes, &edges)
+	attr.SetTurnRestrictions(_CreateTurnRestrictions(base, edge_mapping, rules))
 	return base, attr
 }
 
-func _ParseOsm(filename string, decoder IOSMDecoder, nodes *List[OSMNode], edges *List[OSMEdge], index_mapping *Dict[int64, int]) {
+func _ParseOsm(filename string, decoder IOSMDecoder, nodes *List[OSMNode], edges *List[OSMEdge], index_mapping *Dict[int64, int], restrictions *List[OSMRestriction]) {
 	osm_nodes := NewDict[int64, TempNode](1000)
 
 	file, err := os.Open(filename)
@@ -46,6 +50,10 @@ func _ParseOsm(filename string, decoder IOSMDecoder, nodes *List[OSMNode], edges
 	scanner = osmpbf.New(context.Background(), file, runtime.GOMAXPROCS(-1))
 	_WayHandler(scanner, decoder, edges, &osm_nodes, index_mapping)
 	scanner.Close()
+	file.Seek(0, 0)
+	scanner = osmpbf.New(context.Background(), file, runtime.GOMAXPROCS(-1))
+	_RelationHandler(scanner, decoder, restrictions)
+	scanner.Close()
 	for i := 0; i < edges.Length(); i++ {
 		e := edges.Get(i)
 		node_a := nodes.Get(e.NodeA)
@@ -57,25 +65,39 @@ func _ParseOsm(filename string, decoder IOSMDecoder, nodes *List[OSMNode], edges
 	}
 }
 
-func _CreateGraphBase(osmnodes *List[OSMNode], osmedges *List[OSMEdge]) (*comps.GraphBase, *attr.GraphAttributes) {
+// Creates the graph from the osm-nodes and -edges.
+//
+// Also returns the graph-edges every osm-edge has been mapped to (forward and backward, -1 if oneway).
+func _CreateGraphBase(osmnodes *List[OSMNode], osmedges *List[OSMEdge]) (*comps.GraphBase, *attr.GraphAttributes, Array[[2]int32]) {
 	nodes := NewList[structs.Node](osmnodes.Length())
 	edges := NewList[structs.Edge](osmedges.Length() * 2)
 	node_attrs := NewList[attr.NodeAttribs](osmnodes.Length())
 	edge_attrs := NewList[attr.EdgeAttribs](osmedges.Length() * 2)
+	node_details := NewList[attr.NodeDetails](osmnodes.Length())
+	edge_details := NewList[attr.EdgeDetails](osmedges.Length() * 2)
 	node_geoms := NewList[geo.Coord](osmnodes.Length())
 	edge_geoms := NewList[geo.CoordArray](osmedges.Length() * 2)
 
-	edge_index_mapping := NewDict[int, int](osmedges.Length())
+	edge_index_mapping := NewArray[[2]int32](osmedges.Length())
 	for i, osmedge := range *osmedges {
 		edge := structs.Edge{
 			NodeA: int32(osmedge.NodeA),
 			NodeB: int32(osmedge.NodeB),
 		}
 		edge_attr := osmedge.Attr
+		details := osmedge.Details
+		// ascent and descent from the elevation of the nodes
+		ele_a := osmnodes.Get(osmedge.NodeA).Details.Elevation
+		ele_b := osmnodes.Get(osmedge.NodeB).Details.Elevation
+		if ele_a != attr.NO_ELEVATION && ele_b != attr.NO_ELEVATION {
+			details.Ascent = max(ele_b-ele_a, 0)
+			details.Descent = max(ele_a-ele_b, 0)
+		}
 		edges.Add(edge)
 		edge_attrs.Add(edge_attr)
+		edge_details.Add(details)
 		edge_geoms.Add(geo.CoordArray(osmedge.Nodes))
-		edge_index_mapping[i] = edges.Length() - 1
+		edge_index_mapping[i] = [2]int32{int32(edges.Length() - 1), -1}
 		if !osmedge.Attr.Oneway {
 			edge = structs.Edge{
 				NodeA: int32(osmedge.NodeB),
@@ -84,7 +106,9 @@ func _CreateGraphBase(osmnodes *List[OSMNode], osmedges *List[OSMEdge]) (*comps.
 			edge_attr = osmedge.Attr
 			edges.Add(edge)
 			edge_attrs.Add(edge_attr)
+			edge_details.Add(details.Reversed())
 			edge_geoms.Add(geo.CoordArray(osmedge.Nodes))
+			edge_index_mapping[i][1] = int32(edges.Length() - 1)
 		}
 	}
 
@@ -95,12 +119,13 @@ func _CreateGraphBase(osmnodes *List[OSMNode], osmedges *List[OSMEdge]) (*comps.
 		node_attr := osmnode.Attr
 		nodes.Add(node)
 		node_attrs.Add(node_attr)
+		node_details.Add(osmnode.Details)
 		node_geoms.Add(osmnode.Point)
 	}
 
 	base := comps.NewGraphBase(Array[structs.Node](nodes), Array[structs.Edge](edges))
-	attr := attr.New(Array[attr.NodeAttribs](node_attrs), Array[attr.EdgeAttribs](edge_attrs), Array[geo.Coord](node_geoms), Array[geo.CoordArray](edge_geoms))
-	return base, attr
+	attr := attr.New(Array[attr.NodeAttribs](node_attrs), Array[attr.EdgeAttribs](edge_attrs), Array[attr.NodeDetails](node_details), Array[attr.EdgeDetails](edge_details), Array[geo.Coord](node_geoms), Array[geo.CoordArray](edge_geoms))
+	return base, attr, edge_index_mapping
 }
 
 //*******************************************
@@ -114,7 +139,7 @@ func _InitWayHandler(scanner *osmpbf.Scanner, decoder IOSMDecoder, osm_nodes *Di
 		switch object := scanner.Object().(type) {
 		case *osm.Way:
 			tags := Dict[string, string](object.TagMap())
-			if !decoder.IsValidHighway(tags) {
+			if !_IsValidWay(decoder, tags) {
 				continue
 			}
 			nodes := object.Nodes.NodeIDs()
@@ -160,9 +185,14 @@ func _NodeHandler(scanner *osmpbf.Scanner, decoder IOSMDecoder, osm_nodes *Dict[
 				slog.Debug(fmt.Sprintf("%v", c))
 			}
 			on := osm_nodes.Get(id)
+			node_details := decoder.DecodeNodeDetails(tags)
+			// traffic-signals and barriers are kept as graph-nodes
+			if node_details.TrafficSignals || node_details.Barrier != 0 {
+				on.Count = max(on.Count, 2)
+			}
 			if on.Count > 1 {
 				node_attr := decoder.DecodeNode(tags)
-				node := OSMNode{geo.Coord{float32(object.Lon), float32(object.Lat)}, node_attr, NewList[int32](3)}
+				node := OSMNode{geo.Coord{float32(object.Lon), float32(object.Lat)}, node_attr, node_details, NewList[int32](3)}
 				nodes.Add(node)
 				index_mapping.Set(id, i)
 				i += 1
@@ -184,9 +214,11 @@ func _WayHandler(scanner *osmpbf.Scanner, decoder IOSMDecoder, edges *List[OSMEd
 		switch object := scanner.Object().(type) {
 		case *osm.Way:
 			tags := Dict[string, string](object.TagMap())
-			if !decoder.IsValidHighway(tags) {
+			if !_IsValidWay(decoder, tags) {
 				continue
 			}
+			forward, backward := decoder.DecodeDirection(tags)
+			details := decoder.DecodeEdgeDetails(tags)
 			c += 1
 			if c%1000 == 0 {
 				slog.Debug(fmt.Sprintf("%v", c))
@@ -204,9 +236,18 @@ func _WayHandler(scanner *osmpbf.Scanner, decoder IOSMDecoder, edges *List[OSMEd
 				e.Nodes.Add(on.Point)
 				if on.Count > 1 && curr != start {
 					edge_att := decoder.DecodeEdge(tags)
+					edge_att.Oneway = !(forward && backward)
 					e.NodeA = index_mapping.Get(start)
 					e.NodeB = index_mapping.Get(curr)
 					e.Attr = edge_att
+					e.Details = details
+					e.Way = int64(object.ID)
+					// ways only accessible against their direction are stored reversed
+					if !forward {
+						e.NodeA, e.NodeB = e.NodeB, e.NodeA
+						e.Details = details.Reversed()
+						slices.Reverse(e.Nodes)
+					}
 					edges.Add(e)
 					start = curr
 					e = OSMEdge{}
@@ -224,6 +265,53 @@ func _WayHandler(scanner *osmpbf.Scanner, decoder IOSMDecoder, edges *List[OSMEd
 	}
 }
 
+func _RelationHandler(scanner *osmpbf.Scanner, decoder IOSMDecoder, restrictions *List[OSMRestriction]) {
+	scanner.SkipNodes = true
+	scanner.SkipWays = true
+	for scanner.Scan() {
+		switch object := scanner.Object().(type) {
+		case *osm.Relation:
+			tags := Dict[string, string](object.TagMap())
+			typ := decoder.DecodeRestriction(tags)
+			if !typ.HasValue() {
+				continue
+			}
+			r := OSMRestriction{Type: typ.Value}
+			via_nodes := 0
+			for _, member := range object.Members {
+				switch member.Role {
+				case "from":
+					if member.Type == osm.TypeWay {
+						r.From.Add(member.Ref)
+					}
+				case "to":
+					if member.Type == osm.TypeWay {
+						r.To.Add(member.Ref)
+					}
+				case "via":
+					if member.Type == osm.TypeNode {
+						via_nodes += 1
+					}
+					if member.Type == osm.TypeWay {
+						r.ViaWay = true
+					}
+					r.Via.Add(member.Ref)
+				}
+			}
+			if r.From.Length() == 0 || r.Via.Length() == 0 || r.To.Length() == 0 {
+				continue
+			}
+			// restrictions via multiple nodes or nodes and ways at the same time are invalid
+			if (r.ViaWay && via_nodes > 0) || via_nodes > 1 {
+				continue
+			}
+			restrictions.Add(r)
+		default:
+			continue
+		}
+	}
+}
+
 //*******************************************
 // osm decoder
 //*******************************************
@@ -232,4 +320,20 @@ type IOSMDecoder interface {
 	IsValidHighway(tags Dict[string, string]) bool
 	DecodeNode(tags Dict[string, string]) attr.NodeAttribs
 	DecodeEdge(tags Dict[string, string]) attr.EdgeAttribs
+	DecodeNodeDetails(tags Dict[string, string]) attr.NodeDetails
+	// Returns the details of the way in the direction of its nodes.
+	DecodeEdgeDetails(tags Dict[string, string]) attr.EdgeDetails
+	// Returns if the way is accessible in (forward) and against (backward) the direction of its nodes.
+	DecodeDirection(tags Dict[string, string]) (bool, bool)
+	// Returns the type of the turn-restriction relation (e.g. "no_left_turn"), None if it doesn't apply to the vehicle.
+	DecodeRestriction(tags Dict[string, string]) Optional[string]
+}
+
+// Checks if the way is valid and accessible in at least one direction.
+func _IsValidWay(decoder IOSMDecoder, tags Dict[string, string]) bool {
+	if !decoder.IsValidHighway(tags) {
+		return false
+	}
+	forward, backward := decoder.DecodeDirection(tags)
+	return forward || backward
 }
@@ -0,0 +1,306 @@
+package parser
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ttpr0/go-routing/comps"
+	"github.com/ttpr0/go-routing/structs"
+	. "github.com/ttpr0/go-routing/util"
+)
+
+//*******************************************
+// turn restrictions
+//*******************************************
+
+// Traversal of an osm-edge, reversed edges are traversed from NodeB to NodeA.
+type _EdgeDir struct {
+	Edge     int32
+	Reversed bool
+}
+
+const (
+	// turn from From into To is forbidden
+	_FORBID_TURN = 0
+	// From can only be left into To
+	_ONLY_TURN = 1
+	// To can only be entered from From
+	_ONLY_ENTRY = 2
+)
+
+// Turn-rule between two traversed osm-edges at node Via.
+type _TurnRule struct {
+	From _EdgeDir
+	Via  int32
+	To   _EdgeDir
+	Type int
+}
+
+// Resolves the turn-restriction relations into turn-rules between osm-edges.
+//
+// Restrictions via ways are resolved by adding oneway copies of the via-edges only enterable from the from-edge,
+// the turn into the to-edge is then restricted at the end of the copies.
+func _ResolveRestrictions(nodes *List[OSMNode], edges *List[OSMEdge], index_mapping *Dict[int64, int], restrictions List[OSMRestriction]) List[_TurnRule] {
+	way_edges := NewDict[int64, List[int32]](restrictions.Length())
+	for _, r := range restrictions {
+		for _, ways := range []List[int64]{r.From, r.To} {
+			for _, way := range ways {
+				way_edges[way] = nil
+			}
+		}
+		if r.ViaWay {
+			for _, way := range r.Via {
+				way_edges[way] = nil
+			}
+		}
+	}
+	for i, e := range *edges {
+		if ids, ok := way_edges[e.Way]; ok {
+			way_edges[e.Way] = append(ids, int32(i))
+		}
+	}
+
+	rules := NewList[_TurnRule](restrictions.Length() * 2)
+	// copies of the via-edges by from-edge and via-path
+	path_copies := NewDict[string, []_EdgeDir](10)
+	edge_copies := NewDict[int32, List[int32]](10)
+	for _, r := range restrictions {
+		if !r.ViaWay {
+			continue
+		}
+		only := strings.HasPrefix(r.Type, "only_")
+		via_edges := NewDict[int32, bool](10)
+		for _, way := range r.Via {
+			for _, e := range way_edges[way] {
+				via_edges[e] = true
+			}
+		}
+		to_dirs := NewDict[int, List[_EdgeDir]](10)
+		for _, way := range r.To {
+			for _, e := range way_edges[way] {
+				for _, t := range _GetEdgeDirs(edges, e) {
+					start := _GetDirStart(edges, t)
+					to_dirs[start] = append(to_dirs[start], t)
+				}
+			}
+		}
+		for _, way := range r.From {
+			for _, e := range way_edges[way] {
+				if via_edges.ContainsKey(e) {
+					continue
+				}
+				for _, f := range _GetEdgeDirs(edges, e) {
+					a := _GetDirEnd(edges, f)
+					for _, p := range _FindViaPaths(nodes, edges, via_edges, to_dirs, a) {
+						path, t := p.A, p.B
+						key := fmt.Sprint(only, f, path)
+						copies, ok := path_copies[key]
+						if !ok {
+							copies = _CopyViaPath(nodes, edges, path)
+							path_copies[key] = copies
+							for i, c := range copies {
+								edge_copies[path[i].Edge] = append(edge_copies[path[i].Edge], c.Edge)
+							}
+							prev := f
+							for i := range path {
+								via := int32(_GetDirStart(edges, path[i]))
+								if only {
+									rules.Add(_TurnRule{From: prev, Via: via, To: copies[i], Type: _ONLY_TURN})
+								} else {
+									rules.Add(_TurnRule{From: prev, Via: via, To: path[i], Type: _FORBID_TURN})
+								}
+								rules.Add(_TurnRule{From: prev, Via: via, To: copies[i], Type: _ONLY_ENTRY})
+								prev = copies[i]
+							}
+						}
+						last := copies[len(copies)-1]
+						typ := _FORBID_TURN
+						if only {
+							typ = _ONLY_TURN
+						}
+						rules.Add(_TurnRule{From: last, Via: int32(_GetDirEnd(edges, last)), To: t, Type: typ})
+					}
+				}
+			}
+		}
+	}
+	for _, r := range restrictions {
+		if r.ViaWay || !index_mapping.ContainsKey(r.Via[0]) {
+			continue
+		}
+		typ := _FORBID_TURN
+		if strings.HasPrefix(r.Type, "only_") {
+			typ = _ONLY_TURN
+		}
+		via := index_mapping.Get(r.Via[0])
+		for _, from_way := range r.From {
+			for _, to_way := range r.To {
+				for _, f := range way_edges[from_way] {
+					for _, t := range way_edges[to_way] {
+						// u-turns on the same way are only restricted onto the same edge
+						if from_way == to_way && f != t {
+							continue
+						}
+						for _, f_dir := range _GetEdgeDirs(edges, f) {
+							if _GetDirEnd(edges, f_dir) != via {
+								continue
+							}
+							for _, t_dir := range _GetEdgeDirs(edges, t) {
+								if _GetDirStart(edges, t_dir) != via {
+									continue
+								}
+								rules.Add(_TurnRule{From: f_dir, Via: int32(via), To: t_dir, Type: typ})
+								// copies of the from-edge (added by restrictions via ways) are restricted the same way
+								for _, c := range edge_copies[f] {
+									if _GetDirEnd(edges, _EdgeDir{Edge: c}) == via {
+										rules.Add(_TurnRule{From: _EdgeDir{Edge: c}, Via: int32(via), To: t_dir, Type: typ})
+									}
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return rules
+}
+
+// Returns all paths along the via-edges from the node to the start of any to-edge.
+func _FindViaPaths(nodes *List[OSMNode], edges *List[OSMEdge], via_edges Dict[int32, bool], to_dirs Dict[int, List[_EdgeDir]], node int) List[Tuple[[]_EdgeDir, _EdgeDir]] {
+	paths := NewList[Tuple[[]_EdgeDir, _EdgeDir]](1)
+	path := NewList[_EdgeDir](4)
+	visited := NewDict[int, bool](4)
+	visited[node] = true
+	var search func(curr int)
+	search = func(curr int) {
+		if path.Length() > 0 {
+			for _, t := range to_dirs[curr] {
+				paths.Add(MakeTuple([]_EdgeDir(slices.Clone(path)), t))
+			}
+		}
+		for _, e := range nodes.Get(curr).Edges {
+			if !via_edges.ContainsKey(e) {
+				continue
+			}
+			for _, d := range _GetEdgeDirs(edges, e) {
+				next := _GetDirEnd(edges, d)
+				if _GetDirStart(edges, d) != curr || visited.ContainsKey(next) {
+					continue
+				}
+				visited[next] = true
+				path.Add(d)
+				search(next)
+				path.Remove(path.Length() - 1)
+				visited.Delete(next)
+			}
+		}
+	}
+	search(node)
+	return paths
+}
+
+// Adds oneway copies of the edges along the path.
+func _CopyViaPath(nodes *List[OSMNode], edges *List[OSMEdge], path []_EdgeDir) []_EdgeDir {
+	copies := make([]_EdgeDir, len(path))
+	for i, d := range path {
+		e := edges.Get(int(d.Edge))
+		c := OSMEdge{
+			NodeA:   e.NodeA,
+			NodeB:   e.NodeB,
+			Attr:    e.Attr,
+			Details: e.Details,
+			Nodes:   e.Nodes.Copy(),
+			Way:     e.Way,
+		}
+		if d.Reversed {
+			c.NodeA, c.NodeB = c.NodeB, c.NodeA
+			c.Details = c.Details.Reversed()
+			slices.Reverse(c.Nodes)
+		}
+		c.Attr.Oneway = true
+		edges.Add(c)
+		id := int32(edges.Length() - 1)
+		node_a := nodes.Get(c.NodeA)
+		node_a.Edges.Add(id)
+		nodes.Set(c.NodeA, node_a)
+		node_b := nodes.Get(c.NodeB)
+		node_b.Edges.Add(id)
+		nodes.Set(c.NodeB, node_b)
+		copies[i] = _EdgeDir{Edge: id}
+	}
+	return copies
+}
+
+// Returns the directions the edge can be traversed in.
+func _GetEdgeDirs(edges *List[OSMEdge], edge int32) []_EdgeDir {
+	if edges.Get(int(edge)).Attr.Oneway {
+		return []_EdgeDir{{Edge: edge}}
+	}
+	return []_EdgeDir{{Edge: edge}, {Edge: edge, Reversed: true}}
+}
+func _GetDirStart(edges *List[OSMEdge], d _EdgeDir) int {
+	e := edges.Get(int(d.Edge))
+	if d.Reversed {
+		return e.NodeB
+	}
+	return e.NodeA
+}
+func _GetDirEnd(edges *List[OSMEdge], d _EdgeDir) int {
+	e := edges.Get(int(d.Edge))
+	if d.Reversed {
+		return e.NodeA
+	}
+	return e.NodeB
+}
+
+// Expands the turn-rules into the forbidden turns between graph-edges.
+//
+// edge_mapping contains the forward and backward graph-edge of every osm-edge.
+func _CreateTurnRestrictions(base *comps.GraphBase, edge_mapping Array[[2]int32], rules List[_TurnRule]) Array[structs.TurnRestriction] {
+	restrictions := NewList[structs.TurnRestriction](rules.Length())
+	visited := NewDict[structs.TurnRestriction, bool](rules.Length())
+	add := func(r structs.TurnRestriction) {
+		if visited.ContainsKey(r) {
+			return
+		}
+		visited[r] = true
+		restrictions.Add(r)
+	}
+	get_edge := func(d _EdgeDir) int32 {
+		if d.Reversed {
+			return edge_mapping[d.Edge][1]
+		}
+		return edge_mapping[d.Edge][0]
+	}
+	accessor := base.GetAccessor()
+	for _, rule := range rules {
+		from := get_edge(rule.From)
+		to := get_edge(rule.To)
+		if from == -1 || to == -1 {
+			continue
+		}
+		switch rule.Type {
+		case _FORBID_TURN:
+			add(structs.TurnRestriction{From: from, Via: rule.Via, To: to})
+		case _ONLY_TURN:
+			accessor.SetBaseNode(rule.Via, true)
+			for accessor.Next() {
+				edge := accessor.GetEdgeID()
+				if edge != to {
+					add(structs.TurnRestriction{From: from, Via: rule.Via, To: edge})
+				}
+			}
+		case _ONLY_ENTRY:
+			accessor.SetBaseNode(rule.Via, false)
+			for accessor.Next() {
+				edge := accessor.GetEdgeID()
+				if edge != from {
+					add(structs.TurnRestriction{From: edge, Via: rule.Via, To: to})
+				}
+			}
+		}
+	}
+	return Array[structs.TurnRestriction](restrictions)
+}
@@ -0,0 +1,193 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/ttpr0/go-routing/comps"
+	"github.com/ttpr0/go-routing/geo"
+	"github.com/ttpr0/go-routing/structs"
+	. "github.com/ttpr0/go-routing/util"
+)
+
+// Creates the graph of the ways (osm-id = index) between the nodes (osm-id = index) and applies the restrictions.
+func create_restricted_graph(node_count int, ways [][2]int, restrictions List[OSMRestriction]) (*comps.GraphBase, Array[structs.TurnRestriction]) {
+	nodes := NewList[OSMNode](node_count)
+	index_mapping := NewDict[int64, int](node_count)
+	for i := 0; i < node_count; i++ {
+		nodes.Add(OSMNode{Point: geo.Coord{float32(i) * 0.001, 0}, Edges: NewList[int32](4)})
+		index_mapping[int64(i)] = i
+	}
+	edges := NewList[OSMEdge](len(ways))
+	for i, way := range ways {
+		e := OSMEdge{NodeA: way[0], NodeB: way[1], Way: int64(i)}
+		e.Nodes.Add(nodes[way[0]].Point)
+		e.Nodes.Add(nodes[way[1]].Point)
+		edges.Add(e)
+		nodes[way[0]].Edges.Add(int32(i))
+		nodes[way[1]].Edges.Add(int32(i))
+	}
+	rules := _ResolveRestrictions(&nodes, &edges, &index_mapping, restrictions)
+	base, _, edge_mapping := _CreateGraphBase(&nodes, &edges)
+	return base, _CreateTurnRestrictions(base, edge_mapping, rules)
+}
+
+// Checks if the path of nodes can be driven without any restricted turn.
+func can_drive(base *comps.GraphBase, restrictions Array[structs.TurnRestriction], path []int32) bool {
+	restricted := NewDict[structs.TurnRestriction, bool](restrictions.Length())
+	for _, r := range restrictions {
+		restricted[r] = true
+	}
+	// edges the path can currently be at
+	curr := NewList[int32](4)
+	for i := 0; i+1 < len(path); i++ {
+		next := NewList[int32](4)
+		for e := int32(0); e < int32(base.EdgeCount()); e++ {
+			edge := base.GetEdge(e)
+			if edge.NodeA != path[i] || edge.NodeB != path[i+1] {
+				continue
+			}
+			if i == 0 {
+				next.Add(e)
+				continue
+			}
+			for _, prev := range curr {
+				if !restricted.ContainsKey(structs.TurnRestriction{From: prev, Via: path[i], To: e}) {
+					next.Add(e)
+					break
+				}
+			}
+		}
+		if next.Length() == 0 {
+			return false
+		}
+		curr = next
+	}
+	return true
+}
+
+func TestViaNodeRestrictions(t *testing.T) {
+	// crossing at node 0 with the arms north (1), east (2), south (3) and west (4)
+	ways := [][2]int{{3, 0}, {0, 1}, {0, 2}, {0, 4}}
+	tests := []struct {
+		name        string
+		restriction OSMRestriction
+		paths       map[string][]int32
+		expected    map[string]bool
+	}{
+		{
+			"no_left_turn",
+			OSMRestriction{Type: "no_left_turn", From: List[int64]{0}, Via: List[int64]{0}, To: List[int64]{3}},
+			map[string][]int32{"left": {3, 0, 4}, "straight": {3, 0, 1}, "right": {3, 0, 2}, "u-turn": {3, 0, 3}, "other": {1, 0, 4}},
+			map[string]bool{"left": false, "straight": true, "right": true, "u-turn": true, "other": true},
+		},
+		{
+			"only_straight_on",
+			OSMRestriction{Type: "only_straight_on", From: List[int64]{0}, Via: List[int64]{0}, To: List[int64]{1}},
+			map[string][]int32{"left": {3, 0, 4}, "straight": {3, 0, 1}, "right": {3, 0, 2}, "u-turn": {3, 0, 3}, "other": {1, 0, 4}},
+			map[string]bool{"left": false, "straight": true, "right": false, "u-turn": false, "other": true},
+		},
+		{
+			"no_u_turn",
+			OSMRestriction{Type: "no_u_turn", From: List[int64]{0}, Via: List[int64]{0}, To: List[int64]{0}},
+			map[string][]int32{"left": {3, 0, 4}, "u-turn": {3, 0, 3}, "other u-turn": {1, 0, 1}},
+			map[string]bool{"left": true, "u-turn": false, "other u-turn": true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, restrictions := create_restricted_graph(5, ways, List[OSMRestriction]{tt.restriction})
+			for name, path := range tt.paths {
+				if ok := can_drive(base, restrictions, path); ok != tt.expected[name] {
+					t.Errorf("%v %v: expected %v, got %v", name, path, tt.expected[name], ok)
+				}
+			}
+		})
+	}
+}
+
+func TestViaWayRestrictions(t *testing.T) {
+	// from-way 0 (0-1), via-way 1 (1-2), to-way 2 (2-3), way 3 (2-4) branching off at the end of the via-way,
+	// way 4 (5-1) entering the via-way from another side
+	ways := [][2]int{{0, 1}, {1, 2}, {2, 3}, {2, 4}, {5, 1}}
+	paths := map[string][]int32{
+		"to":             {0, 1, 2, 3},
+		"branch":         {0, 1, 2, 4},
+		"leave":          {0, 1, 5},
+		"other to":       {5, 1, 2, 3},
+		"other branch":   {5, 1, 2, 4},
+		"backward":       {3, 2, 1, 0},
+		"via only":       {1, 2, 3},
+		"via u-turn":     {0, 1, 2, 1, 0},
+		"other via-turn": {5, 1, 2, 1, 0},
+	}
+	tests := []struct {
+		name         string
+		restrictions List[OSMRestriction]
+		expected     map[string]bool
+	}{
+		{
+			"no_straight_on",
+			List[OSMRestriction]{{Type: "no_straight_on", From: List[int64]{0}, Via: List[int64]{1}, ViaWay: true, To: List[int64]{2}}},
+			map[string]bool{"to": false, "branch": true, "leave": true, "other to": true, "other branch": true, "backward": true, "via only": true, "via u-turn": true, "other via-turn": true},
+		},
+		{
+			"only_straight_on",
+			List[OSMRestriction]{{Type: "only_straight_on", From: List[int64]{0}, Via: List[int64]{1}, ViaWay: true, To: List[int64]{2}}},
+			map[string]bool{"to": true, "branch": false, "leave": false, "other to": true, "other branch": true, "backward": true, "via only": true, "via u-turn": false, "other via-turn": true},
+		},
+		{
+			// via-node restrictions from the via-way apply to its copies
+			"no_straight_on and no_right_turn",
+			List[OSMRestriction]{
+				{Type: "no_straight_on", From: List[int64]{0}, Via: List[int64]{1}, ViaWay: true, To: List[int64]{2}},
+				{Type: "no_right_turn", From: List[int64]{1}, Via: List[int64]{2}, To: List[int64]{3}},
+			},
+			map[string]bool{"to": false, "branch": false, "leave": true, "other to": true, "other branch": false, "backward": true, "via only": true, "via u-turn": true, "other via-turn": true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, restrictions := create_restricted_graph(6, ways, tt.restrictions)
+			for name, path := range paths {
+				if ok := can_drive(base, restrictions, path); ok != tt.expected[name] {
+					t.Errorf("%v %v: expected %v, got %v", name, path, tt.expected[name], ok)
+				}
+			}
+		})
+	}
+}
+
+func TestGetRestriction(t *testing.T) {
+	vehicles := []string{"motor_vehicle", "motorcar"}
+	tests := []struct {
+		name        string
+		tags        Dict[string, string]
+		conditional bool
+		expected    Optional[string]
+	}{
+		{"restriction", Dict[string, string]{"type": "restriction", "restriction": "no_left_turn"}, false, Some("no_left_turn")},
+		{"not a restriction", Dict[string, string]{"type": "route", "restriction": "no_left_turn"}, false, None[string]()},
+		{"unknown type", Dict[string, string]{"type": "restriction", "restriction": "give_way"}, false, None[string]()},
+		{"vehicle", Dict[string, string]{"type": "restriction", "restriction:motorcar": "only_right_turn"}, false, Some("only_right_turn")},
+		{"specific vehicle overrides", Dict[string, string]{"type": "restriction", "restriction": "no_left_turn", "restriction:motorcar": "only_left_turn"}, false, Some("only_left_turn")},
+		{"other vehicle", Dict[string, string]{"type": "restriction", "restriction:hgv": "no_left_turn"}, false, None[string]()},
+		{"except", Dict[string, string]{"type": "restriction", "restriction": "no_left_turn", "except": "bus; motorcar"}, false, None[string]()},
+		{"except other", Dict[string, string]{"type": "restriction", "restriction": "no_left_turn", "except": "bicycle;psv"}, false, Some("no_left_turn")},
+		{"conditional skipped", Dict[string, string]{"type": "restriction", "restriction:conditional": "no_left_turn @ (Mo-Fr 07:00-09:00)"}, false, None[string]()},
+		{"conditional", Dict[string, string]{"type": "restriction", "restriction:conditional": "no_left_turn @ (Mo-Fr 07:00-09:00)"}, true, Some("no_left_turn")},
+		{"conditional vehicle", Dict[string, string]{"type": "restriction", "restriction:motorcar:conditional": "only_straight_on @ (22:00-06:00)"}, true, Some("only_straight_on")},
+		{"unconditional preferred", Dict[string, string]{"type": "restriction", "restriction": "no_u_turn", "restriction:motorcar:conditional": "only_straight_on @ (22:00-06:00)"}, true, Some("no_u_turn")},
+		{"conditional override skipped", Dict[string, string]{"type": "restriction", "restriction": "no_u_turn", "restriction:conditional": "no_left_turn @ (22:00-06:00)"}, false, Some("no_u_turn")},
+		{"conditional exception", Dict[string, string]{"type": "restriction", "restriction": "no_left_turn", "restriction:conditional": "none @ (Sa,Su)"}, true, Some("no_left_turn")},
+		{"conditional exception only", Dict[string, string]{"type": "restriction", "restriction:motorcar:conditional": "none @ (Sa,Su)"}, true, None[string]()},
+		{"conditional except", Dict[string, string]{"type": "restriction", "restriction:conditional": "no_left_turn @ (Mo-Fr 07:00-09:00)", "except": "motorcar"}, true, None[string]()},
+		{"conditional except other", Dict[string, string]{"type": "restriction", "restriction:motor_vehicle:conditional": "no_right_turn @ (Mo-Fr 07:00-09:00)", "except": "psv"}, true, Some("no_right_turn")},
+		{"except vehicle", Dict[string, string]{"type": "restriction", "restriction:motorcar": "no_left_turn", "except": "motor_vehicle"}, false, None[string]()},
+	}
+	for _, tt := range tests {
+		r := _GetRestriction(tt.tags, vehicles, tt.conditional)
+		if r.HasValue() != tt.expected.HasValue() || r.Value != tt.expected.Value {
+			t.Errorf("%v: expected %q (set %v), got %q (set %v)", tt.name, tt.expected.Value, tt.expected.HasValue(), r.Value, r.HasValue())
+		}
+	}
+}
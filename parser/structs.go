@@ -15,13 +15,29 @@ type TempNode struct {
 	Count int32
 }
 type OSMNode struct {
-	Point geo.Coord
-	Attr  attr.NodeAttribs
-	Edges List[int32]
+	Point   geo.Coord
+	Attr    attr.NodeAttribs
+	Details attr.NodeDetails
+	Edges   List[int32]
 }
 type OSMEdge struct {
 	NodeA int
 	NodeB int
 	Attr  attr.EdgeAttribs
-	Nodes List[geo.Coord]
+	// details in the direction of the edge
+	Details attr.EdgeDetails
+	Nodes   List[geo.Coord]
+	// id of the osm-way the edge is part of
+	Way int64
+}
+
+// Turn-restriction relation given by osm-ids.
+type OSMRestriction struct {
+	// "no_*" or "only_*" restriction-type
+	Type string
+	From List[int64]
+	// via-node or via-ways
+	Via    List[int64]
+	ViaWay bool
+	To     List[int64]
 }
@@ -1,9 +1,12 @@
 package parser
 
 import (
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/ttpr0/go-routing/attr"
+	. "github.com/ttpr0/go-routing/util"
 )
 
 //*******************************************
@@ -51,6 +54,20 @@ func _GetType(typ string) attr.RoadType {
 		return attr.ROAD
 	case "track":
 		return attr.TRACK
+	case "service":
+		return attr.SERVICE
+	case "footway":
+		return attr.FOOTWAY
+	case "path":
+		return attr.PATH
+	case "pedestrian":
+		return attr.PEDESTRIAN
+	case "steps":
+		return attr.STEPS
+	case "cycleway":
+		return attr.CYCLEWAY
+	case "bridleway":
+		return attr.BRIDLEWAY
 	}
 	return 0
 }
@@ -200,3 +217,183 @@ func _GetORSTravelSpeed(streettype attr.RoadType, maxspeed string, tracktype str
 	}
 	return speed
 }
+
+// Evaluates the access-tags from the most general to the most specific key (e.g. "access", "vehicle", "bicycle").
+//
+// The most specific key set to a known value decides, None if none of the keys is set.
+func _GetAccess(tags Dict[string, string], keys ...string) Optional[bool] {
+	access := None[bool]()
+	for _, key := range keys {
+		switch tags.Get(key) {
+		case "yes", "designated", "permissive", "destination", "delivery", "customers", "official":
+			access = Some(true)
+		case "no", "private", "agricultural", "forestry", "use_sidepath", "dismount":
+			access = Some(false)
+		}
+	}
+	return access
+}
+
+// Returns the direction of a oneway-tag (1: forward, -1: backward, 0: both), None if the value is unknown.
+func _GetOnewayValue(oneway string) Optional[int] {
+	switch oneway {
+	case "yes", "true", "1":
+		return Some(1)
+	case "-1", "reverse":
+		return Some(-1)
+	case "no", "false", "0":
+		return Some(0)
+	}
+	return None[int]()
+}
+
+// Checks if a sidewalk is mapped on any side of the road.
+func _HasSidewalk(tags Dict[string, string]) bool {
+	switch tags.Get("sidewalk") {
+	case "both", "left", "right", "yes":
+		return true
+	}
+	for _, key := range []string{"sidewalk:both", "sidewalk:left", "sidewalk:right"} {
+		if tags.Get(key) == "yes" {
+			return true
+		}
+	}
+	return false
+}
+
+func _GetWalkingSpeed(streettype attr.RoadType) int32 {
+	switch streettype {
+	case attr.STEPS:
+		return 2
+	default:
+		return 3
+	}
+}
+
+func _GetCyclingSpeed(streettype attr.RoadType, tracktype string, surface string) int32 {
+	var speed int32
+	switch streettype {
+	case attr.FOOTWAY, attr.PEDESTRIAN, attr.LIVING_STREET, attr.STEPS, attr.BRIDLEWAY:
+		// shared with pedestrians
+		speed = 10
+	case attr.PATH:
+		speed = 14
+	case attr.TRACK:
+		switch tracktype {
+		case "grade1":
+			speed = 18
+		case "grade2":
+			speed = 14
+		case "grade3":
+			speed = 10
+		case "grade4", "grade5":
+			speed = 8
+		default:
+			speed = 12
+		}
+	default:
+		speed = 18
+	}
+
+	// check if surface is set
+	switch surface {
+	case "compacted", "fine_gravel", "paving_stones":
+		speed = min(speed, 14)
+	case "gravel", "unpaved", "ground", "dirt", "grass", "pebblestone", "cobblestone", "sett", "wood", "grass_paver", "earth":
+		speed = min(speed, 10)
+	case "sand", "mud", "rocky", "stone":
+		speed = min(speed, 6)
+	}
+	return speed
+}
+
+// Returns the type of the turn-restriction (e.g. "no_left_turn") applying to the vehicles (from the most general to the most specific, e.g. ["motor_vehicle", "motorcar"]).
+//
+// The unconditional restriction of the most specific key is preferred. Conditional restrictions (e.g. "no_left_turn @ (Mo-Fr 07:00-09:00)")
+// are only used if conditional is set and no unconditional restriction is tagged. Their conditions are not evaluated (the restriction applies
+// at all times) and conditional exceptions (e.g. "none @ (Sa,Su)") never lift an unconditional restriction. Restrictions excepting any of the vehicles are skipped.
+func _GetRestriction(tags Dict[string, string], vehicles []string, conditional bool) Optional[string] {
+	if !strings.HasPrefix(tags.Get("type"), "restriction") {
+		return None[string]()
+	}
+	keys := []string{"restriction"}
+	for _, vehicle := range vehicles {
+		keys = append(keys, "restriction:"+vehicle)
+	}
+	typ := ""
+	for _, key := range keys {
+		if value := tags.Get(key); value != "" {
+			typ = value
+		}
+	}
+	if typ == "" && conditional {
+		for _, key := range keys {
+			value := strings.TrimSpace(strings.SplitN(tags.Get(key+":conditional"), "@", 2)[0])
+			if strings.HasPrefix(value, "no_") || strings.HasPrefix(value, "only_") {
+				typ = value
+			}
+		}
+	}
+	if !strings.HasPrefix(typ, "no_") && !strings.HasPrefix(typ, "only_") {
+		return None[string]()
+	}
+	for _, except := range strings.Split(tags.Get("except"), ";") {
+		if slices.Contains(vehicles, strings.TrimSpace(except)) {
+			return None[string]()
+		}
+	}
+	return Some(typ)
+}
+
+// Returns the details of the way in the direction of its nodes, access is taken from the most specific of the access-keys.
+func _GetEdgeDetails(tags Dict[string, string], access_keys ...string) attr.EdgeDetails {
+	d := attr.EdgeDetails{}
+	d.Surface = attr.SurfaceTypeFromString(tags.Get("surface"))
+	d.Smoothness = attr.SmoothnessTypeFromString(tags.Get("smoothness"))
+	incline := _ParseNumber(strings.TrimSuffix(tags.Get("incline"), "%"))
+	if incline.HasValue() {
+		d.Incline = int8(max(min(incline.Value, 127), -127))
+	}
+	lanes := _ParseNumber(tags.Get("lanes"))
+	if lanes.HasValue() {
+		d.Lanes = byte(max(min(lanes.Value, 255), 0))
+	}
+	for _, key := range access_keys {
+		if access := attr.AccessTypeFromString(tags.Get(key)); access != 0 {
+			d.Access = access
+		}
+	}
+	d.Toll = tags.Get("toll") == "yes"
+	d.Ferry = tags.Get("route") == "ferry"
+	d.Bridge = tags.ContainsKey("bridge") && tags.Get("bridge") != "no"
+	d.Tunnel = tags.ContainsKey("tunnel") && tags.Get("tunnel") != "no"
+	width := _ParseNumber(strings.TrimSuffix(tags.Get("width"), " m"))
+	if width.HasValue() && width.Value > 0 {
+		d.Width = float32(width.Value)
+	}
+	return d
+}
+
+// Returns the details of the node, nodes with details (except elevation) are kept as graph-nodes.
+func _GetNodeDetails(tags Dict[string, string]) attr.NodeDetails {
+	d := attr.NodeDetails{Elevation: attr.NO_ELEVATION}
+	d.TrafficSignals = tags.Get("highway") == "traffic_signals" || tags.Get("crossing") == "traffic_signals"
+	d.Barrier = attr.BarrierTypeFromString(tags.Get("barrier"))
+	ele := _ParseNumber(strings.TrimSuffix(tags.Get("ele"), " m"))
+	if ele.HasValue() {
+		d.Elevation = int16(max(min(ele.Value, 32767), -32767))
+	}
+	return d
+}
+
+// Parses the (decimal) number, None if it isn't a number.
+func _ParseNumber(value string) Optional[float64] {
+	if value == "" {
+		return None[float64]()
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(strings.Replace(value, ",", ".", 1)), 64)
+	if err != nil {
+		return None[float64]()
+	}
+	return Some(v)
+}
@@ -8,32 +8,59 @@ import (
 type WalkingDecoder struct {
 }
 
-var walking_types = Dict[string, bool]{"motorway": true, "motorway_link": true, "trunk": true, "trunk_link": true,
-	"primary": true, "primary_link": true, "secondary": true, "secondary_link": true, "tertiary": true, "tertiary_link": true,
-	"residential": true, "living_street": true, "service": true, "track": true, "unclassified": true, "road": true}
+var walking_types = Dict[string, bool]{"primary": true, "primary_link": true, "secondary": true, "secondary_link": true,
+	"tertiary": true, "tertiary_link": true, "residential": true, "living_street": true, "service": true, "track": true,
+	"unclassified": true, "road": true, "footway": true, "path": true, "pedestrian": true, "steps": true, "cycleway": true,
+	"bridleway": true}
+
+// highway-types only walkable if pedestrians are explicitly allowed or a sidewalk is mapped
+var walking_restricted_types = Dict[string, bool]{"motorway": true, "motorway_link": true, "trunk": true, "trunk_link": true}
 
 func (self *WalkingDecoder) IsValidHighway(tags Dict[string, string]) bool {
 	if !tags.ContainsKey("highway") {
 		return false
 	}
-	if !walking_types.ContainsKey(tags.Get("highway")) {
+	str_type := tags.Get("highway")
+	if walking_restricted_types.ContainsKey(str_type) {
+		access := _GetAccess(tags, "foot")
+		if access.HasValue() {
+			return access.Value
+		}
+		return _HasSidewalk(tags)
+	}
+	if !walking_types.ContainsKey(str_type) {
 		return false
 	}
+	access := _GetAccess(tags, "access", "foot")
+	if access.HasValue() {
+		return access.Value
+	}
 	return true
 }
 func (self *WalkingDecoder) DecodeNode(tags Dict[string, string]) attr.NodeAttribs {
 	return attr.NodeAttribs{Type: 0}
 }
 func (self *WalkingDecoder) DecodeEdge(tags Dict[string, string]) attr.EdgeAttribs {
-	templimit := tags.Get("maxspeed")
 	str_type := tags.Get("highway")
-	oneway := tags.Get("oneway")
-	track_type := tags.Get("tracktype")
-	surface := tags.Get("surface")
 	e := attr.EdgeAttribs{}
 	e.Type = _GetType(str_type)
-	// e.Templimit = GetTemplimit(templimit, e.Type)
-	e.Maxspeed = byte(_GetORSTravelSpeed(e.Type, templimit, track_type, surface))
-	e.Oneway = _IsOneway(oneway, e.Type)
+	e.Maxspeed = byte(_GetWalkingSpeed(e.Type))
 	return e
 }
+func (self *WalkingDecoder) DecodeNodeDetails(tags Dict[string, string]) attr.NodeDetails {
+	return _GetNodeDetails(tags)
+}
+func (self *WalkingDecoder) DecodeEdgeDetails(tags Dict[string, string]) attr.EdgeDetails {
+	return _GetEdgeDetails(tags, "access", "foot")
+}
+func (self *WalkingDecoder) DecodeDirection(tags Dict[string, string]) (bool, bool) {
+	// oneway-tags of vehicles don't apply to pedestrians
+	oneway := _GetOnewayValue(tags.Get("oneway:foot"))
+	if !oneway.HasValue() {
+		return true, true
+	}
+	return oneway.Value != -1, oneway.Value != 1
+}
+func (self *WalkingDecoder) DecodeRestriction(tags Dict[string, string]) Optional[string] {
+	return None[string]()
+}
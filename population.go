@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ttpr0/go-routing/geo"
+	. "github.com/ttpr0/go-routing/util"
+	"golang.org/x/exp/slog"
+)
+
+//**********************************************************
+// population dataset
+//**********************************************************
+
+type PopulationEntry struct {
+	X   float32 `csv:"x"`
+	Y   float32 `csv:"y"`
+	Pop float32 `csv:"pop"`
+}
+
+// Loads a gridded population dataset from a csv-file containing the columns x, y (wgs84) and pop.
+func LoadPopulation(file string, delimiter rune) *PopulationIndex {
+	slog.Info("Loading population data from " + file)
+	index := NewPopulationIndex(0.05)
+	count := 0
+	for entry := range ReadCSVFromFile[PopulationEntry](file, delimiter) {
+		if entry.Pop <= 0 {
+			continue
+		}
+		index.Add(geo.Coord{entry.X, entry.Y}, entry.Pop)
+		count += 1
+	}
+	slog.Info(fmt.Sprintf("Loaded %v population cells", count))
+	return index
+}
+
+//**********************************************************
+// population index
+//**********************************************************
+
+// Bucketed spatial index of population points.
+type PopulationIndex struct {
+	bucketsize float32
+	buckets    Dict[[2]int32, List[Tuple[geo.Coord, float32]]]
+}
+
+// Creates an empty index using buckets of bucketsize degrees.
+func NewPopulationIndex(bucketsize float32) *PopulationIndex {
+	return &PopulationIndex{
+		bucketsize: bucketsize,
+		buckets:    NewDict[[2]int32, List[Tuple[geo.Coord, float32]]](100),
+	}
+}
+
+func (self *PopulationIndex) Add(point geo.Coord, pop float32) {
+	key := self._GetBucket(point)
+	bucket := self.buckets[key]
+	bucket.Add(MakeTuple(point, pop))
+	self.buckets[key] = bucket
+}
+
+// Returns the summed population of all points within the polygon.
+func (self *PopulationIndex) SumInPolygon(polygon [][]geo.Coord) float64 {
+	if len(polygon) == 0 || len(polygon[0]) == 0 {
+		return 0
+	}
+	envelope := geo.Envelope{math.MaxFloat32, math.MaxFloat32, -math.MaxFloat32, -math.MaxFloat32}
+	for _, coord := range polygon[0] {
+		envelope[0] = min(envelope[0], coord[0])
+		envelope[1] = min(envelope[1], coord[1])
+		envelope[2] = max(envelope[2], coord[0])
+		envelope[3] = max(envelope[3], coord[1])
+	}
+	min_bucket := self._GetBucket(geo.Coord{envelope[0], envelope[1]})
+	max_bucket := self._GetBucket(geo.Coord{envelope[2], envelope[3]})
+	total := float64(0)
+	for x := min_bucket[0]; x <= max_bucket[0]; x++ {
+		for y := min_bucket[1]; y <= max_bucket[1]; y++ {
+			bucket, ok := self.buckets[[2]int32{x, y}]
+			if !ok {
+				continue
+			}
+			for _, item := range bucket {
+				if !envelope.ContainsCoord(item.A) {
+					continue
+				}
+				if geo.SimplePointInPolygon(item.A, polygon) {
+					total += float64(item.B)
+				}
+			}
+		}
+	}
+	return total
+}
+
+func (self *PopulationIndex) _GetBucket(point geo.Coord) [2]int32 {
+	return [2]int32{int32(math.Floor(float64(point[0] / self.bucketsize))), int32(math.Floor(float64(point[1] / self.bucketsize)))}
+}
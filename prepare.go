@@ -7,6 +7,7 @@ import (
 	"github.com/ttpr0/go-routing/comps"
 	"github.com/ttpr0/go-routing/graph"
 	"github.com/ttpr0/go-routing/preproc"
+	"github.com/ttpr0/go-routing/structs"
 	. "github.com/ttpr0/go-routing/util"
 )
 
@@ -28,11 +29,18 @@ func BuildShortestWeighting(base comps.IGraphBase, attributes *attr.GraphAttribu
 	return weights
 }
 
+// Average delay (in seconds) at traffic-signals.
+const TRAFFIC_SIGNAL_DELAY = 10
+
+// Travel-time weighting, edges leading to traffic-signals are delayed by TRAFFIC_SIGNAL_DELAY.
 func BuildCarWeighting(base comps.IGraphBase, attributes *attr.GraphAttributes) *comps.DefaultWeighting {
 	weights := comps.NewDefaultWeighting(base)
 	for i := 0; i < base.EdgeCount(); i++ {
 		attr := attributes.GetEdgeAttribs(int32(i))
 		w := attr.Length * 3.6 / float32(attr.Maxspeed)
+		if attributes.GetNodeDetails(base.GetEdge(int32(i)).NodeB).TrafficSignals {
+			w += TRAFFIC_SIGNAL_DELAY
+		}
 		if w < 1 {
 			w = 1
 		}
@@ -46,7 +54,11 @@ func BuildFootWeighting(base comps.IGraphBase, attributes *attr.GraphAttributes)
 	weights := comps.NewDefaultWeighting(base)
 	for i := 0; i < base.EdgeCount(); i++ {
 		attr := attributes.GetEdgeAttribs(int32(i))
-		w := attr.Length * 3.6 / 3
+		speed := float32(attr.Maxspeed)
+		if speed == 0 {
+			speed = 3
+		}
+		w := attr.Length * 3.6 / speed
 		if w < 1 {
 			w = 1
 		}
@@ -60,7 +72,11 @@ func BuildBikeWeighting(base comps.IGraphBase, attributes *attr.GraphAttributes)
 	weights := comps.NewDefaultWeighting(base)
 	for i := 0; i < base.EdgeCount(); i++ {
 		attr := attributes.GetEdgeAttribs(int32(i))
-		w := attr.Length * 3.6 / 18
+		speed := float32(attr.Maxspeed)
+		if speed == 0 {
+			speed = 18
+		}
+		w := attr.Length * 3.6 / speed
 		if w < 1 {
 			w = 1
 		}
@@ -70,6 +86,18 @@ func BuildBikeWeighting(base comps.IGraphBase, attributes *attr.GraphAttributes)
 	return weights
 }
 
+// Adds turn-costs to the weighting, the turns of the restrictions are forbidden.
+func BuildTCWeighting(base comps.IGraphBase, weight comps.IWeighting, restrictions Array[structs.TurnRestriction]) *comps.TCWeighting {
+	tc_weight := comps.NewTCWeighting(base)
+	for i := 0; i < base.EdgeCount(); i++ {
+		tc_weight.SetEdgeWeight(int32(i), weight.GetEdgeWeight(int32(i)))
+	}
+	for _, r := range restrictions {
+		tc_weight.SetTurnCost(r.From, r.Via, r.To, comps.TURN_RESTRICTED)
+	}
+	return tc_weight
+}
+
 //**********************************************************
 // preprocess speed-up components
 //**********************************************************
@@ -125,6 +153,17 @@ func CreateCH(base *comps.GraphBase, weight comps.IWeighting) (*comps.GraphBase,
 	return new_base, new_ch, ordering
 }
 
+// Builds the contraction hierarchy on the edge-based graph of base respecting the turn-costs.
+//
+// Returns the edge-based graph and weighting, the hierarchy and the mapping from edges of base to nodes of the edge-based graph.
+func CreateTurnCH(base *comps.GraphBase, weight comps.ITCWeighting) (*comps.GraphBase, *comps.DefaultWeighting, *comps.CH, structs.IDMapping) {
+	edge_base, edge_weight := preproc.BuildEdgeBasedGraph(base, weight)
+	new_edge_base, ch, ordering := CreateCH(edge_base, edge_weight)
+	edge_mapping := structs.NewIdendityMapping(base.EdgeCount())
+	edge_mapping.ReorderTargets(ordering)
+	return new_edge_base, edge_weight, ch, edge_mapping
+}
+
 func CreateTiledCH(base *comps.GraphBase, weight comps.IWeighting, partition *comps.Partition) (*comps.GraphBase, *comps.Partition, *comps.CH, Array[int32]) {
 	ch := preproc.CalcContraction5(base, weight, partition)
 
@@ -0,0 +1,50 @@
+package preproc
+
+import (
+	"github.com/ttpr0/go-routing/comps"
+	"github.com/ttpr0/go-routing/structs"
+	. "github.com/ttpr0/go-routing/util"
+)
+
+//*******************************************
+// prepare edge-based graph
+//*******************************************
+
+// Builds the edge-based graph of base, every edge of base becomes a node (with the same id) located at the end of the edge.
+//
+// Turns between edges become edges weighted by the weight of the edge turned into and the turn-cost, restricted turns are skipped.
+// Node-based speed-ups built on the edge-based graph therefore respect turn-costs.
+func BuildEdgeBasedGraph(base comps.IGraphBase, weight comps.ITCWeighting) (*comps.GraphBase, *comps.DefaultWeighting) {
+	nodes := NewArray[structs.Node](base.EdgeCount())
+	for i := 0; i < base.EdgeCount(); i++ {
+		edge := base.GetEdge(int32(i))
+		nodes[i] = structs.Node{Loc: base.GetNode(edge.NodeB).Loc}
+	}
+
+	edges := NewList[structs.Edge](base.EdgeCount() * 2)
+	weights := NewList[int32](base.EdgeCount() * 2)
+	accessor := base.GetAccessor()
+	for i := 0; i < base.EdgeCount(); i++ {
+		edge := base.GetEdge(int32(i))
+		accessor.SetBaseNode(edge.NodeB, true)
+		for accessor.Next() {
+			other_id := accessor.GetEdgeID()
+			tc := weight.GetTurnCost(int32(i), edge.NodeB, other_id)
+			if tc >= comps.TURN_RESTRICTED {
+				continue
+			}
+			edges.Add(structs.Edge{
+				NodeA: int32(i),
+				NodeB: other_id,
+			})
+			weights.Add(weight.GetEdgeWeight(other_id) + tc)
+		}
+	}
+
+	edge_base := comps.NewGraphBase(nodes, Array[structs.Edge](edges))
+	edge_weight := comps.NewDefaultWeighting(edge_base)
+	for i, w := range weights {
+		edge_weight.SetEdgeWeight(int32(i), w)
+	}
+	return edge_base, edge_weight
+}
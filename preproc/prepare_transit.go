@@ -1,7 +1,8 @@
 package preproc
 
 import (
-	"github.com/ttpr0/go-routing/batched/onetomany"
+	"slices"
+
 	"github.com/ttpr0/go-routing/comps"
 	"github.com/ttpr0/go-routing/graph"
 	"github.com/ttpr0/go-routing/structs"
@@ -12,7 +13,11 @@ import (
 // prepare transit-data
 //*******************************************
 
-func PrepareTransit(g graph.IGraph, stops Array[structs.Node], connections Array[structs.Connection], max_transfer_range int32) *comps.Transit {
+// Maps the stops to the graph and computes the walking transfers between them.
+//
+// Transfers store the edges walked. Every transfer takes at least min_transfer_time, transfer_penalty is added to every transfer
+// (including changing vehicles at the same stop). GTFS transfers override these defaults for the stops given.
+func PrepareTransit(g graph.IGraph, stops Array[structs.Node], connections Array[structs.Connection], transfers Array[structs.Transfer], max_transfer_range, min_transfer_time, transfer_penalty int32) *comps.Transit {
 	mapping := NewArray[[2]int32](g.NodeCount())
 	for i := 0; i < g.NodeCount(); i++ {
 		mapping[i] = [2]int32{-1, -1}
@@ -32,32 +37,225 @@ func PrepareTransit(g graph.IGraph, stops Array[structs.Node], connections Array
 		mapping[i][1] = closest
 	}
 	id_mapping := structs.NewIDMapping(mapping)
+
+	// transfers given by the feed
+	transfer_times := NewArray[int32](stops.Length())
+	for i := 0; i < stops.Length(); i++ {
+		transfer_times[i] = min_transfer_time + transfer_penalty
+	}
+	gtfs_transfers := NewDict[Tuple[int32, int32], structs.Transfer](transfers.Length())
+	for _, transfer := range transfers {
+		if transfer.StopA == transfer.StopB {
+			transfer_times[transfer.StopA] = _GetTransferTime(transfer, 0, min_transfer_time, transfer_penalty)
+			continue
+		}
+		gtfs_transfers[MakeTuple(transfer.StopA, transfer.StopB)] = transfer
+	}
+
 	shortcuts := structs.NewShortcutStore(100, false)
-	otm := onetomany.NewRangeDijkstraTC(g, max_transfer_range)
-	solver := otm.CreateSolver()
+	explorer := g.GetGraphExplorer()
 	for i := 0; i < stops.Length(); i++ {
 		s_node := id_mapping.GetSource(int32(i))
 		if s_node == -1 {
 			continue
 		}
-		start := [1]Tuple[int32, int32]{{s_node, 0}}
-		solver.CalcDistanceFromStart(start[:])
+		flags := _CalcTransferTree(g, s_node, max_transfer_range)
 		for j := 0; j < stops.Length(); j++ {
 			if i == j {
 				continue
 			}
+			key := MakeTuple(int32(i), int32(j))
+			transfer, has_transfer := gtfs_transfers[key]
 			t_node := id_mapping.GetSource(int32(j))
+			flag, reached := flags[t_node]
+			if t_node == -1 || !reached || flag.pathlength > max_transfer_range {
+				// transfers given by the feed are added even if out of walking range
+				if has_transfer && transfer.Type == 2 {
+					shortcuts.AddShortcut(structs.Shortcut{From: int32(i), To: int32(j), Weight: transfer.MinTime + transfer_penalty}, []int32{})
+				}
+				continue
+			}
+			var weight int32
+			if has_transfer {
+				if transfer.Type == 3 {
+					continue
+				}
+				weight = _GetTransferTime(transfer, flag.pathlength, min_transfer_time, transfer_penalty)
+			} else {
+				weight = max(flag.pathlength, min_transfer_time) + transfer_penalty
+			}
+			edges := _GetTransferEdges(explorer, flags, s_node, t_node)
+			shortcuts.AddShortcut(structs.Shortcut{From: int32(i), To: int32(j), Weight: weight}, edges)
+		}
+	}
+
+	return comps.NewTransit(id_mapping, stops, connections, shortcuts, transfer_times)
+}
+
+// Returns a copy of the transit-data extended by the stops and connections (e.g. of hypothetical lines).
+//
+// New stops are appended after the existing ones and mapped to the given nodes (-1 if not mapped to the graph).
+// Walking transfers are computed between the new stops and all stops within max_transfer_range, existing transfers are kept.
+func ExtendTransit(g graph.IGraph, transit *comps.Transit, stops Array[structs.Node], stop_nodes Array[int32], connections Array[structs.Connection], max_transfer_range, min_transfer_time, transfer_penalty int32) *comps.Transit {
+	stop_count := transit.StopCount()
+	all_stops := NewArray[structs.Node](stop_count + stops.Length())
+	mapping := NewArray[[2]int32](max(g.NodeCount(), all_stops.Length()))
+	for i := 0; i < mapping.Length(); i++ {
+		mapping[i] = [2]int32{-1, -1}
+	}
+	transfer_times := NewArray[int32](all_stops.Length())
+	for i := 0; i < all_stops.Length(); i++ {
+		var node int32
+		if i < stop_count {
+			all_stops[i] = transit.GetStop(int32(i))
+			node = transit.MapStopToNode(int32(i))
+			transfer_times[i] = transit.GetTransferTime(int32(i))
+		} else {
+			all_stops[i] = stops[i-stop_count]
+			node = stop_nodes[i-stop_count]
+			transfer_times[i] = min_transfer_time + transfer_penalty
+		}
+		if node == -1 {
+			continue
+		}
+		mapping[node][0] = int32(i)
+		mapping[i][1] = node
+	}
+	id_mapping := structs.NewIDMapping(mapping)
+	all_connections := NewArray[structs.Connection](transit.ConnectionCount() + connections.Length())
+	for i := 0; i < transit.ConnectionCount(); i++ {
+		all_connections[i] = transit.GetConnection(int32(i))
+	}
+	for i, conn := range connections {
+		all_connections[transit.ConnectionCount()+i] = conn
+	}
+
+	// existing transfers
+	shortcuts := structs.NewShortcutStore(transit.ShortcutCount()+100, false)
+	for i := 0; i < transit.ShortcutCount(); i++ {
+		edges := NewList[int32](10)
+		transit.GetEdgesFromShortcut(int32(i), false, func(edge int32) {
+			edges.Add(edge)
+		})
+		shortcuts.AddShortcut(transit.GetShortcut(int32(i)), edges)
+	}
+
+	// transfers from the new stops, existing stops reached are remembered to add the transfers towards the new stops
+	explorer := g.GetGraphExplorer()
+	reached_stops := NewDict[int32, bool](10)
+	for i := stop_count; i < all_stops.Length(); i++ {
+		s_node := id_mapping.GetSource(int32(i))
+		if s_node == -1 {
+			continue
+		}
+		flags := _CalcTransferTree(g, s_node, max_transfer_range)
+		for j := 0; j < all_stops.Length(); j++ {
+			t_node := id_mapping.GetSource(int32(j))
+			if i == j || t_node == -1 {
+				continue
+			}
+			flag, reached := flags[t_node]
+			if !reached || flag.pathlength > max_transfer_range {
+				continue
+			}
+			if j < stop_count {
+				reached_stops[int32(j)] = true
+			}
+			weight := max(flag.pathlength, min_transfer_time) + transfer_penalty
+			edges := _GetTransferEdges(explorer, flags, s_node, t_node)
+			shortcuts.AddShortcut(structs.Shortcut{From: int32(i), To: int32(j), Weight: weight}, edges)
+		}
+	}
+	// transfers from the existing stops to the new stops
+	for j := range reached_stops {
+		s_node := id_mapping.GetSource(j)
+		flags := _CalcTransferTree(g, s_node, max_transfer_range)
+		for i := stop_count; i < all_stops.Length(); i++ {
+			t_node := id_mapping.GetSource(int32(i))
 			if t_node == -1 {
 				continue
 			}
-			dist := solver.GetDistance(t_node)
-			if dist > max_transfer_range {
+			flag, reached := flags[t_node]
+			if !reached || flag.pathlength > max_transfer_range {
 				continue
 			}
-			// TODO: edges from shortcuts
-			shortcuts.AddShortcut(structs.Shortcut{From: int32(i), To: int32(j), Weight: dist}, []int32{})
+			weight := max(flag.pathlength, min_transfer_time) + transfer_penalty
+			edges := _GetTransferEdges(explorer, flags, s_node, t_node)
+			shortcuts.AddShortcut(structs.Shortcut{From: j, To: int32(i), Weight: weight}, edges)
 		}
 	}
 
-	return comps.NewTransit(id_mapping, stops, connections, shortcuts)
+	return comps.NewTransit(id_mapping, all_stops, all_connections, shortcuts, transfer_times)
+}
+
+// Returns the edges walked from s_node to t_node in the shortest-path-tree.
+func _GetTransferEdges(explorer graph.IGraphExplorer, flags Dict[int32, _Flag], s_node, t_node int32) List[int32] {
+	edges := NewList[int32](10)
+	curr_id := t_node
+	for curr_id != s_node {
+		edge_id := flags[curr_id].prevEdge
+		edges.Add(edge_id)
+		curr_id = explorer.GetOtherNode(graph.EdgeRef{EdgeID: edge_id}, curr_id)
+	}
+	slices.Reverse(edges)
+	return edges
+}
+
+// Returns the time needed for the transfer given a walking time.
+func _GetTransferTime(transfer structs.Transfer, walk, min_transfer_time, transfer_penalty int32) int32 {
+	switch transfer.Type {
+	case 1:
+		// timed transfers are guaranteed
+		return walk + transfer_penalty
+	case 2:
+		return max(walk, transfer.MinTime) + transfer_penalty
+	case 3:
+		// transfer not possible
+		return 1000000
+	default:
+		return max(walk, min_transfer_time) + transfer_penalty
+	}
+}
+
+// Computes the walking shortest-path-tree from start up to max_range.
+func _CalcTransferTree(g graph.IGraph, start int32, max_range int32) Dict[int32, _Flag] {
+	explorer := g.GetGraphExplorer()
+	heap := NewPriorityQueue[int32, int32](10)
+	flags := NewDict[int32, _Flag](10)
+
+	flags[start] = _Flag{pathlength: 0, visited: false, prevEdge: -1}
+	heap.Enqueue(start, 0)
+	for {
+		curr_id, ok := heap.Dequeue()
+		if !ok {
+			break
+		}
+		curr_flag := flags[curr_id]
+		if curr_flag.visited {
+			continue
+		}
+		curr_flag.visited = true
+		flags[curr_id] = curr_flag
+		if curr_flag.pathlength > max_range {
+			continue
+		}
+		explorer.ForAdjacentEdges(curr_id, graph.FORWARD, graph.ADJACENT_EDGES, func(ref graph.EdgeRef) {
+			other_id := ref.OtherID
+			other_flag, ok := flags[other_id]
+			if !ok {
+				other_flag = _Flag{pathlength: 10000000, visited: false, prevEdge: -1}
+			}
+			if other_flag.visited {
+				return
+			}
+			newlength := curr_flag.pathlength + explorer.GetEdgeWeight(ref)
+			if newlength < other_flag.pathlength {
+				other_flag.pathlength = newlength
+				other_flag.prevEdge = ref.EdgeID
+				heap.Enqueue(other_id, newlength)
+			}
+			flags[other_id] = other_flag
+		})
+	}
+	return flags
 }
@@ -3,9 +3,14 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/ttpr0/go-routing/attr"
 	"github.com/ttpr0/go-routing/comps"
+	"github.com/ttpr0/go-routing/geo"
 	"github.com/ttpr0/go-routing/graph"
 	"github.com/ttpr0/go-routing/parser"
 	"github.com/ttpr0/go-routing/preproc"
@@ -34,7 +39,8 @@ type IRoutingProfile interface {
 	_GetMetadata() ProfileMeta
 }
 
-type PrepDict = Dict[ProfileType, Tuple[*comps.GraphBase, *attr.GraphAttributes]]
+// Parsed graphs and their attributes by the name of the graph.
+type PrepDict = Dict[string, Tuple[*comps.GraphBase, *attr.GraphAttributes]]
 
 type ProfileHandler struct {
 	Build func(string, SourceOptions, IProfileOptions, PrepDict) IRoutingProfile
@@ -73,6 +79,8 @@ type DrivingProfile struct {
 	manager *RoutingManager
 	metric  MetricType
 	vehicle VehicleType
+	// name of the osm-graph and attributes the profile is built from
+	graph string
 
 	base              comps.IGraphBase
 	attr_node_mapping Optional[structs.IDMapping]
@@ -80,6 +88,7 @@ type DrivingProfile struct {
 	weight            Optional[comps.IWeighting]
 	tc_weight         Optional[comps.ITCWeighting]
 	ch_speed_up       Optional[DrivingCHSpeedUp]
+	tc_ch_speed_up    Optional[DrivingTurnCHSpeedUp]
 	overlay_speed_up  Optional[DrivingOverlaySpeedUp]
 }
 
@@ -87,6 +96,16 @@ type DrivingCHSpeedUp struct {
 	ch       *comps.CH
 	ch_index Optional[*comps.CHIndex]
 }
+
+// Contraction hierarchy of the edge-based graph (used with turn-costs).
+type DrivingTurnCHSpeedUp struct {
+	base     *comps.GraphBase
+	weight   *comps.DefaultWeighting
+	ch       *comps.CH
+	ch_index Optional[*comps.CHIndex]
+	// maps edges of the graph to nodes of the edge-based graph
+	edge_mapping structs.IDMapping
+}
 type DrivingOverlaySpeedUp struct {
 	partition  *comps.Partition
 	overlay    *comps.Overlay
@@ -136,6 +155,22 @@ func (self *DrivingProfile) GetCHGraph() Optional[graph.ICHGraph] {
 	g := graph.BuildCHGraph(base, weight, ch, ch_index)
 	return Some(graph.ICHGraph(g))
 }
+
+// Returns the contraction hierarchy of the edge-based graph and the mapping from edges of the graph to its nodes.
+//
+// None if the profile has no turn-costs or hasn't been contracted.
+func (self *DrivingProfile) GetTurnCHGraph() Optional[Tuple[graph.ICHGraph, structs.IDMapping]] {
+	if !self.tc_ch_speed_up.HasValue() {
+		return None[Tuple[graph.ICHGraph, structs.IDMapping]]()
+	}
+	speed_up := self.tc_ch_speed_up.Value
+	if !speed_up.ch_index.HasValue() {
+		speed_up.ch_index = Some(preproc.PreparePHASTIndex(speed_up.base, speed_up.weight, speed_up.ch))
+		self.tc_ch_speed_up = Some(speed_up)
+	}
+	g := graph.BuildCHGraph(speed_up.base, speed_up.weight, speed_up.ch, speed_up.ch_index)
+	return Some(MakeTuple(graph.ICHGraph(g), speed_up.edge_mapping))
+}
 func (self *DrivingProfile) GetTiledGraph() Optional[graph.ITiledGraph] {
 	base := self.base
 	if !self.weight.HasValue() {
@@ -156,17 +191,18 @@ func (self *DrivingProfile) GetTransitGraph(schedule string) Optional[*graph.Tra
 	return None[*graph.TransitGraph]()
 }
 func (self *DrivingProfile) GetAttributes() attr.IAttributes {
-	att := self.manager._GetAttributes(DRIVING)
+	att := self.manager._GetAttributes(self.graph)
 	return attr.NewMappedAttributes(att, self.attr_node_mapping, self.attr_edge_mapping)
 }
 func (self *DrivingProfile) _GetMetadata() ProfileMeta {
 	meta := DrivingMeta{
 		Metric:  self.metric,
 		Vehicle: self.vehicle,
+		Graph:   self.graph,
 
 		TurnCosts: self.tc_weight.HasValue(),
 
-		CH:      self.ch_speed_up.HasValue(),
+		CH:      self.ch_speed_up.HasValue() || self.tc_ch_speed_up.HasValue(),
 		Overlay: self.overlay_speed_up.HasValue(),
 	}
 	meta_str, _ := json.Marshal(meta)
@@ -179,9 +215,11 @@ func (self *DrivingProfile) _GetMetadata() ProfileMeta {
 type DrivingMeta struct {
 	Metric  MetricType  `json:"metric"`
 	Vehicle VehicleType `json:"vehicle"`
+	Graph   string      `json:"graph"`
 
 	TurnCosts bool `json:"turn-costs"`
 
+	// contraction hierarchy, built on the edge-based graph if turn-costs are used
 	CH      bool `json:"ch"`
 	Overlay bool `json:"overlay"`
 }
@@ -192,6 +230,9 @@ func LoadDrivingProfile(path string, p_meta ProfileMeta) IRoutingProfile {
 	}
 	meta := DrivingMeta{}
 	json.Unmarshal(p_meta.Meta, &meta)
+	if meta.Graph == "" {
+		meta.Graph = DRIVING.String()
+	}
 
 	prefix := path
 
@@ -207,8 +248,16 @@ func LoadDrivingProfile(path string, p_meta ProfileMeta) IRoutingProfile {
 		tc_weight = None[comps.ITCWeighting]()
 	}
 	var ch_speed_up Optional[DrivingCHSpeedUp]
+	var tc_ch_speed_up Optional[DrivingTurnCHSpeedUp]
 	var overlay_speed_up Optional[DrivingOverlaySpeedUp]
-	if meta.CH {
+	if meta.CH && meta.TurnCosts {
+		tc_ch_speed_up = Some(DrivingTurnCHSpeedUp{
+			base:         comps.Load[*comps.GraphBase](prefix + "-tc_ch_base"),
+			weight:       comps.Load[*comps.DefaultWeighting](prefix + "-tc_ch_weight"),
+			ch:           comps.Load[*comps.CH](prefix + "-tc_ch"),
+			edge_mapping: structs.LoadIDMapping(prefix + "-tc_ch_edge_mapping"),
+		})
+	} else if meta.CH {
 		ch_speed_up = Some(DrivingCHSpeedUp{
 			ch: comps.Load[*comps.CH](prefix + "-ch"),
 		})
@@ -223,6 +272,7 @@ func LoadDrivingProfile(path string, p_meta ProfileMeta) IRoutingProfile {
 	return &DrivingProfile{
 		metric:  meta.Metric,
 		vehicle: meta.Vehicle,
+		graph:   meta.Graph,
 
 		base:              base,
 		attr_node_mapping: Some(attr_node_mapping),
@@ -230,6 +280,7 @@ func LoadDrivingProfile(path string, p_meta ProfileMeta) IRoutingProfile {
 		tc_weight:         tc_weight,
 
 		ch_speed_up:      ch_speed_up,
+		tc_ch_speed_up:   tc_ch_speed_up,
 		overlay_speed_up: overlay_speed_up,
 	}
 }
@@ -241,15 +292,16 @@ func BuildDrivingProfile(out_path string, source_ SourceOptions, options_ IProfi
 
 	var base *comps.GraphBase
 	var attributes *attr.GraphAttributes
-	if prep_cache.ContainsKey(DRIVING) {
+	graph_name, decoder := GetProfileDecoder(DRIVING, options.Decoder)
+	if prep_cache.ContainsKey(graph_name) {
 		slog.Info("Using cached graph")
-		item := prep_cache.Get(DRIVING)
+		item := prep_cache.Get(graph_name)
 		base = item.A
 		attributes = item.B
 	} else {
 		slog.Info("Parsing graph...")
 		// parse graph from osm
-		base, attributes = parser.ParseGraph(osm, &parser.DrivingDecoder{})
+		base, attributes = parser.ParseGraph(osm, decoder)
 		// remove closely connected components
 		slog.Info("Removing unconnected components...")
 		remove_nodes, remove_edges := RemoveConnectedComponents(base)
@@ -257,7 +309,7 @@ func BuildDrivingProfile(out_path string, source_ SourceOptions, options_ IProfi
 		base = comps.RemoveNodes(base, remove_nodes)
 		attributes.RemoveNodes(remove_nodes)
 		attributes.RemoveEdges(remove_edges)
-		prep_cache.Set(DRIVING, MakeTuple(base, attributes))
+		prep_cache.Set(graph_name, MakeTuple(base, attributes))
 		slog.Info("Successfully parsed graph")
 	}
 
@@ -265,6 +317,7 @@ func BuildDrivingProfile(out_path string, source_ SourceOptions, options_ IProfi
 	profile := &DrivingProfile{
 		metric:  options.Metric,
 		vehicle: options.Vehicle,
+		graph:   graph_name,
 	}
 
 	// build metric
@@ -290,7 +343,34 @@ func BuildDrivingProfile(out_path string, source_ SourceOptions, options_ IProfi
 	// node mapping of attributes of nodes are reordered
 	attr_node_mapping := structs.NewIdendityMapping(base.NodeCount())
 
-	if options.Preparation.Contraction {
+	if options.TurnRestrictions {
+		slog.Info("Building turn-costs from turn-restrictions")
+		tc_weight := BuildTCWeighting(base, weight, attributes.GetTurnRestrictions())
+		// the graph is kept in order since turn-costs can't be reordered
+		profile.attr_node_mapping = Some(attr_node_mapping)
+		profile.base = base
+		profile.tc_weight = Some(comps.ITCWeighting(tc_weight))
+		structs.StoreIDMapping(attr_node_mapping, prefix+"-attr_node_mapping")
+		comps.Store(base, prefix+"-base")
+		comps.Store(tc_weight, prefix+"-weight")
+		if options.Preparation.Contraction {
+			slog.Info("Building contraction hierarchy of edge-based graph")
+			edge_base, edge_weight, ch, edge_mapping := CreateTurnCH(base, tc_weight)
+			slog.Info("Contraction hierarchy successfully built")
+			profile.tc_ch_speed_up = Some(DrivingTurnCHSpeedUp{
+				base:         edge_base,
+				weight:       edge_weight,
+				ch:           ch,
+				edge_mapping: edge_mapping,
+			})
+			comps.Store(edge_base, prefix+"-tc_ch_base")
+			comps.Store(edge_weight, prefix+"-tc_ch_weight")
+			comps.Store(ch, prefix+"-tc_ch")
+			structs.StoreIDMapping(edge_mapping, prefix+"-tc_ch_edge_mapping")
+		} else if options.Preparation.Overlay {
+			panic("overlay is not supported with turn-restrictions")
+		}
+	} else if options.Preparation.Contraction {
 		slog.Info("Building contraction hierarchy")
 		new_base, ch, ordering := CreateCH(base, weight)
 		slog.Info("Contraction hierarchy successfully built")
@@ -360,6 +440,8 @@ type WalkingProfile struct {
 	manager *RoutingManager
 	metric  MetricType
 	vehicle VehicleType
+	// name of the osm-graph and attributes the profile is built from
+	graph string
 
 	base      comps.IGraphBase
 	weight    Optional[comps.IWeighting]
@@ -400,13 +482,14 @@ func (self *WalkingProfile) GetTransitGraph(schedule string) Optional[*graph.Tra
 	return None[*graph.TransitGraph]()
 }
 func (self *WalkingProfile) GetAttributes() attr.IAttributes {
-	att := self.manager._GetAttributes(WALKING)
+	att := self.manager._GetAttributes(self.graph)
 	return attr.NewMappedAttributes(att, None[structs.IDMapping](), None[structs.IDMapping]())
 }
 func (self *WalkingProfile) _GetMetadata() ProfileMeta {
 	meta := WalkingMeta{
 		Metric:  self.metric,
 		Vehicle: self.vehicle,
+		Graph:   self.graph,
 
 		TurnCosts: self.tc_weight.HasValue(),
 	}
@@ -420,6 +503,7 @@ func (self *WalkingProfile) _GetMetadata() ProfileMeta {
 type WalkingMeta struct {
 	Metric  MetricType  `json:"metric"`
 	Vehicle VehicleType `json:"vehicle"`
+	Graph   string      `json:"graph"`
 
 	TurnCosts bool `json:"turn-costs"`
 }
@@ -430,6 +514,9 @@ func LoadWalkingProfile(path string, p_meta ProfileMeta) IRoutingProfile {
 	}
 	meta := WalkingMeta{}
 	json.Unmarshal(p_meta.Meta, &meta)
+	if meta.Graph == "" {
+		meta.Graph = WALKING.String()
+	}
 
 	prefix := path
 
@@ -447,6 +534,7 @@ func LoadWalkingProfile(path string, p_meta ProfileMeta) IRoutingProfile {
 	return &WalkingProfile{
 		metric:  meta.Metric,
 		vehicle: meta.Vehicle,
+		graph:   meta.Graph,
 
 		base:      base,
 		weight:    weight,
@@ -460,26 +548,28 @@ func BuildWalkingProfile(out_path string, source_ SourceOptions, options_ IProfi
 
 	var base *comps.GraphBase
 	var attributes *attr.GraphAttributes
-	if prep_cache.ContainsKey(WALKING) {
-		item := prep_cache.Get(WALKING)
+	graph_name, decoder := GetProfileDecoder(WALKING, options.Decoder)
+	if prep_cache.ContainsKey(graph_name) {
+		item := prep_cache.Get(graph_name)
 		base = item.A
 		attributes = item.B
 	} else {
 		// parse graph from osm
-		base, attributes = parser.ParseGraph(osm, &parser.WalkingDecoder{})
+		base, attributes = parser.ParseGraph(osm, decoder)
 		// remove closely connected components
 		remove_nodes, remove_edges := RemoveConnectedComponents(base)
 		slog.Info(fmt.Sprintf("removed %v nodes", remove_nodes.Length()))
 		base = comps.RemoveNodes(base, remove_nodes)
 		attributes.RemoveNodes(remove_nodes)
 		attributes.RemoveEdges(remove_edges)
-		prep_cache.Set(WALKING, MakeTuple(base, attributes))
+		prep_cache.Set(graph_name, MakeTuple(base, attributes))
 	}
 
 	// build profile
 	profile := &WalkingProfile{
 		metric:  options.Metric,
 		vehicle: options.Vehicle,
+		graph:   graph_name,
 	}
 
 	// build metric
@@ -518,6 +608,8 @@ type CyclingProfile struct {
 	manager *RoutingManager
 	metric  MetricType
 	vehicle VehicleType
+	// name of the osm-graph and attributes the profile is built from
+	graph string
 
 	base      comps.IGraphBase
 	weight    Optional[comps.IWeighting]
@@ -558,13 +650,14 @@ func (self *CyclingProfile) GetTransitGraph(schedule string) Optional[*graph.Tra
 	return None[*graph.TransitGraph]()
 }
 func (self *CyclingProfile) GetAttributes() attr.IAttributes {
-	att := self.manager._GetAttributes(WALKING)
+	att := self.manager._GetAttributes(self.graph)
 	return attr.NewMappedAttributes(att, None[structs.IDMapping](), None[structs.IDMapping]())
 }
 func (self *CyclingProfile) _GetMetadata() ProfileMeta {
 	meta := CyclingMeta{
 		Metric:  self.metric,
 		Vehicle: self.vehicle,
+		Graph:   self.graph,
 
 		TurnCosts: self.tc_weight.HasValue(),
 	}
@@ -578,6 +671,7 @@ func (self *CyclingProfile) _GetMetadata() ProfileMeta {
 type CyclingMeta struct {
 	Metric  MetricType  `json:"metric"`
 	Vehicle VehicleType `json:"vehicle"`
+	Graph   string      `json:"graph"`
 
 	TurnCosts bool `json:"turn-costs"`
 }
@@ -588,6 +682,9 @@ func LoadCyclingProfile(path string, p_meta ProfileMeta) IRoutingProfile {
 	}
 	meta := CyclingMeta{}
 	json.Unmarshal(p_meta.Meta, &meta)
+	if meta.Graph == "" {
+		meta.Graph = CYCLING.String()
+	}
 
 	prefix := path
 
@@ -605,6 +702,7 @@ func LoadCyclingProfile(path string, p_meta ProfileMeta) IRoutingProfile {
 	return &CyclingProfile{
 		metric:  meta.Metric,
 		vehicle: meta.Vehicle,
+		graph:   meta.Graph,
 
 		base:      base,
 		weight:    weight,
@@ -618,26 +716,28 @@ func BuildCyclingProfile(out_path string, source_ SourceOptions, options_ IProfi
 
 	var base *comps.GraphBase
 	var attributes *attr.GraphAttributes
-	if prep_cache.ContainsKey(CYCLING) {
-		item := prep_cache.Get(CYCLING)
+	graph_name, decoder := GetProfileDecoder(CYCLING, options.Decoder)
+	if prep_cache.ContainsKey(graph_name) {
+		item := prep_cache.Get(graph_name)
 		base = item.A
 		attributes = item.B
 	} else {
 		// parse graph from osm
-		base, attributes = parser.ParseGraph(osm, &parser.CyclingDecoder{})
+		base, attributes = parser.ParseGraph(osm, decoder)
 		// remove closely connected components
 		remove_nodes, remove_edges := RemoveConnectedComponents(base)
 		slog.Info(fmt.Sprintf("removed %v nodes", remove_nodes.Length()))
 		base = comps.RemoveNodes(base, remove_nodes)
 		attributes.RemoveNodes(remove_nodes)
 		attributes.RemoveEdges(remove_edges)
-		prep_cache.Set(CYCLING, MakeTuple(base, attributes))
+		prep_cache.Set(graph_name, MakeTuple(base, attributes))
 	}
 
 	// build profile
 	profile := &CyclingProfile{
 		metric:  options.Metric,
 		vehicle: options.Vehicle,
+		graph:   graph_name,
 	}
 
 	// build metric
@@ -681,6 +781,24 @@ type TransitProfile struct {
 	tc_weight       comps.ITCWeighting
 	transit         *comps.Transit
 	transit_weights Dict[string, *comps.TransitWeighting]
+	// routes of the transit connections indexed by their route-id
+	routes Array[structs.Route]
+	// one-to-many algorithm used for matrices
+	algorithm string
+	// trips of the schedules indexed by their trip-id and the stops of all GTFS stop-ids (used to match real-time updates)
+	trips    Array[structs.Trip]
+	stop_ids Dict[string, int32]
+	// schedules with real-time updates applied keyed by the schedule-day (weekday or date) they apply to,
+	// replaced as a whole so graphs in use are not affected
+	realtime_weights Dict[string, *comps.TransitWeighting]
+	realtime_lock    sync.RWMutex
+	// transfer options the transit has been prepared with (stored with the profile, used to connect the stops of scenarios)
+	max_transfer_range int32
+	min_transfer_time  int32
+	transfer_penalty   int32
+	// transit-data extended by hypothetical lines, keyed by their id (expired scenarios are removed when adding new ones)
+	scenarios     Dict[string, *TransitScenario]
+	scenario_lock sync.RWMutex
 }
 
 func (self *TransitProfile) Profile() ProfileType {
@@ -707,17 +825,150 @@ func (self *TransitProfile) GetTiledGraph() Optional[graph.ITiledGraph] {
 	return None[graph.ITiledGraph]()
 }
 func (self *TransitProfile) GetTransitGraph(schedule string) Optional[*graph.TransitGraph] {
-	base := self.base
-	transit := self.transit
+	transit_weight := self.GetTransitWeighting(schedule)
+	if !transit_weight.HasValue() {
+		return None[*graph.TransitGraph]()
+	}
+	g := graph.BuildTransitGraph(self.base, self.tc_weight, self.transit, transit_weight.Value)
+	return Some(g)
+}
+
+// Returns the schedule of the schedule-day with its real-time updates applied.
+func (self *TransitProfile) GetTransitWeighting(schedule string) Optional[*comps.TransitWeighting] {
+	schedule_, ok := self.GetSchedule(schedule)
+	if !ok {
+		return None[*comps.TransitWeighting]()
+	}
+	// updates of a date don't apply to the other days sharing its weekday schedule
+	self.realtime_lock.RLock()
+	transit_weight, ok := self.realtime_weights[schedule]
+	self.realtime_lock.RUnlock()
+	if !ok {
+		transit_weight = self.transit_weights[schedule_]
+	}
+	return Some(transit_weight)
+}
+
+// Returns the name of the built schedule used for the schedule-day.
+//
+// Dates fall back to the weekday schedules if no date schedules have been built.
+func (self *TransitProfile) GetSchedule(schedule string) (string, bool) {
+	if self.transit_weights.ContainsKey(schedule) {
+		return schedule, true
+	}
+	date, err := time.Parse("2006-01-02", schedule)
+	if err != nil {
+		return "", false
+	}
+	schedule = strings.ToLower(date.Weekday().String())
 	if !self.transit_weights.ContainsKey(schedule) {
+		return "", false
+	}
+	return schedule, true
+}
+
+// Returns the static schedule as built from the GTFS feeds.
+func (self *TransitProfile) GetStaticWeighting(schedule string) *comps.TransitWeighting {
+	return self.transit_weights[schedule]
+}
+
+// Replaces the real-time updated schedule of the schedule-day (weekday or date), None removes all real-time updates.
+func (self *TransitProfile) SetRealtimeWeighting(schedule_day string, weight Optional[*comps.TransitWeighting]) {
+	self.realtime_lock.Lock()
+	defer self.realtime_lock.Unlock()
+	if self.realtime_weights == nil {
+		self.realtime_weights = NewDict[string, *comps.TransitWeighting](1)
+	}
+	if weight.HasValue() {
+		self.realtime_weights[schedule_day] = weight.Value
+	} else {
+		self.realtime_weights.Delete(schedule_day)
+	}
+}
+func (self *TransitProfile) GetTransit() *comps.Transit {
+	return self.transit
+}
+func (self *TransitProfile) GetRoutes() Array[structs.Route] {
+	return self.routes
+}
+func (self *TransitProfile) GetTrips() Array[structs.Trip] {
+	return self.trips
+}
+func (self *TransitProfile) GetStopIDs() Dict[string, int32] {
+	return self.stop_ids
+}
+func (self *TransitProfile) GetAlgorithm() string {
+	return self.algorithm
+}
+
+// Applies the options not stored with the built profile (e.g. the one-to-many algorithm).
+func (self *TransitProfile) _SetRuntimeOptions(options TransitOptions) {
+	self.algorithm = options.Algorithm
+}
+
+// Returns the transit-graph of the scenario using the schedule-day.
+func (self *TransitProfile) GetScenarioTransitGraph(scenario string, schedule string) Optional[*graph.TransitGraph] {
+	scenario_ := self.GetScenario(scenario)
+	if !scenario_.HasValue() {
 		return None[*graph.TransitGraph]()
 	}
-	transit_weight := self.transit_weights[schedule]
-	g := graph.BuildTransitGraph(base, self.tc_weight, transit, transit_weight)
+	schedule_, ok := self.GetSchedule(schedule)
+	if !ok {
+		return None[*graph.TransitGraph]()
+	}
+	s := scenario_.Value
+	g := graph.BuildTransitGraph(self.base, self.tc_weight, s.transit, s.transit_weights[schedule_])
 	return Some(g)
 }
+
+// Returns the scenario if it exists and has not expired.
+func (self *TransitProfile) GetScenario(scenario string) Optional[*TransitScenario] {
+	self.scenario_lock.RLock()
+	defer self.scenario_lock.RUnlock()
+	value, ok := self.scenarios[scenario]
+	if !ok || value.IsExpired(time.Now()) {
+		return None[*TransitScenario]()
+	}
+	return Some(value)
+}
+
+// Adds the scenario, expired scenarios are removed and the oldest ones are evicted to keep at most max_count scenarios (unlimited if <= 0).
+func (self *TransitProfile) SetScenario(scenario string, value *TransitScenario, max_count int) {
+	self.scenario_lock.Lock()
+	defer self.scenario_lock.Unlock()
+	if self.scenarios == nil {
+		self.scenarios = NewDict[string, *TransitScenario](1)
+	}
+	now := time.Now()
+	for id, s := range self.scenarios {
+		if s.IsExpired(now) {
+			self.scenarios.Delete(id)
+		}
+	}
+	self.scenarios.Delete(scenario)
+	for max_count > 0 && self.scenarios.Length() >= max_count {
+		oldest := ""
+		for id, s := range self.scenarios {
+			if oldest == "" || s.info.Created.Before(self.scenarios[oldest].info.Created) {
+				oldest = id
+			}
+		}
+		slog.Info("Evicting transit scenario " + oldest)
+		self.scenarios.Delete(oldest)
+	}
+	self.scenarios[scenario] = value
+}
+func (self *TransitProfile) RemoveScenario(scenario string) bool {
+	self.scenario_lock.Lock()
+	defer self.scenario_lock.Unlock()
+	if !self.scenarios.ContainsKey(scenario) {
+		return false
+	}
+	self.scenarios.Delete(scenario)
+	return true
+}
 func (self *TransitProfile) GetAttributes() attr.IAttributes {
-	att := self.manager._GetAttributes(WALKING)
+	att := self.manager._GetAttributes(_GetTransitBaseType(self.vehicle).String())
 	return attr.NewMappedAttributes(att, None[structs.IDMapping](), None[structs.IDMapping]())
 }
 func (self *TransitProfile) _GetMetadata() ProfileMeta {
@@ -730,6 +981,10 @@ func (self *TransitProfile) _GetMetadata() ProfileMeta {
 		Vehicle: self.vehicle,
 
 		Weights: weights,
+
+		MaxTransferRange: self.max_transfer_range,
+		MinTransferTime:  self.min_transfer_time,
+		TransferPenalty:  self.transfer_penalty,
 	}
 	meta_str, _ := json.Marshal(meta)
 	return ProfileMeta{
@@ -743,6 +998,10 @@ type TransitMeta struct {
 	Vehicle VehicleType `json:"vehicle"`
 
 	Weights []string `json:"weights"`
+
+	MaxTransferRange int32 `json:"max_transfer_range"`
+	MinTransferTime  int32 `json:"min_transfer_time"`
+	TransferPenalty  int32 `json:"transfer_penalty"`
 }
 
 func LoadTransitProfile(path string, p_meta ProfileMeta) IRoutingProfile {
@@ -762,6 +1021,18 @@ func LoadTransitProfile(path string, p_meta ProfileMeta) IRoutingProfile {
 	for _, w := range meta.Weights {
 		transit_weights[w] = comps.Load[*comps.TransitWeighting](prefix + "-transit-weight-" + w)
 	}
+	var routes Array[structs.Route]
+	if _, err := os.Stat(prefix + "-routes.json"); err == nil {
+		routes = ReadJSONFromFile[Array[structs.Route]](prefix + "-routes.json")
+	}
+	var trips Array[structs.Trip]
+	if _, err := os.Stat(prefix + "-trips.json"); err == nil {
+		trips = ReadJSONFromFile[Array[structs.Trip]](prefix + "-trips.json")
+	}
+	var stop_ids Dict[string, int32]
+	if _, err := os.Stat(prefix + "-stop-ids.json"); err == nil {
+		stop_ids = ReadJSONFromFile[Dict[string, int32]](prefix + "-stop-ids.json")
+	}
 
 	return &TransitProfile{
 		metric:  meta.Metric,
@@ -771,6 +1042,25 @@ func LoadTransitProfile(path string, p_meta ProfileMeta) IRoutingProfile {
 		tc_weight:       tc_weight,
 		transit:         transit,
 		transit_weights: transit_weights,
+		routes:          routes,
+		trips:           trips,
+		stop_ids:        stop_ids,
+
+		max_transfer_range: meta.MaxTransferRange,
+		min_transfer_time:  meta.MinTransferTime,
+		transfer_penalty:   meta.TransferPenalty,
+	}
+}
+
+// Returns the type of the graph the transit-network is embedded into.
+func _GetTransitBaseType(vehicle VehicleType) ProfileType {
+	switch vehicle {
+	case CAR:
+		return DRIVING
+	case BIKE:
+		return CYCLING
+	default:
+		return WALKING
 	}
 }
 
@@ -779,20 +1069,13 @@ func BuildTransitProfile(out_path string, source_ SourceOptions, options_ IProfi
 	osm := source_.OSM
 	gtfs := source_.GTFS
 
-	var prep_type ProfileType
-	switch options.Vehicle {
-	case CAR:
-		prep_type = DRIVING
-	case BIKE:
-		prep_type = CYCLING
-	default:
-		prep_type = WALKING
-	}
+	prep_type := _GetTransitBaseType(options.Vehicle)
+	graph_name := prep_type.String()
 
 	var base *comps.GraphBase
 	var attributes *attr.GraphAttributes
-	if prep_cache.ContainsKey(prep_type) {
-		item := prep_cache.Get(prep_type)
+	if prep_cache.ContainsKey(graph_name) {
+		item := prep_cache.Get(graph_name)
 		base = item.A
 		attributes = item.B
 	} else {
@@ -804,14 +1087,19 @@ func BuildTransitProfile(out_path string, source_ SourceOptions, options_ IProfi
 		base = comps.RemoveNodes(base, remove_nodes)
 		attributes.RemoveNodes(remove_nodes)
 		attributes.RemoveEdges(remove_edges)
-		prep_cache.Set(prep_type, MakeTuple(base, attributes))
+		prep_cache.Set(graph_name, MakeTuple(base, attributes))
 	}
 
 	// build profile
 	profile := &TransitProfile{
 		metric:  FASTEST,
 		vehicle: options.Vehicle,
+
+		max_transfer_range: options.Preparation.MaxTransferRange,
+		min_transfer_time:  options.Preparation.MinTransferTime,
+		transfer_penalty:   options.Preparation.TransferPenalty,
 	}
+	profile._SetRuntimeOptions(options)
 
 	// build metric
 	var weight *comps.DefaultWeighting
@@ -835,9 +1123,16 @@ func BuildTransitProfile(out_path string, source_ SourceOptions, options_ IProfi
 	comps.Store(base, prefix+"-base")
 	comps.Store(weight, prefix+"-weight")
 
-	stops, conns, schedules := parser.ParseGtfs(gtfs, options.Preparation.FilterPolygon)
+	dates := _GetScheduleDates(options.Preparation.ScheduleDates.From, options.Preparation.ScheduleDates.To)
+	stops, conns, schedules, routes, transfers, trips, stop_ids := parser.ParseGtfs(gtfs, _GetGTFSFilter(options, base), dates, options.Preparation.StopMergeDistance)
+	profile.routes = routes
+	WriteJSONToFile(routes, prefix+"-routes.json")
+	profile.trips = trips
+	WriteJSONToFile(trips, prefix+"-trips.json")
+	profile.stop_ids = stop_ids
+	WriteJSONToFile(stop_ids, prefix+"-stop-ids.json")
 	g := graph.BuildGraph(base, weight)
-	transit := preproc.PrepareTransit(g, stops, conns, options.Preparation.MaxTransferRange)
+	transit := preproc.PrepareTransit(g, stops, conns, transfers, options.Preparation.MaxTransferRange, options.Preparation.MinTransferTime, options.Preparation.TransferPenalty)
 	profile.transit = transit
 	comps.Store(transit, prefix+"-transit")
 	transit_weights := NewDict[string, *comps.TransitWeighting](7)
@@ -854,3 +1149,53 @@ func BuildTransitProfile(out_path string, source_ SourceOptions, options_ IProfi
 
 	return profile
 }
+
+// Builds the filter of the GTFS stops from the preparation options, all filters set are intersected.
+func _GetGTFSFilter(options TransitOptions, base *comps.GraphBase) parser.GTFSFilter {
+	filter := parser.NewGTFSFilter()
+	if options.Preparation.FilterPolygon != "" {
+		filter.ReadPolygons(options.Preparation.FilterPolygon)
+	}
+	if options.Preparation.FilterBBox != nil {
+		bbox := options.Preparation.FilterBBox
+		if len(bbox) != 4 {
+			panic("filter-bbox has to contain [min_lon, min_lat, max_lon, max_lat]")
+		}
+		filter.ClipToBBox(geo.Envelope{bbox[0], bbox[1], bbox[2], bbox[3]})
+	}
+	if options.Preparation.ClipToOSM && base.NodeCount() > 0 {
+		extent := geo.Envelope{180, 90, -180, -90}
+		for i := 0; i < base.NodeCount(); i++ {
+			loc := base.GetNode(int32(i)).Loc
+			extent = geo.Envelope{min(extent[0], loc[0]), min(extent[1], loc[1]), max(extent[2], loc[0]), max(extent[3], loc[1])}
+		}
+		filter.ClipToBBox(extent)
+	}
+	return filter
+}
+
+// Returns all dates from "from" to "to" (inclusive), empty if no range is given.
+func _GetScheduleDates(from string, to string) []time.Time {
+	if from == "" && to == "" {
+		return nil
+	}
+	if to == "" {
+		to = from
+	}
+	start, err := time.Parse("2006-01-02", from)
+	if err != nil {
+		panic("invalid schedule date: " + from)
+	}
+	end, err := time.Parse("2006-01-02", to)
+	if err != nil {
+		panic("invalid schedule date: " + to)
+	}
+	if end.Before(start) {
+		panic("schedule dates have to be ascending")
+	}
+	dates := make([]time.Time, 0)
+	for date := start; !date.After(end); date = date.AddDate(0, 0, 1) {
+		dates = append(dates, date)
+	}
+	return dates
+}
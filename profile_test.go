@@ -0,0 +1,57 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ttpr0/go-routing/comps"
+	"github.com/ttpr0/go-routing/structs"
+	. "github.com/ttpr0/go-routing/util"
+)
+
+func TestTransitProfileStoresTransferOptions(t *testing.T) {
+	prefix := filepath.Join(t.TempDir(), "transit")
+	profile := create_realtime_profile()
+	base := comps.NewGraphBase(Array[structs.Node]{{}}, Array[structs.Edge]{})
+	weight := comps.NewDefaultWeighting(base)
+	profile.max_transfer_range = 900
+	profile.min_transfer_time = 120
+	profile.transfer_penalty = 300
+	comps.Store(base, prefix+"-base")
+	comps.Store(weight, prefix+"-weight")
+	comps.Store(profile.transit, prefix+"-transit")
+	comps.Store(profile.transit_weights["monday"], prefix+"-transit-weight-monday")
+
+	loaded := LoadTransitProfile(prefix, profile._GetMetadata()).(*TransitProfile)
+	// runtime options of a changed config don't affect the prepared transfers
+	loaded._SetRuntimeOptions(TransitOptions{})
+	if loaded.max_transfer_range != 900 || loaded.min_transfer_time != 120 || loaded.transfer_penalty != 300 {
+		t.Errorf("expected the transfer options 900, 120 and 300, got %v, %v and %v", loaded.max_transfer_range, loaded.min_transfer_time, loaded.transfer_penalty)
+	}
+}
+
+func TestTransitScenarioEviction(t *testing.T) {
+	profile := create_realtime_profile()
+	now := time.Now()
+	new_scenario := func(created time.Duration, ttl time.Duration) *TransitScenario {
+		return &TransitScenario{info: TransitScenarioResponse{Created: now.Add(created), Expires: now.Add(created + ttl)}}
+	}
+
+	profile.SetScenario("expired", new_scenario(-2*time.Hour, time.Hour), 3)
+	if scenario := profile.GetScenario("expired"); scenario.HasValue() {
+		t.Errorf("expected expired scenarios to be hidden")
+	}
+	profile.SetScenario("a", new_scenario(-3*time.Minute, time.Hour), 3)
+	if profile.scenarios.ContainsKey("expired") {
+		t.Errorf("expected expired scenarios to be removed")
+	}
+	profile.SetScenario("b", new_scenario(-2*time.Minute, time.Hour), 3)
+	profile.SetScenario("c", new_scenario(-1*time.Minute, time.Hour), 3)
+	profile.SetScenario("d", new_scenario(0, time.Hour), 3)
+	for id, expected := range map[string]bool{"a": false, "b": true, "c": true, "d": true} {
+		if scenario := profile.GetScenario(id); scenario.HasValue() != expected {
+			t.Errorf("scenario %v: expected existing %v", id, expected)
+		}
+	}
+}
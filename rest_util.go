@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"reflect"
 	"strconv"
+	"strings"
 
 	. "github.com/ttpr0/go-routing/util"
 	"golang.org/x/exp/slog"
@@ -43,9 +44,27 @@ func WriteResponse[T any](w http.ResponseWriter, resp T, status int) {
 	w.Write(data)
 }
 
+func WriteStreamResponse(w http.ResponseWriter, stream *StreamResult) {
+	w.Header().Set("Content-Type", stream.content_type)
+	w.WriteHeader(http.StatusOK)
+	err := stream.write(w)
+	if err != nil {
+		slog.Error("failed to write stream: " + err.Error())
+		return
+	}
+	slog.Info("successfully finished stream")
+}
+
 type Result struct {
 	result any
 	status int
+	stream *StreamResult
+}
+
+// Response body written incrementally to the client instead of being marshaled as a whole.
+type StreamResult struct {
+	content_type string
+	write        func(io.Writer) error
 }
 
 func OK[T any](value T) Result {
@@ -55,6 +74,26 @@ func OK[T any](value T) Result {
 	}
 }
 
+// Creates a result whose body is written by the given function.
+//
+// Writing starts after the handler returned, errors can only be logged since the status has already been sent.
+func Stream(content_type string, write func(io.Writer) error) Result {
+	return Result{
+		status: http.StatusOK,
+		stream: &StreamResult{
+			content_type: content_type,
+			write:        write,
+		},
+	}
+}
+
+func NotFound[T any](value T) Result {
+	return Result{
+		result: value,
+		status: http.StatusNotFound,
+	}
+}
+
 func BadRequest[T any](value T) Result {
 	return Result{
 		result: value,
@@ -70,11 +109,14 @@ func MapPost[F any](app *http.ServeMux, path string, handler func(F) Result) {
 			slog.Error("failed POST " + err.Error())
 			WriteResponse(w, NewErrorResponse(path, err.Error()), http.StatusInternalServerError)
 		}
+		_SetPathValues(r, &body)
 		res := handler(body)
 		_SetCORSHeaders(w)
 		if res.status != http.StatusOK {
 			slog.Error("failed POST " + path)
 			WriteResponse(w, NewErrorResponse(path, res.result), res.status)
+		} else if res.stream != nil {
+			WriteStreamResponse(w, res.stream)
 		} else {
 			slog.Info("successfully finished POST")
 			WriteResponse(w, res.result, res.status)
@@ -142,6 +184,8 @@ func MapGet[F any](app *http.ServeMux, path string, handler func(F) Result) {
 		if res.status != http.StatusOK {
 			slog.Error("failed GET " + path)
 			WriteResponse(w, NewErrorResponse(path, res.result), res.status)
+		} else if res.stream != nil {
+			WriteStreamResponse(w, res.stream)
 		} else {
 			slog.Info("successfully finished GET")
 			WriteResponse(w, res.result, res.status)
@@ -149,6 +193,58 @@ func MapGet[F any](app *http.ServeMux, path string, handler func(F) Result) {
 	})
 }
 
+// Maps handlers of resources addressed by an id following path (e.g. "/v1/jobs/{id}" and "/v1/jobs/{id}/result").
+//
+// Handlers are keyed by method, optionally followed by the sub-path after the id (e.g. "GET", "GET result", "DELETE").
+func MapResource(app *http.ServeMux, path string, handlers Dict[string, func(string) Result]) {
+	prefix := strings.TrimSuffix(path, "/") + "/"
+	app.HandleFunc(prefix, func(w http.ResponseWriter, r *http.Request) {
+		slog.Info(r.Method + " " + r.URL.Path)
+		tokens := strings.SplitN(strings.TrimPrefix(r.URL.Path, prefix), "/", 2)
+		id := tokens[0]
+		key := r.Method
+		if len(tokens) > 1 && tokens[1] != "" {
+			key += " " + tokens[1]
+		}
+		_SetCORSHeaders(w)
+		if id == "" || !handlers.ContainsKey(key) {
+			WriteResponse(w, NewErrorResponse(r.URL.Path, "Not found"), http.StatusNotFound)
+			return
+		}
+		res := handlers.Get(key)(id)
+		if res.status != http.StatusOK {
+			slog.Error("failed " + r.Method + " " + r.URL.Path)
+			WriteResponse(w, NewErrorResponse(r.URL.Path, res.result), res.status)
+		} else if res.stream != nil {
+			WriteStreamResponse(w, res.stream)
+		} else {
+			slog.Info("successfully finished " + r.Method)
+			WriteResponse(w, res.result, res.status)
+		}
+	})
+}
+
+// Sets string fields tagged with `path:"name"` to the value of the wildcard {name} in the request pattern.
+func _SetPathValues[F any](r *http.Request, body *F) {
+	value := reflect.ValueOf(body).Elem()
+	if value.Kind() != reflect.Struct {
+		return
+	}
+	typ := value.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name := field.Tag.Get("path")
+		if name == "" || field.Type.Kind() != reflect.String {
+			continue
+		}
+		path_value := r.PathValue(name)
+		if path_value == "" {
+			continue
+		}
+		value.Field(i).SetString(path_value)
+	}
+}
+
 type handler func(http.ResponseWriter, *http.Request)
 
 func _SetCORSHeaders(w http.ResponseWriter) {
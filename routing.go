@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math/rand"
 
+	"github.com/ttpr0/go-routing/attr"
 	"github.com/ttpr0/go-routing/geo"
 	"github.com/ttpr0/go-routing/routing"
 	. "github.com/ttpr0/go-routing/util"
@@ -21,6 +22,8 @@ type RoutingRequest struct {
 	Draw      bool      `json:"drawRouting"`
 	Alg       string    `json:"algorithm"`
 	Stepcount int       `json:"stepount"`
+	// if true the attributes and details of every edge are added to the features
+	Details bool `json:"details"`
 }
 
 type DrawContextRequest struct {
@@ -79,30 +82,11 @@ func HandleRoutingRequest(req RoutingRequest) Result {
 	att := profile.GetAttributes()
 	start_node, _ := att.GetClosestNode(start)
 	end_node, _ := att.GetClosestNode(end)
-	var alg routing.IShortestPath
-	switch req.Alg {
-	case "Dijkstra":
-		g := profile.GetGraph()
-		alg = routing.NewDijkstra(g.Value, start_node, end_node)
-	case "A*":
-		g := profile.GetGraph()
-		alg = routing.NewAStar(g.Value, start_node, end_node)
-	case "Bidirect-Dijkstra":
-		g := profile.GetGraph()
-		alg = routing.NewBidirectDijkstra(g.Value, start_node, end_node)
-	case "Bidirect-A*":
-		g := profile.GetGraph()
-		alg = routing.NewBidirectAStar(g.Value, start_node, end_node)
-	case "BODijkstra":
-		g := profile.GetTiledGraph()
-		alg = routing.NewBODijkstra(g.Value, start_node, end_node)
-	case "CH":
-		g := profile.GetCHGraph()
-		alg = routing.NewCH(g.Value, start_node, end_node)
-	default:
-		g := profile.GetGraph()
-		alg = routing.NewDijkstra(g.Value, start_node, end_node)
+	alg_, res := GetShortestPathAlgorithm(profile, req.Alg, start_node, end_node)
+	if !alg_.HasValue() {
+		return res
 	}
+	alg := alg_.Value
 	slog.Debug(fmt.Sprintf("Using algorithm: %v", req.Alg))
 	slog.Debug(fmt.Sprintf("Start Caluclating shortest path between %v and %v", start, end))
 	ok := alg.CalcShortestPath()
@@ -114,10 +98,57 @@ func HandleRoutingRequest(req RoutingRequest) Result {
 	path := alg.GetShortestPath()
 	slog.Debug("start building response")
 	resp := NewRoutingResponse(path.GetGeometry(att), true, int(req.Key))
+	if req.Details {
+		_AddRouteDetails(&resp, &path, att)
+	}
 	slog.Debug("reponse build")
 	return OK(resp)
 }
 
+// Creates the shortest-path algorithm, unknown algorithms fall back to dijkstra.
+//
+// Graphs with turn-costs only support the edge-based dijkstra, node-based algorithms would ignore the turn-restrictions.
+func GetShortestPathAlgorithm(profile IRoutingProfile, alg string, start_node, end_node int32) (Optional[routing.IShortestPath], Result) {
+	g := profile.GetGraph()
+	if g.HasValue() && HasTurnCosts(g.Value) {
+		switch alg {
+		case "", "Dijkstra":
+			return Some(routing.IShortestPath(routing.NewDijkstraTC(g.Value, start_node, end_node))), OK("")
+		default:
+			return None[routing.IShortestPath](), BadRequest(alg + " is not supported with turn-restrictions")
+		}
+	}
+	switch alg {
+	case "A*":
+		if g.HasValue() {
+			return Some(routing.IShortestPath(routing.NewAStar(g.Value, start_node, end_node))), OK("")
+		}
+	case "Bidirect-Dijkstra":
+		if g.HasValue() {
+			return Some(routing.IShortestPath(routing.NewBidirectDijkstra(g.Value, start_node, end_node))), OK("")
+		}
+	case "Bidirect-A*":
+		if g.HasValue() {
+			return Some(routing.IShortestPath(routing.NewBidirectAStar(g.Value, start_node, end_node))), OK("")
+		}
+	case "BODijkstra":
+		tiled_g := profile.GetTiledGraph()
+		if tiled_g.HasValue() {
+			return Some(routing.IShortestPath(routing.NewBODijkstra(tiled_g.Value, start_node, end_node))), OK("")
+		}
+	case "CH":
+		ch_g := profile.GetCHGraph()
+		if ch_g.HasValue() {
+			return Some(routing.IShortestPath(routing.NewCH(ch_g.Value, start_node, end_node))), OK("")
+		}
+	default:
+		if g.HasValue() {
+			return Some(routing.IShortestPath(routing.NewDijkstra(g.Value, start_node, end_node))), OK("")
+		}
+	}
+	return None[routing.IShortestPath](), BadRequest("Graph not found")
+}
+
 var algs_dict Dict[int, Tuple[IRoutingProfile, routing.IShortestPath]] = NewDict[int, Tuple[IRoutingProfile, routing.IShortestPath]](10)
 
 func HandleCreateContextRequest(req DrawContextRequest) Result {
@@ -132,30 +163,11 @@ func HandleCreateContextRequest(req DrawContextRequest) Result {
 	att := profile.GetAttributes()
 	start_node, _ := att.GetClosestNode(start)
 	end_node, _ := att.GetClosestNode(end)
-	var alg routing.IShortestPath
-	switch req.Algorithm {
-	case "Dijkstra":
-		g := profile.GetGraph()
-		alg = routing.NewDijkstra(g.Value, start_node, end_node)
-	case "A*":
-		g := profile.GetGraph()
-		alg = routing.NewAStar(g.Value, start_node, end_node)
-	case "Bidirect-Dijkstra":
-		g := profile.GetGraph()
-		alg = routing.NewBidirectDijkstra(g.Value, start_node, end_node)
-	case "Bidirect-A*":
-		g := profile.GetGraph()
-		alg = routing.NewBidirectAStar(g.Value, start_node, end_node)
-	case "BODijkstra":
-		g := profile.GetTiledGraph()
-		alg = routing.NewBODijkstra(g.Value, start_node, end_node)
-	case "CH":
-		g := profile.GetCHGraph()
-		alg = routing.NewCH(g.Value, start_node, end_node)
-	default:
-		g := profile.GetGraph()
-		alg = routing.NewDijkstra(g.Value, start_node, end_node)
+	alg_, res := GetShortestPathAlgorithm(profile, req.Algorithm, start_node, end_node)
+	if !alg_.HasValue() {
+		return res
 	}
+	alg := alg_.Value
 	key := -1
 	for {
 		k := rand.Intn(1000)
@@ -202,6 +214,39 @@ func HandleRoutingStepRequest(req DrawRoutingRequest) Result {
 // routing utilities
 //**********************************************************
 
+// Adds the attributes and details of the edges to the features of the response, node-details are of the node the edge leads to.
+func _AddRouteDetails(resp *RoutingResponse, path *routing.Path, att attr.IAttributes) {
+	nodes := path.GetNodes()
+	iter := path.EdgeIterator()
+	for i := 0; ; i++ {
+		edge, ok := iter.Next()
+		if !ok {
+			break
+		}
+		edge_attr := att.GetEdgeAttribs(edge)
+		edge_details := att.GetEdgeDetails(edge)
+		node_details := att.GetNodeDetails(nodes[i])
+		props := resp.Features[i].Properties()
+		props["type"] = edge_attr.Type
+		props["length"] = edge_attr.Length
+		props["maxspeed"] = edge_attr.Maxspeed
+		props["surface"] = edge_details.Surface
+		props["smoothness"] = edge_details.Smoothness
+		props["incline"] = edge_details.Incline
+		props["lanes"] = edge_details.Lanes
+		props["access"] = edge_details.Access
+		props["toll"] = edge_details.Toll
+		props["ferry"] = edge_details.Ferry
+		props["bridge"] = edge_details.Bridge
+		props["tunnel"] = edge_details.Tunnel
+		props["width"] = edge_details.Width
+		props["ascent"] = edge_details.Ascent
+		props["descent"] = edge_details.Descent
+		props["traffic_signals"] = node_details.TrafficSignals
+		props["barrier"] = node_details.Barrier
+	}
+}
+
 func ProfileFromAlg(alg string) Optional[IRoutingProfile] {
 	var profile Optional[IRoutingProfile]
 	switch alg {
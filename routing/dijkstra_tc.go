@@ -0,0 +1,136 @@
+package routing
+
+import (
+	"fmt"
+
+	"github.com/ttpr0/go-routing/comps"
+	"github.com/ttpr0/go-routing/graph"
+	. "github.com/ttpr0/go-routing/util"
+	"golang.org/x/exp/slog"
+)
+
+type flag_dtc struct {
+	path_length int32
+	prev_edge   int32
+	visited     bool
+}
+
+// Edge-based dijkstra respecting the turn-costs of the graph, restricted turns are never taken.
+type DijkstraTC struct {
+	heap     PriorityQueue[int32, int32]
+	start_id int32
+	end_id   int32
+	// last edge of the shortest path, -1 if not found
+	end_edge int32
+	graph    graph.IGraph
+	flags    []flag_dtc
+}
+
+func NewDijkstraTC(g graph.IGraph, start, end int32) *DijkstraTC {
+	d := DijkstraTC{graph: g, start_id: start, end_id: end, end_edge: -1}
+
+	flags := make([]flag_dtc, g.EdgeCount())
+	for i := 0; i < len(flags); i++ {
+		flags[i].path_length = 1000000000
+		flags[i].prev_edge = -1
+	}
+	d.flags = flags
+
+	heap := NewPriorityQueue[int32, int32](100)
+	explorer := g.GetGraphExplorer()
+	explorer.ForAdjacentEdges(start, graph.FORWARD, graph.ADJACENT_EDGES, func(ref graph.EdgeRef) {
+		weight := explorer.GetEdgeWeight(ref)
+		if weight < d.flags[ref.EdgeID].path_length {
+			d.flags[ref.EdgeID].path_length = weight
+			heap.Enqueue(ref.EdgeID, weight)
+		}
+	})
+	d.heap = heap
+
+	return &d
+}
+
+func (self *DijkstraTC) CalcShortestPath() bool {
+	if self.start_id == self.end_id {
+		return true
+	}
+	for {
+		ok, found := self._Step(nil)
+		if !ok {
+			return found
+		}
+	}
+}
+
+func (self *DijkstraTC) Steps(count int, handler func(int32)) bool {
+	if self.start_id == self.end_id {
+		return false
+	}
+	for c := 0; c < count; c++ {
+		ok, _ := self._Step(handler)
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Settles the next edge, returns false if the search is finished and if the end has been found.
+func (self *DijkstraTC) _Step(handler func(int32)) (bool, bool) {
+	explorer := self.graph.GetGraphExplorer()
+	for {
+		curr_id, ok := self.heap.Dequeue()
+		if !ok {
+			return false, false
+		}
+		curr_flag := self.flags[curr_id]
+		if curr_flag.visited {
+			continue
+		}
+		curr_flag.visited = true
+		self.flags[curr_id] = curr_flag
+		curr_edge := self.graph.GetEdge(curr_id)
+		if curr_edge.NodeB == self.end_id {
+			self.end_edge = curr_id
+			return false, true
+		}
+		curr_ref := graph.EdgeRef{EdgeID: curr_id, Type: 0, OtherID: curr_edge.NodeB}
+		explorer.ForAdjacentEdges(curr_edge.NodeB, graph.FORWARD, graph.ADJACENT_EDGES, func(ref graph.EdgeRef) {
+			other_flag := self.flags[ref.EdgeID]
+			if other_flag.visited {
+				return
+			}
+			turn_cost := explorer.GetTurnCost(curr_ref, curr_edge.NodeB, ref)
+			if turn_cost >= comps.TURN_RESTRICTED {
+				return
+			}
+			if handler != nil {
+				handler(ref.EdgeID)
+			}
+			new_length := curr_flag.path_length + explorer.GetEdgeWeight(ref) + turn_cost
+			if other_flag.path_length > new_length {
+				other_flag.prev_edge = curr_id
+				other_flag.path_length = new_length
+				self.heap.Enqueue(ref.EdgeID, new_length)
+			}
+			self.flags[ref.EdgeID] = other_flag
+		})
+		return true, false
+	}
+}
+
+func (self *DijkstraTC) GetShortestPath() Path {
+	path := make([]int32, 0, 10)
+	length := int32(0)
+	if self.end_edge != -1 {
+		length = self.flags[self.end_edge].path_length
+	}
+	for curr := self.end_edge; curr != -1; curr = self.flags[curr].prev_edge {
+		path = append(path, curr)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	slog.Debug(fmt.Sprintf("length: %v", length))
+	return NewPath(self.graph, path)
+}
@@ -0,0 +1,103 @@
+package routing
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/ttpr0/go-routing/comps"
+	"github.com/ttpr0/go-routing/geo"
+	"github.com/ttpr0/go-routing/graph"
+	"github.com/ttpr0/go-routing/structs"
+	. "github.com/ttpr0/go-routing/util"
+)
+
+// Creates the square 0-1-2-3 with two-way edges, 0-3 takes 15s all others 10s.
+//
+// If restricted, turning from 0->1 into 1->2 is forbidden.
+func create_turn_graph(restricted bool) graph.IGraph {
+	nodes := Array[structs.Node]{{Loc: geo.Coord{0, 0}}, {Loc: geo.Coord{1, 0}}, {Loc: geo.Coord{1, 1}}, {Loc: geo.Coord{0, 1}}}
+	edges := NewList[structs.Edge](8)
+	weights := NewList[int32](8)
+	for _, e := range [][3]int32{{0, 1, 10}, {1, 2, 10}, {2, 3, 10}, {3, 0, 15}} {
+		edges.Add(structs.Edge{NodeA: e[0], NodeB: e[1]})
+		edges.Add(structs.Edge{NodeA: e[1], NodeB: e[0]})
+		weights.Add(e[2])
+		weights.Add(e[2])
+	}
+	base := comps.NewGraphBase(nodes, Array[structs.Edge](edges))
+	weight := comps.NewTCWeighting(base)
+	for i, w := range weights {
+		weight.SetEdgeWeight(int32(i), w)
+	}
+	if restricted {
+		weight.SetTurnCost(0, 1, 2, comps.TURN_RESTRICTED)
+	}
+	return graph.BuildTCGraph(base, weight)
+}
+
+func TestDijkstraTC(t *testing.T) {
+	tests := []struct {
+		name       string
+		restricted bool
+		start      int32
+		end        int32
+		expected   []int32
+	}{
+		{"unrestricted", false, 0, 2, []int32{1, 2}},
+		{"restricted", true, 0, 2, []int32{3, 2}},
+		{"other direction", true, 2, 0, []int32{1, 0}},
+		{"start is end", true, 1, 1, []int32{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alg := NewDijkstraTC(create_turn_graph(tt.restricted), tt.start, tt.end)
+			if !alg.CalcShortestPath() {
+				t.Fatal("end not reached")
+			}
+			path := alg.GetShortestPath()
+			if nodes := path.GetNodes(); !slices.Equal(nodes, tt.expected) {
+				t.Errorf("expected nodes %v, got %v", tt.expected, nodes)
+			}
+		})
+	}
+}
+
+type test_spt_consumer struct {
+	values map[geo.Coord]int
+}
+
+func (self *test_spt_consumer) ConsumePoint(point geo.Coord, value int) {
+	if v, ok := self.values[point]; !ok || value < v {
+		self.values[point] = value
+	}
+}
+func (self *test_spt_consumer) ConsumeEdge(edge int32, start_value int, end_value int) {}
+
+func TestShortestPathTreeTC(t *testing.T) {
+	tests := []struct {
+		name       string
+		restricted bool
+		max_val    int32
+		expected   []int
+	}{
+		{"unrestricted", false, 100, []int{0, 10, 20, 15}},
+		{"restricted", true, 100, []int{0, 10, 25, 15}},
+		{"max value", true, 20, []int{0, 10, -1, 15}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := create_turn_graph(tt.restricted)
+			consumer := &test_spt_consumer{values: map[geo.Coord]int{}}
+			NewShortestPathTreeTC(g).CalcShortestPathTree(0, tt.max_val, consumer)
+			for node, expected := range tt.expected {
+				value, ok := consumer.values[g.GetNodeGeom(int32(node))]
+				if !ok {
+					value = -1
+				}
+				if value != expected {
+					t.Errorf("node %v: expected %v, got %v", node, expected, value)
+				}
+			}
+		})
+	}
+}
@@ -23,6 +23,15 @@ func (self *Path) GetGeometry(attributes attr.IAttributes) []geo.CoordArray {
 	}
 	return self.lines
 }
+
+// Returns the node every edge of the path leads to.
+func (self *Path) GetNodes() []int32 {
+	nodes := make([]int32, 0, len(self.path))
+	for _, edge_id := range self.path {
+		nodes = append(nodes, self.graph.GetEdge(edge_id).NodeB)
+	}
+	return nodes
+}
 func (self *Path) EdgeIterator() IIterator[int32] {
 	return &EdgeIterator{&self.path, 0}
 }
@@ -33,7 +42,7 @@ type EdgeIterator struct {
 }
 
 func (self *EdgeIterator) Next() (int32, bool) {
-	if len(*self.path) < self.curr {
+	if len(*self.path) <= self.curr {
 		return 0, false
 	} else {
 		self.curr += 1
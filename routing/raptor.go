@@ -0,0 +1,366 @@
+package routing
+
+import (
+	"github.com/ttpr0/go-routing/comps"
+	"github.com/ttpr0/go-routing/graph"
+	. "github.com/ttpr0/go-routing/util"
+)
+
+//*******************************************
+// raptor
+//*******************************************
+
+type raptor_label struct {
+	arrival int32
+	// round the label has been set in
+	round int32
+	// 0 = access-walk, 1 = ride, 2 = transfer
+	typ byte
+	// stop the ride has been boarded at or the transfer started at
+	prev_stop int32
+	// boarded connection or used shortcut
+	ref  graph.EdgeRef
+	conn comps.ConnectionWeight
+}
+
+type raptor_walk struct {
+	dist int32
+	ref  graph.EdgeRef
+	prev int32
+}
+
+// Round-based public-transit search (RAPTOR).
+//
+// Round k computes the earliest arrival at every stop using at most k rides, transfers between rides use the shortcuts between stops.
+// Access and egress are walked on the base graph.
+type RAPTOR struct {
+	g             *graph.TransitGraph
+	start_id      int32
+	end_id        int32
+	departure     int32
+	max_transfers int32
+	max_range     int32
+
+	labels   [][]raptor_label
+	best     []int32
+	access   []raptor_walk
+	egress   []raptor_walk
+	journeys List[RAPTORJourney]
+}
+
+// Journey of the pareto set.
+type RAPTORJourney struct {
+	Transfers int32
+	Arrival   int32
+	Steps     List[TransitPathStep]
+}
+
+// Creates a RAPTOR search from start to end leaving at departure.
+//
+// Journeys use at most max_transfers transfers and take at most max_range seconds.
+func NewRAPTOR(g *graph.TransitGraph, start, end int32, departure int32, max_transfers int32, max_range int32) *RAPTOR {
+	return &RAPTOR{
+		g:             g,
+		start_id:      start,
+		end_id:        end,
+		departure:     departure,
+		max_transfers: max_transfers,
+		max_range:     max_range,
+	}
+}
+
+// Computes the pareto set of journeys (arrival time × number of transfers).
+//
+// Returns false if end is not reachable.
+func (self *RAPTOR) CalcJourneys() bool {
+	g := self.g
+	stop_count := g.StopCount()
+	self.access = _CalcRAPTORWalk(g, self.start_id, graph.FORWARD, self.max_range)
+	self.egress = _CalcRAPTORWalk(g, self.end_id, graph.BACKWARD, self.max_range)
+
+	max_rounds := int(self.max_transfers) + 1
+	self.labels = make([][]raptor_label, max_rounds+1)
+	self.best = make([]int32, stop_count)
+	for i := 0; i < stop_count; i++ {
+		self.best[i] = 1000000000
+	}
+	for k := 0; k <= max_rounds; k++ {
+		self.labels[k] = make([]raptor_label, stop_count)
+		for i := 0; i < stop_count; i++ {
+			self.labels[k][i] = raptor_label{arrival: 1000000000, prev_stop: -1}
+		}
+	}
+
+	// round 0: walk to all stops
+	marked := NewList[int32](10)
+	for i := 0; i < stop_count; i++ {
+		node := g.MapStopToNode(int32(i))
+		if node == -1 {
+			continue
+		}
+		dist := self.access[node].dist
+		if dist > self.max_range {
+			continue
+		}
+		self.labels[0][i] = raptor_label{arrival: self.departure + dist, round: 0, typ: 0, prev_stop: -1}
+		self.best[i] = self.departure + dist
+		marked.Add(int32(i))
+	}
+
+	explorer := g.GetTransitExplorer()
+	for k := 1; k <= max_rounds; k++ {
+		curr := self.labels[k]
+		prev := self.labels[k-1]
+		copy(curr, prev)
+		// rides boarded at stops improved in the last round
+		ride_marked := NewList[int32](10)
+		is_marked := NewDict[int32, bool](10)
+		for _, stop := range marked {
+			board_time := prev[stop].arrival
+			if prev[stop].typ == 1 {
+				// changing vehicles requires the transfer-time of the stop
+				board_time += g.GetTransferTime(stop)
+			}
+			explorer.ForAdjacentEdges(stop, graph.FORWARD, graph.ADJACENT_ALL, func(ref graph.EdgeRef) {
+				if ref.IsShortcut() {
+					return
+				}
+				weight := explorer.GetConnectionWeight(ref, board_time)
+				if !weight.HasValue() {
+					return
+				}
+				board_ref := ref
+				board_conn := weight.Value
+				self.g.FollowTrip(ref, weight.Value, func(ref graph.EdgeRef, conn comps.ConnectionWeight) bool {
+					other := ref.OtherID
+					arrival := conn.Arrival
+					if arrival-self.departure > self.max_range {
+						return false
+					}
+					if arrival < self.best[other] && arrival < curr[other].arrival {
+						curr[other] = raptor_label{arrival: arrival, round: int32(k), typ: 1, prev_stop: stop, ref: board_ref, conn: board_conn}
+						self.best[other] = arrival
+						if !is_marked.ContainsKey(other) {
+							is_marked[other] = true
+							ride_marked.Add(other)
+						}
+					}
+					return true
+				})
+			})
+		}
+		// transfers from all stops reached by a ride
+		marked = NewList[int32](ride_marked.Length())
+		for _, stop := range ride_marked {
+			marked.Add(stop)
+		}
+		for _, stop := range ride_marked {
+			arrival := curr[stop].arrival
+			explorer.ForAdjacentEdges(stop, graph.FORWARD, graph.ADJACENT_ALL, func(ref graph.EdgeRef) {
+				if !ref.IsShortcut() {
+					return
+				}
+				other := ref.OtherID
+				new_arrival := arrival + explorer.GetShortcutWeight(ref)
+				if new_arrival-self.departure > self.max_range {
+					return
+				}
+				if new_arrival < self.best[other] && new_arrival < curr[other].arrival {
+					curr[other] = raptor_label{arrival: new_arrival, round: int32(k), typ: 2, prev_stop: stop, ref: ref}
+					self.best[other] = new_arrival
+					if !is_marked.ContainsKey(other) {
+						is_marked[other] = true
+						marked.Add(other)
+					}
+				}
+			})
+		}
+		if marked.Length() == 0 {
+			break
+		}
+	}
+
+	// collect the pareto set
+	self.journeys = NewList[RAPTORJourney](max_rounds + 1)
+	best_arrival := int32(1000000000)
+	if self.access[self.end_id].dist <= self.max_range {
+		best_arrival = self.departure + self.access[self.end_id].dist
+		self.journeys.Add(RAPTORJourney{
+			Transfers: 0,
+			Arrival:   best_arrival,
+			Steps:     self._GetWalkSteps(self.access, self.end_id, self.departure, false),
+		})
+	}
+	for k := 1; k <= max_rounds; k++ {
+		best_stop := int32(-1)
+		arrival := best_arrival
+		for i := 0; i < stop_count; i++ {
+			label := self.labels[k][i]
+			if label.round != int32(k) {
+				continue
+			}
+			node := g.MapStopToNode(int32(i))
+			if node == -1 || self.egress[node].dist > self.max_range {
+				continue
+			}
+			if label.arrival+self.egress[node].dist < arrival {
+				arrival = label.arrival + self.egress[node].dist
+				best_stop = int32(i)
+			}
+		}
+		if best_stop == -1 || arrival-self.departure > self.max_range {
+			continue
+		}
+		best_arrival = arrival
+		self.journeys.Add(RAPTORJourney{
+			Transfers: int32(k - 1),
+			Arrival:   arrival,
+			Steps:     self._GetJourneySteps(int32(k), best_stop),
+		})
+	}
+	return self.journeys.Length() > 0
+}
+
+// Returns the pareto set ordered by ascending number of transfers.
+func (self *RAPTOR) GetJourneys() List[RAPTORJourney] {
+	return self.journeys
+}
+
+func (self *RAPTOR) _GetJourneySteps(round int32, stop int32) List[TransitPathStep] {
+	g := self.g
+	// egress from the last stop
+	end_label := self.labels[round][stop]
+	steps := self._GetWalkSteps(self.egress, g.MapStopToNode(stop), end_label.arrival, true)
+	reversed := NewList[TransitPathStep](10)
+	for i := steps.Length() - 1; i >= 0; i-- {
+		reversed.Add(steps[i])
+	}
+	for {
+		label := self.labels[round][stop]
+		// labels might have been copied from earlier rounds
+		round = label.round
+		if label.typ == 0 {
+			break
+		}
+		if label.typ == 2 {
+			prev_label := self.labels[round][label.prev_stop]
+			reversed.Add(TransitPathStep{
+				Ref:       label.ref,
+				From:      g.MapStopToNode(label.prev_stop),
+				To:        g.MapStopToNode(stop),
+				Departure: prev_label.arrival,
+				Arrival:   label.arrival,
+			})
+			stop = label.prev_stop
+			continue
+		}
+		// follow the trip again to collect its connections
+		rides := NewList[TransitPathStep](4)
+		curr_stop := label.prev_stop
+		self.g.FollowTrip(label.ref, label.conn, func(ref graph.EdgeRef, conn comps.ConnectionWeight) bool {
+			rides.Add(TransitPathStep{
+				Ref:          ref,
+				IsConnection: true,
+				Trip:         conn.Trip,
+				From:         g.MapStopToNode(curr_stop),
+				To:           g.MapStopToNode(ref.OtherID),
+				Departure:    conn.Departure,
+				Arrival:      conn.Arrival,
+			})
+			curr_stop = ref.OtherID
+			return curr_stop != stop
+		})
+		for i := rides.Length() - 1; i >= 0; i-- {
+			reversed.Add(rides[i])
+		}
+		stop = label.prev_stop
+		round = label.round - 1
+	}
+	// access to the first stop
+	access := self._GetWalkSteps(self.access, g.MapStopToNode(stop), self.departure, false)
+	for i := access.Length() - 1; i >= 0; i-- {
+		reversed.Add(access[i])
+	}
+	steps = NewList[TransitPathStep](reversed.Length())
+	for i := reversed.Length() - 1; i >= 0; i-- {
+		steps.Add(reversed[i])
+	}
+	return steps
+}
+
+// Returns the walked steps between node and the root of the walk in travel order.
+//
+// For backward walks (egress) node is the start of the walk and time the departure, otherwise node is the end of the walk and time the departure at its root.
+func (self *RAPTOR) _GetWalkSteps(walk []raptor_walk, node int32, time int32, backward bool) List[TransitPathStep] {
+	steps := NewList[TransitPathStep](10)
+	curr := node
+	for walk[curr].prev != -1 {
+		flag := walk[curr]
+		steps.Add(TransitPathStep{
+			Ref:  flag.ref,
+			From: flag.prev,
+			To:   curr,
+		})
+		curr = flag.prev
+	}
+	if backward {
+		// steps are already in travel order
+		for i := 0; i < steps.Length(); i++ {
+			step := steps[i]
+			step.From, step.To = step.To, step.From
+			step.Departure = time + walk[node].dist - walk[step.From].dist
+			step.Arrival = time + walk[node].dist - walk[step.To].dist
+			steps[i] = step
+		}
+		return steps
+	}
+	for i, j := 0, steps.Length()-1; i < j; i, j = i+1, j-1 {
+		steps[i], steps[j] = steps[j], steps[i]
+	}
+	for i := 0; i < steps.Length(); i++ {
+		step := steps[i]
+		step.Departure = time + walk[step.From].dist
+		step.Arrival = time + walk[step.To].dist
+		steps[i] = step
+	}
+	return steps
+}
+
+// Computes walking distances from (forward) or to (backward) node on the base graph.
+func _CalcRAPTORWalk(g *graph.TransitGraph, node int32, dir graph.Direction, max_range int32) []raptor_walk {
+	flags := make([]raptor_walk, g.NodeCount())
+	for i := 0; i < len(flags); i++ {
+		flags[i] = raptor_walk{dist: 1000000000, prev: -1}
+	}
+	flags[node].dist = 0
+	visited := make([]bool, g.NodeCount())
+	heap := NewPriorityQueue[int32, int32](100)
+	heap.Enqueue(node, 0)
+	explorer := g.GetGraphExplorer()
+	for {
+		curr_id, ok := heap.Dequeue()
+		if !ok {
+			break
+		}
+		if visited[curr_id] {
+			continue
+		}
+		visited[curr_id] = true
+		curr_dist := flags[curr_id].dist
+		explorer.ForAdjacentEdges(curr_id, dir, graph.ADJACENT_EDGES, func(ref graph.EdgeRef) {
+			other_id := ref.OtherID
+			if visited[other_id] {
+				return
+			}
+			new_dist := curr_dist + explorer.GetEdgeWeight(ref)
+			if new_dist > max_range {
+				return
+			}
+			if new_dist < flags[other_id].dist {
+				flags[other_id] = raptor_walk{dist: new_dist, ref: ref, prev: curr_id}
+				heap.Enqueue(other_id, new_dist)
+			}
+		})
+	}
+	return flags
+}
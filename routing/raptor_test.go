@@ -0,0 +1,101 @@
+package routing
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/ttpr0/go-routing/comps"
+	"github.com/ttpr0/go-routing/geo"
+	"github.com/ttpr0/go-routing/graph"
+	"github.com/ttpr0/go-routing/structs"
+	. "github.com/ttpr0/go-routing/util"
+)
+
+// Creates four stops A-B-C-D (nodes 0-3) connected by walking edges of 3000s.
+//
+// A direct ride A->D (trip 0) arrives at 32400, A->B->D (trips 1, 2) at 30000 and A->B->C->D (trips 1, 3, 4) at 29700.
+// Changing vehicles at B leaves 100s.
+func create_raptor_graph(transfer_time_b int32) *graph.TransitGraph {
+	nodes := NewList[structs.Node](4)
+	for i := 0; i < 4; i++ {
+		nodes.Add(structs.Node{Loc: geo.Coord{float32(i) * 0.01, 0}})
+	}
+	edges := NewList[structs.Edge](6)
+	for i := int32(0); i < 3; i++ {
+		edges.Add(structs.Edge{NodeA: i, NodeB: i + 1})
+		edges.Add(structs.Edge{NodeA: i + 1, NodeB: i})
+	}
+	base := comps.NewGraphBase(Array[structs.Node](nodes), Array[structs.Edge](edges))
+	weight := comps.NewDefaultWeighting(base)
+	for i := 0; i < base.EdgeCount(); i++ {
+		weight.SetEdgeWeight(int32(i), 3000)
+	}
+
+	connections := Array[structs.Connection]{
+		{StopA: 0, StopB: 3},
+		{StopA: 0, StopB: 1},
+		{StopA: 1, StopB: 3},
+		{StopA: 1, StopB: 2},
+		{StopA: 2, StopB: 3},
+	}
+	transfer_times := Array[int32]{0, transfer_time_b, 0, 0}
+	transit := comps.NewTransit(structs.NewIdendityMapping(4), Array[structs.Node](nodes), connections, structs.NewShortcutStore(0, false), transfer_times)
+	transit_weight := comps.NewTransitWeighting(transit)
+	transit_weight.SetWeights(0, []comps.ConnectionWeight{{Departure: 30600, Arrival: 32400, Trip: 0}})
+	transit_weight.SetWeights(1, []comps.ConnectionWeight{{Departure: 28900, Arrival: 29100, Trip: 1}})
+	transit_weight.SetWeights(2, []comps.ConnectionWeight{{Departure: 29200, Arrival: 30000, Trip: 2}})
+	transit_weight.SetWeights(3, []comps.ConnectionWeight{{Departure: 29200, Arrival: 29400, Trip: 3}})
+	transit_weight.SetWeights(4, []comps.ConnectionWeight{{Departure: 29500, Arrival: 29700, Trip: 4}})
+	return graph.BuildTransitGraph(base, weight, transit, transit_weight)
+}
+
+func TestRAPTORJourneys(t *testing.T) {
+	type journey struct {
+		transfers int32
+		arrival   int32
+		trips     []int32
+	}
+	tests := []struct {
+		name            string
+		max_transfers   int32
+		transfer_time_b int32
+		expected        []journey
+	}{
+		{"pareto set", 5, 0, []journey{{0, 32400, []int32{0}}, {1, 30000, []int32{1, 2}}, {2, 29700, []int32{1, 3, 4}}}},
+		{"one transfer", 1, 0, []journey{{0, 32400, []int32{0}}, {1, 30000, []int32{1, 2}}}},
+		{"no transfers", 0, 0, []journey{{0, 32400, []int32{0}}}},
+		{"missed transfers", 5, 360, []journey{{0, 32400, []int32{0}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := create_raptor_graph(tt.transfer_time_b)
+			raptor := NewRAPTOR(g, 0, 3, 28800, tt.max_transfers, 7200)
+			if !raptor.CalcJourneys() {
+				t.Fatal("end not reached")
+			}
+			journeys := raptor.GetJourneys()
+			if journeys.Length() != len(tt.expected) {
+				t.Fatalf("expected %v journeys, got %v", len(tt.expected), journeys.Length())
+			}
+			for i, expected := range tt.expected {
+				journey := journeys[i]
+				if journey.Transfers != expected.transfers || journey.Arrival != expected.arrival {
+					t.Errorf("journey %v: expected %v transfers arriving at %v, got %v transfers arriving at %v", i, expected.transfers, expected.arrival, journey.Transfers, journey.Arrival)
+				}
+				trips := []int32{}
+				for _, step := range journey.Steps {
+					if step.IsConnection {
+						trips = append(trips, step.Trip)
+					}
+				}
+				if !slices.Equal(trips, expected.trips) {
+					t.Errorf("journey %v: expected trips %v, got %v", i, expected.trips, trips)
+				}
+				last := journey.Steps[journey.Steps.Length()-1]
+				if last.To != 3 || last.Arrival != expected.arrival {
+					t.Errorf("journey %v: last step ends at node %v at %v", i, last.To, last.Arrival)
+				}
+			}
+		})
+	}
+}
@@ -49,6 +49,12 @@ func NewShortestPathTree4(graph *graph.TransitGraph, from, to int32) *ShortestPa
 	return &d
 }
 
+// Sets the time-window during which routes are allowed to start.
+func (self *ShortestPathTree4) SetTimeWindow(from, to int32) {
+	self.from = from
+	self.to = to
+}
+
 func (self *ShortestPathTree4) CalcShortestPathTree(start, max_val int32, consumer ISPTConsumer) {
 	self.node_flags.Reset()
 	self.edge_flags.Reset()
@@ -67,6 +73,8 @@ type TransitItem struct {
 	time      int32
 	departure int32
 	stop      int32
+	// trip the stop has been reached with (-1 if reached by walking)
+	trip int32
 }
 
 func _CalcTransitDijkstra(g *graph.TransitGraph, starts Array[Tuple[int32, int32]], node_flags Flags[DistFlag], edge_flags Flags[EdgeDistFlag], stop_flags Flags[TransitFlag], max_range int32, from, to int32, consumer ISPTConsumer) {
@@ -76,6 +84,8 @@ func _CalcTransitDijkstra(g *graph.TransitGraph, starts Array[Tuple[int32, int32
 	// step 2: transit-dijkstra from all found stops
 	heap := NewPriorityQueue[TransitItem, int32](100)
 	explorer := g.GetTransitExplorer()
+	// latest departure at the start every trip has been boarded with at a connection
+	trip_departures := NewDict[Tuple[int32, int32], int32](100)
 
 	for i := 0; i < g.StopCount(); i++ {
 		base_node := g.MapStopToNode(int32(i))
@@ -88,14 +98,14 @@ func _CalcTransitDijkstra(g *graph.TransitGraph, starts Array[Tuple[int32, int32
 		}
 		dist := flag.Dist
 		time := to + dist
-		heap.Enqueue(TransitItem{time, to, int32(i)}, time)
+		heap.Enqueue(TransitItem{time, to, int32(i), -1}, time)
 		explorer.ForAdjacentEdges(int32(i), graph.FORWARD, graph.ADJACENT_ALL, func(ref graph.EdgeRef) {
 			if ref.IsShortcut() {
 				return
 			}
 			weights := explorer.GetConnectionWeights(ref, from+dist, to+dist)
 			for _, w := range weights {
-				heap.Enqueue(TransitItem{w.Departure, w.Departure - dist, int32(i)}, w.Departure)
+				heap.Enqueue(TransitItem{w.Departure, w.Departure - dist, int32(i), -1}, w.Departure)
 			}
 		})
 	}
@@ -104,6 +114,20 @@ func _CalcTransitDijkstra(g *graph.TransitGraph, starts Array[Tuple[int32, int32
 		if !ok {
 			break
 		}
+		// changing vehicles requires the transfer-time of the stop
+		ready := item.time
+		if item.trip != -1 {
+			ready += g.GetTransferTime(item.stop)
+			// staying on the vehicle is always possible
+			explorer.ForAdjacentEdges(item.stop, graph.FORWARD, graph.ADJACENT_ALL, func(ref graph.EdgeRef) {
+				for _, w := range explorer.GetConnectionWeights(ref, item.time, item.time+graph.MAX_DWELL_TIME) {
+					if w.Trip != item.trip || w.Arrival-item.departure > max_range {
+						continue
+					}
+					heap.Enqueue(TransitItem{w.Arrival, item.departure, ref.OtherID, w.Trip}, w.Arrival)
+				}
+			})
+		}
 		curr_flag := stop_flags.Get(item.stop)
 		prune := false
 		for _, trip := range curr_flag.trips {
@@ -129,9 +153,9 @@ func _CalcTransitDijkstra(g *graph.TransitGraph, starts Array[Tuple[int32, int32
 				if new_time-item.departure > max_range {
 					return
 				}
-				heap.Enqueue(TransitItem{new_time, item.departure, other_id}, new_time)
+				heap.Enqueue(TransitItem{new_time, item.departure, other_id, -1}, new_time)
 			} else {
-				weight := explorer.GetConnectionWeight(ref, item.time)
+				weight := explorer.GetConnectionWeight(ref, ready)
 				if !weight.HasValue() {
 					return
 				}
@@ -139,7 +163,14 @@ func _CalcTransitDijkstra(g *graph.TransitGraph, starts Array[Tuple[int32, int32
 				if new_time-item.departure > max_range {
 					return
 				}
-				heap.Enqueue(TransitItem{new_time, item.departure, other_id}, new_time)
+				// trips already boarded at the same connection with a later departure reach all following stops earlier
+				trip := weight.Value.Trip
+				key := MakeTuple(trip, ref.EdgeID)
+				if departure, ok := trip_departures[key]; ok && departure >= item.departure {
+					return
+				}
+				trip_departures[key] = item.departure
+				heap.Enqueue(TransitItem{new_time, item.departure, other_id, trip}, new_time)
 			}
 		})
 	}
@@ -29,12 +29,20 @@ func NewShortestPathTree5(graph graph.IGraph) *ShortestPathTree5 {
 }
 
 func (self *ShortestPathTree5) CalcShortestPathTree(start int32, max_val int32, consumer ISPTConsumer) {
+	// reset state of previous calls
+	self.heap.Clear()
+	for i := 0; i < len(self.flags); i++ {
+		self.flags[i] = flag_spt{path_length: 1000000000}
+	}
 	self.heap.Enqueue(start, 0)
 	self.flags[start].path_length = 0
 	explorer := self.graph.GetGraphExplorer()
 
 	for {
-		curr_id, _ := self.heap.Dequeue()
+		curr_id, ok := self.heap.Dequeue()
+		if !ok {
+			return
+		}
 		//curr := (*d.graph).GetNode(curr_id)
 		curr_flag := self.flags[curr_id]
 		if curr_flag.path_length > float64(max_val) {
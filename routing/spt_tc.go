@@ -0,0 +1,88 @@
+package routing
+
+import (
+	"github.com/ttpr0/go-routing/comps"
+	"github.com/ttpr0/go-routing/graph"
+	. "github.com/ttpr0/go-routing/util"
+)
+
+// Edge-based shortest-path-tree respecting the turn-costs of the graph.
+type ShortestPathTreeTC struct {
+	heap        PriorityQueue[int32, int32]
+	graph       graph.IGraph
+	edge_flags  []flag_dtc
+	node_visits []bool
+}
+
+func NewShortestPathTreeTC(g graph.IGraph) *ShortestPathTreeTC {
+	return &ShortestPathTreeTC{
+		heap:        NewPriorityQueue[int32, int32](100),
+		graph:       g,
+		edge_flags:  make([]flag_dtc, g.EdgeCount()),
+		node_visits: make([]bool, g.NodeCount()),
+	}
+}
+
+func (self *ShortestPathTreeTC) CalcShortestPathTree(start int32, max_val int32, consumer ISPTConsumer) {
+	// reset state of previous calls
+	self.heap.Clear()
+	for i := 0; i < len(self.edge_flags); i++ {
+		self.edge_flags[i] = flag_dtc{path_length: 1000000000, prev_edge: -1}
+	}
+	for i := 0; i < len(self.node_visits); i++ {
+		self.node_visits[i] = false
+	}
+	explorer := self.graph.GetGraphExplorer()
+
+	consumer.ConsumePoint(self.graph.GetNodeGeom(start), 0)
+	self.node_visits[start] = true
+	explorer.ForAdjacentEdges(start, graph.FORWARD, graph.ADJACENT_EDGES, func(ref graph.EdgeRef) {
+		weight := explorer.GetEdgeWeight(ref)
+		if weight < self.edge_flags[ref.EdgeID].path_length {
+			self.edge_flags[ref.EdgeID].path_length = weight
+			self.heap.Enqueue(ref.EdgeID, weight)
+			consumer.ConsumeEdge(ref.EdgeID, 0, int(weight))
+		}
+	})
+
+	for {
+		curr_id, ok := self.heap.Dequeue()
+		if !ok {
+			return
+		}
+		curr_flag := self.edge_flags[curr_id]
+		if curr_flag.path_length > max_val {
+			return
+		}
+		if curr_flag.visited {
+			continue
+		}
+		curr_flag.visited = true
+		self.edge_flags[curr_id] = curr_flag
+		curr_edge := self.graph.GetEdge(curr_id)
+		// the first edge settled at a node reaches it first
+		if !self.node_visits[curr_edge.NodeB] {
+			self.node_visits[curr_edge.NodeB] = true
+			consumer.ConsumePoint(self.graph.GetNodeGeom(curr_edge.NodeB), int(curr_flag.path_length))
+		}
+		curr_ref := graph.EdgeRef{EdgeID: curr_id, Type: 0, OtherID: curr_edge.NodeB}
+		explorer.ForAdjacentEdges(curr_edge.NodeB, graph.FORWARD, graph.ADJACENT_EDGES, func(ref graph.EdgeRef) {
+			other_flag := self.edge_flags[ref.EdgeID]
+			if other_flag.visited {
+				return
+			}
+			turn_cost := explorer.GetTurnCost(curr_ref, curr_edge.NodeB, ref)
+			if turn_cost >= comps.TURN_RESTRICTED {
+				return
+			}
+			new_length := curr_flag.path_length + explorer.GetEdgeWeight(ref) + turn_cost
+			if other_flag.path_length > new_length {
+				other_flag.prev_edge = curr_id
+				other_flag.path_length = new_length
+				self.heap.Enqueue(ref.EdgeID, new_length)
+				consumer.ConsumeEdge(ref.EdgeID, int(curr_flag.path_length), int(new_length))
+			}
+			self.edge_flags[ref.EdgeID] = other_flag
+		})
+	}
+}
@@ -3,6 +3,7 @@ package routing
 import (
 	"fmt"
 
+	"github.com/ttpr0/go-routing/comps"
 	"github.com/ttpr0/go-routing/graph"
 	. "github.com/ttpr0/go-routing/util"
 	"golang.org/x/exp/slog"
@@ -11,7 +12,11 @@ import (
 type flag_td struct {
 	path_length float64
 	ref         graph.EdgeRef
-	visited     bool
+	prev        int32
+	// true if the node has been reached using a transit connection
+	is_conn bool
+	conn    comps.ConnectionWeight
+	visited bool
 }
 
 type TransitDijkstra struct {
@@ -23,12 +28,14 @@ type TransitDijkstra struct {
 	flags     []flag_td
 }
 
-func NewTransitDijkstra(g *graph.TransitGraph, start, end int32) *TransitDijkstra {
-	d := TransitDijkstra{graph: g, start_id: start, end_id: end, departure: 36000}
+// Creates a time-dependent dijkstra computing the earliest arrival at end when leaving start at departure.
+func NewTransitDijkstra(g *graph.TransitGraph, start, end int32, departure int32) *TransitDijkstra {
+	d := TransitDijkstra{graph: g, start_id: start, end_id: end, departure: departure}
 
 	flags := make([]flag_td, g.NodeCount())
 	for i := 0; i < len(flags); i++ {
 		flags[i].path_length = 1000000000
+		flags[i].prev = -1
 	}
 	flags[start].path_length = 0
 	d.flags = flags
@@ -52,30 +59,50 @@ func (self *TransitDijkstra) CalcShortestPath() bool {
 		if curr_id == self.end_id {
 			return true
 		}
-		//curr := (*d.graph).GetNode(curr_id)
 		curr_flag := self.flags[curr_id]
 		if curr_flag.visited {
 			continue
 		}
 		curr_flag.visited = true
+		self.flags[curr_id] = curr_flag
 		arival := self.departure + int32(curr_flag.path_length)
 		if self.graph.IsStop(curr_id) {
-			transit_explorer.ForAdjacentEdges(curr_id, graph.FORWARD, graph.ADJACENT_ALL, func(ref graph.EdgeRef) {
+			curr_stop := self.graph.MapNodeToStop(curr_id)
+			transit_explorer.ForAdjacentEdges(curr_stop, graph.FORWARD, graph.ADJACENT_ALL, func(ref graph.EdgeRef) {
+				// transfers between stops are covered by the walking network
 				if ref.IsShortcut() {
 					return
 				}
-				other_id := ref.OtherID
+				other_id := self.graph.MapStopToNode(ref.OtherID)
+				if other_id == -1 {
+					return
+				}
 				other_flag := self.flags[other_id]
 				if other_flag.visited {
 					return
 				}
-				conn_weight := transit_explorer.GetConnectionWeight(ref, arival)
+				var conn_weight Optional[comps.ConnectionWeight]
+				if curr_flag.is_conn {
+					// staying on the vehicle is always possible, changing requires the transfer-time of the stop
+					conn_weight = transit_explorer.GetConnectionWeight(ref, arival+self.graph.GetTransferTime(curr_stop))
+					for _, w := range transit_explorer.GetConnectionWeights(ref, arival, arival+graph.MAX_DWELL_TIME) {
+						if w.Trip == curr_flag.conn.Trip && (!conn_weight.HasValue() || w.Arrival <= conn_weight.Value.Arrival) {
+							conn_weight = Some(w)
+							break
+						}
+					}
+				} else {
+					conn_weight = transit_explorer.GetConnectionWeight(ref, arival)
+				}
 				if !conn_weight.HasValue() {
 					return
 				}
 				new_length := float64(conn_weight.Value.Arrival - self.departure)
 				if other_flag.path_length > new_length {
 					other_flag.ref = ref
+					other_flag.prev = curr_id
+					other_flag.is_conn = true
+					other_flag.conn = conn_weight.Value
 					other_flag.path_length = new_length
 					self.heap.Enqueue(other_id, new_length)
 				}
@@ -92,12 +119,13 @@ func (self *TransitDijkstra) CalcShortestPath() bool {
 			new_length := curr_flag.path_length + edge_weight
 			if other_flag.path_length > new_length {
 				other_flag.ref = ref
+				other_flag.prev = curr_id
+				other_flag.is_conn = false
 				other_flag.path_length = new_length
 				self.heap.Enqueue(other_id, new_length)
 			}
 			self.flags[other_id] = other_flag
 		})
-		self.flags[curr_id] = curr_flag
 	}
 }
 
@@ -105,25 +133,57 @@ func (self *TransitDijkstra) Steps(count int, handler func(int32)) bool {
 	return false
 }
 
+// Returns the walked edges of the shortest path.
 func (self *TransitDijkstra) GetShortestPath() Path {
-	explorer := self.graph.GetGraphExplorer()
-
 	path := make([]int32, 0, 10)
-	length := int32(self.flags[self.end_id].path_length)
+	for _, step := range self.GetTransitPath() {
+		if !step.IsConnection {
+			path = append(path, step.Ref.EdgeID)
+		}
+	}
+	slog.Debug(fmt.Sprintf("length: %v", int32(self.flags[self.end_id].path_length)))
+	return NewPath(self.graph, path)
+}
+
+// Single step of a transit path, either a walked edge or a ridden connection.
+type TransitPathStep struct {
+	Ref          graph.EdgeRef
+	IsConnection bool
+	// trip of the connection (only set for connections)
+	Trip int32
+	// nodes the step starts and ends at
+	From int32
+	To   int32
+	// absolute times (in s)
+	Departure int32
+	Arrival   int32
+}
+
+// Returns all steps of the shortest path from start to end.
+func (self *TransitDijkstra) GetTransitPath() List[TransitPathStep] {
+	steps := NewList[TransitPathStep](10)
 	curr_id := self.end_id
-	for {
-		if curr_id == self.start_id {
-			break
+	for curr_id != self.start_id {
+		flag := self.flags[curr_id]
+		prev_flag := self.flags[flag.prev]
+		step := TransitPathStep{
+			Ref:          flag.ref,
+			IsConnection: flag.is_conn,
+			From:         flag.prev,
+			To:           curr_id,
+			Departure:    self.departure + int32(prev_flag.path_length),
+			Arrival:      self.departure + int32(flag.path_length),
 		}
-		ref := self.flags[curr_id].ref
-		if ref.IsEdge() {
-			path = append(path, ref.EdgeID)
+		if flag.is_conn {
+			step.Trip = flag.conn.Trip
+			step.Departure = flag.conn.Departure
+			step.Arrival = flag.conn.Arrival
 		}
-		curr_id = explorer.GetOtherNode(ref, curr_id)
+		steps.Add(step)
+		curr_id = flag.prev
 	}
-	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
-		path[i], path[j] = path[j], path[i]
+	for i, j := 0, len(steps)-1; i < j; i, j = i+1, j-1 {
+		steps[i], steps[j] = steps[j], steps[i]
 	}
-	slog.Debug(fmt.Sprintf("length: %v", length))
-	return NewPath(self.graph, path)
+	return steps
 }
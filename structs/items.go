@@ -19,16 +19,56 @@ type Node struct {
 	Loc geo.Coord
 }
 
+// Forbidden turn from edge From into edge To at node Via (e.g. from a turn-restriction).
+type TurnRestriction struct {
+	From int32
+	Via  int32
+	To   int32
+}
+
 type Connection struct {
 	StopA   int32
 	StopB   int32
 	RouteID int32
 }
 
+type Route struct {
+	ID        string
+	Agency    string
+	ShortName string
+	LongName  string
+	// GTFS route-type (e.g. 0 = tram, 1 = subway, 2 = rail, 3 = bus)
+	Type int32
+}
+
+// Trip of the transit-schedule, used to match real-time updates to the connections of the trip.
+type Trip struct {
+	// (namespaced) GTFS trip_id, trips expanded from frequencies.txt are suffixed by their start-time (e.g. "T1@28800")
+	ID string
+	// stops served by the trip in order (stops outside of the filter are removed)
+	Stops []TripStop
+}
+
+type TripStop struct {
+	Stop int32
+	// GTFS stop_sequence
+	Sequence int32
+}
+
 //*******************************************
 // shortcut struct
 //*******************************************
 
+// Transfer between two stops as given by GTFS transfers.txt.
+type Transfer struct {
+	StopA int32
+	StopB int32
+	// GTFS transfer_type (0: recommended, 1: timed, 2: minimum time required, 3: not possible)
+	Type int32
+	// minimum transfer time (in s), only set for type 2
+	MinTime int32
+}
+
 type Shortcut struct {
 	From    int32
 	To      int32
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/ttpr0/go-routing/comps"
+	"github.com/ttpr0/go-routing/parser"
+	"github.com/ttpr0/go-routing/structs"
+	. "github.com/ttpr0/go-routing/util"
+	"golang.org/x/exp/slog"
+)
+
+//**********************************************************
+// transit realtime request and response
+//**********************************************************
+
+// Applies the trip-updates of a GTFS-Realtime feed to a schedule of a public-transit profile.
+//
+// Every request replaces the updates applied before (feeds are treated as full datasets).
+type TransitRealtimeRequest struct {
+	Profile string `json:"profile"`
+	// weekday (e.g. "monday") or ISO-date (e.g. "2024-05-01") the updates apply to (defaults to the current date),
+	// updates of a date only apply to requests of the same date even if it uses a weekday schedule
+	ScheduleDay string `json:"schedule_day"`
+	// base64-encoded GTFS-Realtime FeedMessage (protobuf)
+	Data []byte `json:"data"`
+	// name of the GTFS feed the updates belong to if multiple feeds have been merged
+	Feed string `json:"feed"`
+	// IANA timezone of the schedule used to convert absolute times into delays (defaults to the local timezone)
+	Timezone string `json:"timezone"`
+	// removes all real-time updates of the schedule
+	Reset bool `json:"reset"`
+}
+
+type TransitRealtimeResponse struct {
+	Schedule       string `json:"schedule"`
+	UpdatedTrips   int    `json:"updated_trips"`
+	CanceledTrips  int    `json:"canceled_trips"`
+	UnmatchedTrips int    `json:"unmatched_trips"`
+}
+
+//**********************************************************
+// transit realtime handler
+//**********************************************************
+
+func HandleTransitRealtimeRequest(req TransitRealtimeRequest) Result {
+	slog.Info("Run Transit-Realtime Request")
+
+	if req.Profile == "" {
+		req.Profile = "transit-foot"
+	}
+	if req.ScheduleDay == "" {
+		req.ScheduleDay = time.Now().Format("2006-01-02")
+	}
+	profile_, res := GetRequestProfile(MANAGER, req.Profile, "time")
+	if !profile_.HasValue() {
+		return res
+	}
+	profile, ok := profile_.Value.(*TransitProfile)
+	if !ok {
+		return BadRequest("Profile is not a public-transit profile")
+	}
+	schedule, ok := profile.GetSchedule(req.ScheduleDay)
+	if !ok {
+		return BadRequest("Schedule not found")
+	}
+	if req.Reset {
+		profile.SetRealtimeWeighting(req.ScheduleDay, None[*comps.TransitWeighting]())
+		return OK(TransitRealtimeResponse{Schedule: schedule})
+	}
+	if profile.GetTrips() == nil {
+		return BadRequest("Profile contains no trips, graphs have to be rebuilt to apply real-time updates")
+	}
+
+	if len(req.Data) == 0 {
+		return BadRequest("No GTFS-Realtime data given")
+	}
+	updates, err := parser.ParseTripUpdates(req.Data)
+	if err != nil {
+		return BadRequest("Invalid GTFS-Realtime feed: " + err.Error())
+	}
+	location := time.Local
+	if req.Timezone != "" {
+		location, err = time.LoadLocation(req.Timezone)
+		if err != nil {
+			return BadRequest("Invalid timezone")
+		}
+	}
+	// trips of other service-dates are only skipped if the schedule belongs to a date
+	date := None[time.Time]()
+	if d, err := time.ParseInLocation("2006-01-02", req.ScheduleDay, location); err == nil {
+		date = Some(d)
+	}
+
+	weight, resp := ApplyTripUpdates(profile.GetTransit(), profile.GetStaticWeighting(schedule), profile.GetTrips(), profile.GetStopIDs(), updates, req.Feed, date, location)
+	resp.Schedule = schedule
+	profile.SetRealtimeWeighting(req.ScheduleDay, Some(weight))
+
+	slog.Info("Transit-Realtime reponse build")
+	return OK(resp)
+}
+
+//**********************************************************
+// realtime utilities
+//**********************************************************
+
+// Applies the trip-updates to a copy of the static schedule, the static schedule is not modified.
+//
+// Stop-time updates are matched by their stop_sequence or stop_id. Delays propagate along the trip until the next stop
+// with an update, stops before the first update keep their schedule. Canceled trips are removed.
+// Absolute times are converted into delays relative to midnight of the service-date in the given location.
+// Skipped stops are treated like stops without an update, added trips are not supported.
+func ApplyTripUpdates(transit *comps.Transit, static *comps.TransitWeighting, trips Array[structs.Trip], stop_ids Dict[string, int32], updates List[parser.GTFSTripUpdate], feed string, date Optional[time.Time], location *time.Location) (*comps.TransitWeighting, TransitRealtimeResponse) {
+	prefix := ""
+	if feed != "" {
+		prefix = feed + ":"
+	}
+	trip_mapping := NewDict[string, int32](trips.Length())
+	for i, trip := range trips {
+		trip_mapping[trip.ID] = int32(i)
+	}
+
+	// step 1: match updates to trips
+	resp := TransitRealtimeResponse{}
+	trip_updates := NewDict[int32, parser.GTFSTripUpdate](updates.Length())
+	for _, update := range updates {
+		if date.HasValue() && update.StartDate != "" && update.StartDate != date.Value.Format("20060102") {
+			continue
+		}
+		trip, ok := trip_mapping[prefix+update.TripID]
+		if !ok && update.StartTime != "" {
+			// trips expanded from frequencies.txt
+			if start, valid := parser.ParseGTFSTime(update.StartTime); valid {
+				trip, ok = trip_mapping[prefix+update.TripID+"@"+strconv.Itoa(start)]
+			}
+		}
+		if !ok || update.Relationship == 1 || update.Relationship == 2 {
+			resp.UnmatchedTrips += 1
+			continue
+		}
+		trip_updates[trip] = update
+	}
+
+	// step 2: collect the connections of all updated trips
+	trip_hops := NewDict[int32, List[Tuple[int32, int32]]](trip_updates.Length())
+	for c := 0; c < static.ConnectionCount(); c++ {
+		for i, w := range static.GetWeights(int32(c)) {
+			if !trip_updates.ContainsKey(w.Trip) {
+				continue
+			}
+			hops := trip_hops[w.Trip]
+			hops.Add(MakeTuple(int32(c), int32(i)))
+			trip_hops[w.Trip] = hops
+		}
+	}
+
+	// step 3: update the connections of every trip
+	weight := static.Copy()
+	modified := NewDict[int32, bool](100)
+	removed := NewDict[Tuple[int32, int32], bool](10)
+	get_weights := func(conn int32) []comps.ConnectionWeight {
+		if !modified.ContainsKey(conn) {
+			weight.SetWeights(conn, slices.Clone(static.GetWeights(conn)))
+			modified[conn] = true
+		}
+		return weight.GetWeights(conn)
+	}
+	var midnight int64
+	if date.HasValue() {
+		midnight = date.Value.Unix()
+	}
+	for trip, update := range trip_updates {
+		hops := trip_hops[trip]
+		if hops.Length() == 0 {
+			resp.UnmatchedTrips += 1
+			continue
+		}
+		// canceled or deleted
+		if update.Relationship == 3 || update.Relationship == 7 {
+			for _, hop := range hops {
+				get_weights(hop.A)
+				removed[hop] = true
+			}
+			resp.CanceledTrips += 1
+			continue
+		}
+		slices.SortFunc(hops, func(a, b Tuple[int32, int32]) int {
+			return int(static.GetWeights(a.A)[a.B].Departure - static.GetWeights(b.A)[b.B].Departure)
+		})
+		// midnight of the service-date is taken from the update if the schedule isn't bound to a date
+		trip_midnight := midnight
+		if !date.HasValue() {
+			trip_midnight = -1
+			if d, err := time.ParseInLocation("20060102", update.StartDate, location); err == nil {
+				trip_midnight = d.Unix()
+			}
+		}
+		_ApplyTripUpdate(transit, static, trips[trip].Stops, hops, update, prefix, stop_ids, trip_midnight, get_weights)
+		resp.UpdatedTrips += 1
+	}
+
+	// step 4: remove canceled trips and restore the ordering by departure
+	for conn := range modified {
+		weights := weight.GetWeights(conn)
+		new_weights := make([]comps.ConnectionWeight, 0, len(weights))
+		for i, w := range weights {
+			if removed.ContainsKey(MakeTuple(conn, int32(i))) {
+				continue
+			}
+			new_weights = append(new_weights, w)
+		}
+		slices.SortStableFunc(new_weights, func(a, b comps.ConnectionWeight) int {
+			return int(a.Departure - b.Departure)
+		})
+		weight.SetWeights(conn, new_weights)
+	}
+	return weight, resp
+}
+
+// Applies the delays of the update to the connections (hops) of the trip ordered by departure.
+//
+// midnight is the POSIX-time of the service-date (-1 if unknown, absolute times are ignored then).
+func _ApplyTripUpdate(transit *comps.Transit, static *comps.TransitWeighting, stops []structs.TripStop, hops List[Tuple[int32, int32]], update parser.GTFSTripUpdate, prefix string, stop_ids Dict[string, int32], midnight int64, get_weights func(int32) []comps.ConnectionWeight) {
+	// hops departing at and arriving at every stop of the trip
+	hop_from := NewArray[int](len(stops))
+	hop_to := NewArray[int](len(stops))
+	for k := range stops {
+		hop_from[k] = -1
+		hop_to[k] = -1
+	}
+	k := 0
+	for h, hop := range hops {
+		conn := transit.GetConnection(hop.A)
+		for k < len(stops)-1 && !(stops[k].Stop == conn.StopA && stops[k+1].Stop == conn.StopB) {
+			k += 1
+		}
+		if k >= len(stops)-1 {
+			break
+		}
+		hop_from[k] = h
+		hop_to[k+1] = h
+		k += 1
+	}
+	get_static := func(h int) comps.ConnectionWeight {
+		return static.GetWeights(hops[h].A)[hops[h].B]
+	}
+
+	// match stop-time updates to the stops of the trip
+	stop_updates := NewDict[int, parser.GTFSStopTimeUpdate](update.StopTimeUpdates.Length())
+	last := 0
+	for _, stop_update := range update.StopTimeUpdates {
+		index := -1
+		for i := 0; i < len(stops); i++ {
+			// search starts after the last match since stops may be served multiple times
+			k := (last + i) % len(stops)
+			if stop_update.StopSequence >= 0 {
+				if stops[k].Sequence == stop_update.StopSequence {
+					index = k
+					break
+				}
+			} else if stop, ok := stop_ids[prefix+stop_update.StopID]; ok && stops[k].Stop == stop {
+				index = k
+				break
+			}
+		}
+		if index == -1 {
+			continue
+		}
+		stop_updates[index] = stop_update
+		last = index
+	}
+
+	// converts the event into a delay relative to the scheduled time
+	get_delay := func(event Optional[parser.GTFSStopTimeEvent], scheduled int32) (int32, bool) {
+		if !event.HasValue() {
+			return 0, false
+		}
+		if event.Value.Delay.HasValue() {
+			return event.Value.Delay.Value, true
+		}
+		if event.Value.Time.HasValue() && midnight >= 0 {
+			return int32(event.Value.Time.Value - midnight - int64(scheduled)), true
+		}
+		return 0, false
+	}
+
+	// propagate delays along the trip
+	delay := int32(0)
+	prev_arrival := int32(-1000000000)
+	for k := 0; k < len(stops); k++ {
+		arrival_delay := delay
+		departure_delay := delay
+		if stop_update, ok := stop_updates[k]; ok {
+			if stop_update.Relationship == 2 {
+				// no data, the schedule is used until the next update
+				arrival_delay = 0
+				departure_delay = 0
+			} else {
+				if h := hop_to[k]; h != -1 {
+					if d, ok := get_delay(stop_update.Arrival, get_static(h).Arrival); ok {
+						arrival_delay = d
+						departure_delay = d
+					}
+				}
+				if h := hop_from[k]; h != -1 {
+					if d, ok := get_delay(stop_update.Departure, get_static(h).Departure); ok {
+						departure_delay = d
+					}
+				}
+			}
+			delay = departure_delay
+		}
+		if h := hop_to[k]; h != -1 {
+			weights := get_weights(hops[h].A)
+			w := weights[hops[h].B]
+			w.Arrival = max(get_static(h).Arrival+arrival_delay, w.Departure)
+			weights[hops[h].B] = w
+			prev_arrival = w.Arrival
+		}
+		if h := hop_from[k]; h != -1 {
+			weights := get_weights(hops[h].A)
+			w := weights[hops[h].B]
+			// vehicles can't depart before they arrived
+			w.Departure = max(get_static(h).Departure+departure_delay, prev_arrival)
+			weights[hops[h].B] = w
+		}
+		if hop_to[k] == -1 {
+			prev_arrival = -1000000000
+		}
+	}
+}
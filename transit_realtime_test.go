@@ -0,0 +1,137 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ttpr0/go-routing/comps"
+	"github.com/ttpr0/go-routing/parser"
+	"github.com/ttpr0/go-routing/structs"
+	. "github.com/ttpr0/go-routing/util"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Creates the line A-B-C (stops 0-2) served by trip T1 at 100 and T2 at 1000, every hop takes 100s with 50s dwell-time.
+func create_realtime_profile() *TransitProfile {
+	stops := Array[structs.Node]{{}, {}, {}}
+	connections := Array[structs.Connection]{{StopA: 0, StopB: 1}, {StopA: 1, StopB: 2}}
+	transit := comps.NewTransit(structs.NewIdendityMapping(3), stops, connections, structs.NewShortcutStore(0, false), NewArray[int32](3))
+	weight := comps.NewTransitWeighting(transit)
+	weight.SetWeights(0, []comps.ConnectionWeight{{Departure: 100, Arrival: 200, Trip: 0}, {Departure: 1000, Arrival: 1100, Trip: 1}})
+	weight.SetWeights(1, []comps.ConnectionWeight{{Departure: 250, Arrival: 350, Trip: 0}, {Departure: 1150, Arrival: 1250, Trip: 1}})
+	trip_stops := []structs.TripStop{{Stop: 0, Sequence: 1}, {Stop: 1, Sequence: 2}, {Stop: 2, Sequence: 3}}
+	return &TransitProfile{
+		transit:         transit,
+		transit_weights: Dict[string, *comps.TransitWeighting]{"monday": weight},
+		trips:           Array[structs.Trip]{{ID: "T1", Stops: trip_stops}, {ID: "T2", Stops: trip_stops}},
+		stop_ids:        Dict[string, int32]{"A": 0, "B": 1, "C": 2},
+	}
+}
+
+func TestRealtimeWeightingScheduleDay(t *testing.T) {
+	profile := create_realtime_profile()
+	static := profile.GetStaticWeighting("monday")
+	realtime := static.Copy()
+	realtime.SetWeights(0, []comps.ConnectionWeight{{Departure: 160, Arrival: 260, Trip: 0}})
+
+	// 2024-05-06 and 2024-05-13 are mondays
+	profile.SetRealtimeWeighting("2024-05-06", Some(realtime))
+	tests := []struct {
+		name     string
+		day      string
+		expected *comps.TransitWeighting
+	}{
+		{"updated date", "2024-05-06", realtime},
+		{"other date", "2024-05-13", static},
+		{"weekday", "monday", static},
+	}
+	for _, tt := range tests {
+		weight := profile.GetTransitWeighting(tt.day)
+		if !weight.HasValue() || weight.Value != tt.expected {
+			t.Errorf("%v: got the wrong schedule for %v", tt.name, tt.day)
+		}
+	}
+	if weight := profile.GetTransitWeighting("2024-05-07"); weight.HasValue() {
+		t.Errorf("expected no schedule for a tuesday")
+	}
+
+	profile.SetRealtimeWeighting("2024-05-06", None[*comps.TransitWeighting]())
+	if weight := profile.GetTransitWeighting("2024-05-06"); weight.Value != static {
+		t.Errorf("expected the static schedule after the reset")
+	}
+}
+
+// Encodes a FeedEntity with a trip-update, every stop-time update is given as [stop_sequence, arrival-delay].
+func encode_feed_entity(trip_id string, relationship uint64, deleted bool, stop_updates ...[2]int64) []byte {
+	var trip []byte
+	trip = protowire.AppendTag(trip, 1, protowire.BytesType)
+	trip = protowire.AppendString(trip, trip_id)
+	trip = protowire.AppendTag(trip, 4, protowire.VarintType)
+	trip = protowire.AppendVarint(trip, relationship)
+	var trip_update []byte
+	trip_update = protowire.AppendTag(trip_update, 1, protowire.BytesType)
+	trip_update = protowire.AppendBytes(trip_update, trip)
+	for _, u := range stop_updates {
+		var event []byte
+		event = protowire.AppendTag(event, 1, protowire.VarintType)
+		event = protowire.AppendVarint(event, uint64(u[1]))
+		var stop_update []byte
+		stop_update = protowire.AppendTag(stop_update, 1, protowire.VarintType)
+		stop_update = protowire.AppendVarint(stop_update, uint64(u[0]))
+		stop_update = protowire.AppendTag(stop_update, 2, protowire.BytesType)
+		stop_update = protowire.AppendBytes(stop_update, event)
+		trip_update = protowire.AppendTag(trip_update, 2, protowire.BytesType)
+		trip_update = protowire.AppendBytes(trip_update, stop_update)
+	}
+	var entity []byte
+	entity = protowire.AppendTag(entity, 1, protowire.BytesType)
+	entity = protowire.AppendString(entity, trip_id)
+	if deleted {
+		entity = protowire.AppendTag(entity, 2, protowire.VarintType)
+		entity = protowire.AppendVarint(entity, 1)
+	}
+	entity = protowire.AppendTag(entity, 3, protowire.BytesType)
+	entity = protowire.AppendBytes(entity, trip_update)
+	return entity
+}
+
+func TestApplyTripUpdates(t *testing.T) {
+	profile := create_realtime_profile()
+	static := profile.GetStaticWeighting("monday")
+
+	var feed []byte
+	for _, entity := range [][]byte{
+		// T1 arrives 30s early at B, the delay propagates to C
+		encode_feed_entity("T1", 0, false, [2]int64{2, -30}),
+		// deleted entities are ignored
+		encode_feed_entity("T1", 0, true, [2]int64{2, 600}),
+		encode_feed_entity("T2", 3, false),
+		encode_feed_entity("T9", 0, false, [2]int64{2, 60}),
+	} {
+		feed = protowire.AppendTag(feed, 2, protowire.BytesType)
+		feed = protowire.AppendBytes(feed, entity)
+	}
+	updates, err := parser.ParseTripUpdates(feed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	weight, resp := ApplyTripUpdates(profile.GetTransit(), static, profile.GetTrips(), profile.GetStopIDs(), updates, "", None[time.Time](), time.UTC)
+	if resp.UpdatedTrips != 1 || resp.CanceledTrips != 1 || resp.UnmatchedTrips != 1 {
+		t.Errorf("expected 1 updated, 1 canceled and 1 unmatched trip, got %+v", resp)
+	}
+	expected := [][]comps.ConnectionWeight{
+		{{Departure: 100, Arrival: 170, Trip: 0}},
+		{{Departure: 220, Arrival: 320, Trip: 0}},
+	}
+	for conn, e := range expected {
+		if w := weight.GetWeights(int32(conn)); !slices.Equal(w, e) {
+			t.Errorf("connection %v: expected %v, got %v", conn, e, w)
+		}
+	}
+	// the static schedule is not modified
+	if w := static.GetWeights(0); len(w) != 2 || w[0].Arrival != 200 {
+		t.Errorf("static schedule modified: %v", w)
+	}
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/ttpr0/go-routing/attr"
+	"github.com/ttpr0/go-routing/geo"
+	"github.com/ttpr0/go-routing/graph"
+	"github.com/ttpr0/go-routing/routing"
+	"github.com/ttpr0/go-routing/structs"
+	. "github.com/ttpr0/go-routing/util"
+	"golang.org/x/exp/slog"
+)
+
+//**********************************************************
+// transit route request and response
+//**********************************************************
+
+type TransitRouteRequest struct {
+	Origin      geo.Coord `json:"origin"`
+	Destination geo.Coord `json:"destination"`
+	Profile     string    `json:"profile"`
+	// weekday (e.g. "monday") or ISO-date (e.g. "2024-05-01") of travel
+	Date string `json:"date"`
+	// departure time at the origin (in s since midnight)
+	DepartureTime int32 `json:"departure_time"`
+	// maximum number of transfers between rides (uses RAPTOR if set)
+	MaxTransfers *int32 `json:"max_transfers"`
+}
+
+// GeoJSON feature-collection containing one feature per leg of the journey.
+type TransitRouteResponse struct {
+	Type      string        `json:"type"`
+	Departure int32         `json:"departure"`
+	Arrival   int32         `json:"arrival"`
+	Transfers int           `json:"transfers"`
+	Features  []geo.Feature `json:"features"`
+}
+
+// Pareto set of journeys ordered by ascending number of transfers.
+type TransitJourneysResponse struct {
+	Journeys []TransitRouteResponse `json:"journeys"`
+}
+
+//**********************************************************
+// transit route handler
+//**********************************************************
+
+func HandleTransitRouteRequest(req TransitRouteRequest) Result {
+	slog.Info("Run Transit-Route Request")
+
+	task_, res := _PrepareTransitRouteTask(req)
+	if !task_.HasValue() {
+		return res
+	}
+	task := task_.Value
+	var steps List[routing.TransitPathStep]
+	if req.MaxTransfers != nil {
+		slog.Info("Using RAPTOR")
+		alg := routing.NewRAPTOR(task.g, task.start, task.end, req.DepartureTime, *req.MaxTransfers, 100000000)
+		if !alg.CalcJourneys() {
+			return BadRequest("No route found")
+		}
+		// the last journey of the pareto set arrives earliest
+		journeys := alg.GetJourneys()
+		steps = journeys[journeys.Length()-1].Steps
+	} else {
+		alg := routing.NewTransitDijkstra(task.g, task.start, task.end, req.DepartureTime)
+		if !alg.CalcShortestPath() {
+			return BadRequest("No route found")
+		}
+		steps = alg.GetTransitPath()
+	}
+	resp := _BuildTransitRouteResponse(steps, req.DepartureTime, task)
+	slog.Info("Transit-Route reponse build")
+	return OK(resp)
+}
+
+func HandleTransitJourneysRequest(req TransitRouteRequest) Result {
+	slog.Info("Run Transit-Journeys Request")
+
+	task_, res := _PrepareTransitRouteTask(req)
+	if !task_.HasValue() {
+		return res
+	}
+	task := task_.Value
+	max_transfers := int32(5)
+	if req.MaxTransfers != nil {
+		max_transfers = *req.MaxTransfers
+	}
+	alg := routing.NewRAPTOR(task.g, task.start, task.end, req.DepartureTime, max_transfers, 100000000)
+	if !alg.CalcJourneys() {
+		return BadRequest("No route found")
+	}
+	journeys := make([]TransitRouteResponse, 0, 4)
+	for _, journey := range alg.GetJourneys() {
+		journeys = append(journeys, _BuildTransitRouteResponse(journey.Steps, req.DepartureTime, task))
+	}
+	resp := TransitJourneysResponse{Journeys: journeys}
+	slog.Info("Transit-Journeys reponse build")
+	return OK(resp)
+}
+
+type _TransitRouteTask struct {
+	g      *graph.TransitGraph
+	att    attr.IAttributes
+	routes Array[structs.Route]
+	start  int32
+	end    int32
+}
+
+func _PrepareTransitRouteTask(req TransitRouteRequest) (Optional[_TransitRouteTask], Result) {
+	if req.Profile == "" {
+		req.Profile = "transit-foot"
+	}
+	if req.Date == "" {
+		req.Date = "monday"
+	}
+	if req.MaxTransfers != nil && *req.MaxTransfers < 0 {
+		return None[_TransitRouteTask](), BadRequest("max_transfers must not be negative")
+	}
+	profile_, res := GetRequestProfile(MANAGER, req.Profile, "time")
+	if !profile_.HasValue() {
+		return None[_TransitRouteTask](), res
+	}
+	profile, ok := profile_.Value.(*TransitProfile)
+	if !ok {
+		return None[_TransitRouteTask](), BadRequest("Profile is not a public-transit profile")
+	}
+	g_ := profile.GetTransitGraph(req.Date)
+	if !g_.HasValue() {
+		return None[_TransitRouteTask](), BadRequest("Schedule not found")
+	}
+	att := profile.GetAttributes()
+	start, ok := att.GetClosestNode(req.Origin)
+	if !ok {
+		return None[_TransitRouteTask](), BadRequest("Origin could not be mapped to the network")
+	}
+	end, ok := att.GetClosestNode(req.Destination)
+	if !ok {
+		return None[_TransitRouteTask](), BadRequest("Destination could not be mapped to the network")
+	}
+	return Some(_TransitRouteTask{
+		g:      g_.Value,
+		att:    att,
+		routes: profile.GetRoutes(),
+		start:  start,
+		end:    end,
+	}), OK("")
+}
+
+func _BuildTransitRouteResponse(steps List[routing.TransitPathStep], departure int32, task _TransitRouteTask) TransitRouteResponse {
+	legs := _BuildTransitLegs(steps)
+	features := make([]geo.Feature, 0, legs.Length())
+	transfers := 0
+	rides := 0
+	for _, leg := range legs {
+		if leg.typ == "ride" {
+			rides += 1
+		}
+		features = append(features, _BuildTransitLegFeature(leg, task.g, task.att, task.routes))
+	}
+	if rides > 1 {
+		transfers = rides - 1
+	}
+	resp := TransitRouteResponse{
+		Type:      "FeatureCollection",
+		Departure: departure,
+		Arrival:   departure,
+		Transfers: transfers,
+		Features:  features,
+	}
+	if legs.Length() > 0 {
+		last_steps := legs[legs.Length()-1].steps
+		resp.Arrival = last_steps[len(last_steps)-1].Arrival
+	}
+	return resp
+}
+
+//**********************************************************
+// transit legs
+//**********************************************************
+
+type _TransitLeg struct {
+	// one of ["walk", "ride", "transfer"]
+	typ   string
+	steps []routing.TransitPathStep
+}
+
+// Groups the steps of the path into legs.
+//
+// Consecutive connections of the same trip form a ride, walks between two rides are transfers.
+func _BuildTransitLegs(steps List[routing.TransitPathStep]) List[_TransitLeg] {
+	legs := NewList[_TransitLeg](4)
+	for _, step := range steps {
+		if legs.Length() > 0 {
+			last := &legs[legs.Length()-1]
+			last_step := last.steps[len(last.steps)-1]
+			if !step.IsConnection && last.typ != "ride" {
+				last.steps = append(last.steps, step)
+				continue
+			}
+			if step.IsConnection && last.typ == "ride" && last_step.Trip == step.Trip {
+				last.steps = append(last.steps, step)
+				continue
+			}
+		}
+		if step.IsConnection {
+			legs.Add(_TransitLeg{typ: "ride", steps: []routing.TransitPathStep{step}})
+		} else {
+			legs.Add(_TransitLeg{typ: "walk", steps: []routing.TransitPathStep{step}})
+		}
+	}
+	// walks between rides are transfers
+	last_ride := -1
+	for i, leg := range legs {
+		if leg.typ != "ride" {
+			continue
+		}
+		if last_ride != -1 {
+			for j := last_ride + 1; j < i; j++ {
+				legs[j].typ = "transfer"
+			}
+		}
+		last_ride = i
+	}
+	return legs
+}
+
+func _BuildTransitLegFeature(leg _TransitLeg, g *graph.TransitGraph, att attr.IAttributes, routes Array[structs.Route]) geo.Feature {
+	first := leg.steps[0]
+	last := leg.steps[len(leg.steps)-1]
+	props := NewDict[string, any](8)
+	props["type"] = leg.typ
+	props["departure"] = first.Departure
+	props["arrival"] = last.Arrival
+	props["duration"] = last.Arrival - first.Departure
+
+	line := make(geo.CoordArray, 0, 10)
+	if leg.typ == "ride" {
+		first_conn := g.GetConnection(first.Ref.EdgeID)
+		last_conn := g.GetConnection(last.Ref.EdgeID)
+		line = append(line, g.GetStop(first_conn.StopA).Loc)
+		for _, step := range leg.steps {
+			conn := g.GetConnection(step.Ref.EdgeID)
+			line = append(line, g.GetStop(conn.StopB).Loc)
+		}
+		props["from_stop"] = first_conn.StopA
+		props["to_stop"] = last_conn.StopB
+		props["stops"] = len(leg.steps) + 1
+		route_id := first_conn.RouteID
+		if int(route_id) < routes.Length() {
+			route := routes[route_id]
+			props["route_id"] = route.ID
+			props["route_short_name"] = route.ShortName
+			props["route_long_name"] = route.LongName
+			props["route_type"] = route.Type
+			props["agency"] = route.Agency
+		} else {
+			props["route_id"] = fmt.Sprint(route_id)
+		}
+	} else {
+		for _, step := range leg.steps {
+			if step.Ref.IsShortcut() {
+				// transfers between stops consist of the edges walked
+				has_edges := false
+				g.GetEdgesFromShortcut(step.Ref.EdgeID, func(edge int32) {
+					has_edges = true
+					line = _AppendLineGeom(line, att.GetEdgeGeom(edge))
+				})
+				if !has_edges {
+					line = _AppendLineGeom(line, geo.CoordArray{g.GetNodeGeom(step.From), g.GetNodeGeom(step.To)})
+				}
+			} else {
+				line = _AppendLineGeom(line, att.GetEdgeGeom(step.Ref.EdgeID))
+			}
+		}
+	}
+	geom := geo.NewLineString(line)
+	return geo.NewFeature(&geom, props)
+}
+
+// Appends the geometry to the line skipping its first coordinate if the line is not empty.
+func _AppendLineGeom(line geo.CoordArray, geom geo.CoordArray) geo.CoordArray {
+	if len(line) > 0 && len(geom) > 0 {
+		geom = geom[1:]
+	}
+	return append(line, geom...)
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"slices"
+	"time"
+
+	"github.com/ttpr0/go-routing/comps"
+	"github.com/ttpr0/go-routing/geo"
+	"github.com/ttpr0/go-routing/graph"
+	"github.com/ttpr0/go-routing/preproc"
+	"github.com/ttpr0/go-routing/structs"
+	. "github.com/ttpr0/go-routing/util"
+	"golang.org/x/exp/slog"
+)
+
+//**********************************************************
+// transit scenario request and response
+//**********************************************************
+
+// Hypothetical lines added to a public-transit profile.
+type TransitScenarioRequest struct {
+	Profile string                `json:"profile"`
+	Lines   []TransitScenarioLine `json:"lines"`
+}
+
+// Frequency-based line running from the first to the last stop.
+type TransitScenarioLine struct {
+	Name  string      `json:"name"`
+	Stops []geo.Coord `json:"stops"`
+	// running times (in s) between consecutive stops
+	RunningTimes []int32 `json:"running_times"`
+	// time (in s) spent at every intermediate stop
+	DwellTime int32 `json:"dwell_time"`
+	// time (in s) between two departures
+	Headway int32 `json:"headway"`
+	// first and last departure at the first stop (in s since midnight)
+	StartTime int32 `json:"start_time"`
+	EndTime   int32 `json:"end_time"`
+	// if true the line also runs in the opposite direction
+	Bidirectional bool `json:"bidirectional"`
+	// schedules the line runs on (weekdays or ISO-dates, defaults to all)
+	Schedules []string `json:"schedules"`
+}
+
+type TransitScenarioResponse struct {
+	ID      string `json:"id"`
+	Profile string `json:"profile"`
+	// number of stops and connections added
+	Stops       int `json:"stops"`
+	Connections int `json:"connections"`
+	// scenarios are removed once expired (or evicted earlier if too many scenarios exist)
+	Created time.Time `json:"created"`
+	Expires time.Time `json:"expires"`
+}
+
+//**********************************************************
+// transit scenario handler
+//**********************************************************
+
+func HandleCreateScenarioRequest(req TransitScenarioRequest) Result {
+	slog.Info("Run Create-Scenario Request")
+
+	if req.Profile == "" {
+		req.Profile = "transit-foot"
+	}
+	if len(req.Lines) == 0 {
+		return BadRequest("No lines given")
+	}
+	profile_, res := GetRequestProfile(MANAGER, req.Profile, "time")
+	if !profile_.HasValue() {
+		return res
+	}
+	profile, ok := profile_.Value.(*TransitProfile)
+	if !ok {
+		return BadRequest("Profile is not a public-transit profile")
+	}
+	scenario_, res := BuildTransitScenario(profile, req.Lines)
+	if !scenario_.HasValue() {
+		return res
+	}
+	scenario := scenario_.Value
+	ttl, max_count := _GetScenarioLimits(MANAGER._GetServiceConfig())
+	scenario.info.ID = _NewJobID()
+	scenario.info.Profile = req.Profile
+	scenario.info.Created = time.Now()
+	scenario.info.Expires = scenario.info.Created.Add(ttl)
+	profile.SetScenario(scenario.info.ID, scenario, max_count)
+
+	slog.Info("Create-Scenario reponse build")
+	return OK(scenario.info)
+}
+
+func HandleGetScenarioRequest(id string) Result {
+	scenario := _FindScenario(id)
+	if !scenario.HasValue() {
+		return NotFound("Scenario not found")
+	}
+	return OK(scenario.Value.B.info)
+}
+
+func HandleDeleteScenarioRequest(id string) Result {
+	scenario := _FindScenario(id)
+	if !scenario.HasValue() {
+		return NotFound("Scenario not found")
+	}
+	scenario.Value.A.RemoveScenario(id)
+	return OK(scenario.Value.B.info)
+}
+
+// Returns the scenario and the profile it belongs to.
+func _FindScenario(id string) Optional[Tuple[*TransitProfile, *TransitScenario]] {
+	for _, name := range MANAGER.GetProfiles() {
+		profile, ok := MANAGER.GetProfile(name).Value.(*TransitProfile)
+		if !ok {
+			continue
+		}
+		scenario := profile.GetScenario(id)
+		if scenario.HasValue() {
+			return Some(MakeTuple(profile, scenario.Value))
+		}
+	}
+	return None[Tuple[*TransitProfile, *TransitScenario]]()
+}
+
+// Returns the transit-graph of a request, the scenario is used if given.
+//
+// The graph is None if the profile has no transit-graph (e.g. driving profiles).
+func GetRequestTransitGraph(profile IRoutingProfile, scenario string, schedule string) (Optional[*graph.TransitGraph], Result) {
+	if scenario == "" {
+		return profile.GetTransitGraph(schedule), OK("")
+	}
+	p, ok := profile.(*TransitProfile)
+	if !ok {
+		return None[*graph.TransitGraph](), BadRequest("Scenarios are only supported for public-transit profiles")
+	}
+	if scenario_ := p.GetScenario(scenario); !scenario_.HasValue() {
+		return None[*graph.TransitGraph](), BadRequest("Scenario not found")
+	}
+	g := p.GetScenarioTransitGraph(scenario, schedule)
+	if !g.HasValue() {
+		return None[*graph.TransitGraph](), BadRequest("Schedule not found")
+	}
+	return g, OK("")
+}
+
+//**********************************************************
+// transit scenario
+//**********************************************************
+
+// Transit-data and schedules of a profile extended by hypothetical lines.
+type TransitScenario struct {
+	transit         *comps.Transit
+	transit_weights Dict[string, *comps.TransitWeighting]
+	info            TransitScenarioResponse
+}
+
+func (self *TransitScenario) IsExpired(now time.Time) bool {
+	return !self.info.Expires.IsZero() && !now.Before(self.info.Expires)
+}
+
+// Returns the time scenarios are kept and the maximum number of scenarios per profile.
+//
+// Defaults to one day and 10 scenarios.
+func _GetScenarioLimits(config Config) (time.Duration, int) {
+	ttl := config.Services.Scenarios.TTL
+	if ttl <= 0 {
+		ttl = 86400
+	}
+	max_count := config.Services.Scenarios.MaxCount
+	if max_count <= 0 {
+		max_count = 10
+	}
+	return time.Duration(ttl) * time.Second, max_count
+}
+
+// Adds the lines to a copy of the transit-data and the static schedules of the profile.
+//
+// Stops of the lines mapped to the node of an existing stop are merged into it, all others are added as new stops.
+// Real-time updates of the profile are not part of the scenario.
+func BuildTransitScenario(profile *TransitProfile, lines []TransitScenarioLine) (Optional[*TransitScenario], Result) {
+	transit := profile.GetTransit()
+	for _, line := range lines {
+		if len(line.Stops) < 2 {
+			return None[*TransitScenario](), BadRequest("Lines need at least two stops")
+		}
+		if len(line.RunningTimes) != len(line.Stops)-1 {
+			return None[*TransitScenario](), BadRequest("Lines need one running time between every two consecutive stops")
+		}
+		for _, t := range line.RunningTimes {
+			if t < 0 {
+				return None[*TransitScenario](), BadRequest("Running times must not be negative")
+			}
+		}
+		if line.Headway <= 0 {
+			return None[*TransitScenario](), BadRequest("Headway must be positive")
+		}
+		if line.DwellTime < 0 || line.EndTime < line.StartTime {
+			return None[*TransitScenario](), BadRequest("Invalid dwell_time or service span")
+		}
+		for _, schedule := range line.Schedules {
+			if _, ok := profile.GetSchedule(schedule); !ok {
+				return None[*TransitScenario](), BadRequest("Schedule not found: " + schedule)
+			}
+		}
+	}
+
+	// step 1: map the stops of the lines to existing or new stops
+	att := profile.GetAttributes()
+	stops := NewList[structs.Node](10)
+	stop_nodes := NewList[int32](10)
+	node_stops := NewDict[int32, int32](10)
+	line_stops := make([][]int32, len(lines))
+	for l, line := range lines {
+		line_stops[l] = make([]int32, len(line.Stops))
+		for i, coord := range line.Stops {
+			node, ok := att.GetClosestNode(coord)
+			if !ok {
+				node = -1
+			}
+			if node != -1 && transit.MapNodeToStop(node) != -1 {
+				line_stops[l][i] = transit.MapNodeToStop(node)
+				continue
+			}
+			if node != -1 && node_stops.ContainsKey(node) {
+				line_stops[l][i] = node_stops[node]
+				continue
+			}
+			stop := int32(transit.StopCount() + stops.Length())
+			stops.Add(structs.Node{Loc: coord})
+			stop_nodes.Add(node)
+			if node != -1 {
+				node_stops[node] = stop
+			}
+			line_stops[l][i] = stop
+		}
+	}
+
+	// step 2: build the connections and weights of every direction
+	next_trip := int32(0)
+	for _, weight := range profile.transit_weights {
+		for c := 0; c < weight.ConnectionCount(); c++ {
+			for _, w := range weight.GetWeights(int32(c)) {
+				next_trip = max(next_trip, w.Trip+1)
+			}
+		}
+	}
+	connections := NewList[structs.Connection](10)
+	conn_weights := NewList[List[comps.ConnectionWeight]](10)
+	conn_schedules := NewList[[]string](10)
+	for l, line := range lines {
+		// route-ids of the lines follow the routes of the profile
+		route_id := int32(len(profile.GetRoutes()) + l)
+		directions := 1
+		if line.Bidirectional {
+			directions = 2
+		}
+		for d := 0; d < directions; d++ {
+			l_stops := slices.Clone(line_stops[l])
+			running_times := slices.Clone(line.RunningTimes)
+			if d == 1 {
+				slices.Reverse(l_stops)
+				slices.Reverse(running_times)
+			}
+			hops := NewList[int32](len(l_stops) - 1)
+			for k := 0; k < len(l_stops)-1; k++ {
+				if l_stops[k] == l_stops[k+1] {
+					hops.Add(-1)
+					continue
+				}
+				hops.Add(int32(connections.Length()))
+				connections.Add(structs.Connection{StopA: l_stops[k], StopB: l_stops[k+1], RouteID: route_id})
+				conn_weights.Add(NewList[comps.ConnectionWeight](10))
+				conn_schedules.Add(line.Schedules)
+			}
+			for start := line.StartTime; start <= line.EndTime; start += line.Headway {
+				departure := start
+				for k, hop := range hops {
+					arrival := departure + running_times[k]
+					if hop != -1 {
+						conn_weights[hop].Add(comps.ConnectionWeight{Departure: departure, Arrival: arrival, Trip: next_trip})
+					}
+					departure = arrival + line.DwellTime
+				}
+				next_trip += 1
+			}
+		}
+	}
+
+	// step 3: extend the transit-data and schedules
+	g := profile.GetGraph().Value
+	new_transit := preproc.ExtendTransit(g, transit, Array[structs.Node](stops), Array[int32](stop_nodes), Array[structs.Connection](connections), profile.max_transfer_range, profile.min_transfer_time, profile.transfer_penalty)
+	transit_weights := NewDict[string, *comps.TransitWeighting](len(profile.transit_weights))
+	for name, static := range profile.transit_weights {
+		weight := comps.NewTransitWeighting(new_transit)
+		for c := 0; c < static.ConnectionCount(); c++ {
+			weight.SetWeights(int32(c), static.GetWeights(int32(c)))
+		}
+		for c := range connections {
+			if !_RunsOnSchedule(profile, conn_schedules[c], name) {
+				continue
+			}
+			weight.SetWeights(int32(static.ConnectionCount()+c), conn_weights[c])
+		}
+		transit_weights[name] = weight
+	}
+
+	return Some(&TransitScenario{
+		transit:         new_transit,
+		transit_weights: transit_weights,
+		info: TransitScenarioResponse{
+			Stops:       stops.Length(),
+			Connections: connections.Length(),
+		},
+	}), OK("")
+}
+
+// Checks if a line running on the schedules (all if empty) runs on the built schedule.
+func _RunsOnSchedule(profile *TransitProfile, schedules []string, name string) bool {
+	if len(schedules) == 0 {
+		return true
+	}
+	for _, schedule := range schedules {
+		if s, ok := profile.GetSchedule(schedule); ok && s == name {
+			return true
+		}
+	}
+	return false
+}
@@ -1,11 +1,15 @@
 package main
 
 import (
+	"fmt"
+	"hash/fnv"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/ttpr0/go-routing/attr"
 	"github.com/ttpr0/go-routing/geo"
+	"github.com/ttpr0/go-routing/graph"
 	"github.com/ttpr0/go-routing/parser"
 	. "github.com/ttpr0/go-routing/util"
 )
@@ -64,6 +68,12 @@ func MapCoordsToNodes(att attr.IAttributes, coords []geo.Coord) Array[int32] {
 	return nodes
 }
 
+// Checks if the graph has turn-costs, node-based searches would ignore them.
+func HasTurnCosts(g graph.IGraph) bool {
+	_, ok := g.(*graph.TCGraph)
+	return ok
+}
+
 func GetDecoder(typ ProfileType) parser.IOSMDecoder {
 	var decoder parser.IOSMDecoder
 	switch typ {
@@ -76,3 +86,22 @@ func GetDecoder(typ ProfileType) parser.IOSMDecoder {
 	}
 	return decoder
 }
+
+// Returns the decoder of the profile and the name of the graph parsed by it.
+//
+// Graphs of decoder files are named after the type, the file and a hash of its path (e.g. "driving-truck-1a2b3c4d")
+// to keep them apart from the default graphs and from files of the same name in other directories.
+func GetProfileDecoder(typ ProfileType, decoder_file string) (string, parser.IOSMDecoder) {
+	if decoder_file == "" {
+		return typ.String(), GetDecoder(typ)
+	}
+	path, err := filepath.Abs(decoder_file)
+	if err != nil {
+		path = filepath.Clean(decoder_file)
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(path))
+	name := strings.TrimSuffix(filepath.Base(decoder_file), filepath.Ext(decoder_file))
+	config := parser.ReadDecoderConfig(decoder_file)
+	return fmt.Sprintf("%v-%v-%08x", typ, name, hash.Sum32()), parser.NewConfigDecoder(config)
+}
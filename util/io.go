@@ -10,6 +10,7 @@ import (
 	"os"
 	"reflect"
 	"strconv"
+	"strings"
 )
 
 func NewBufferReader(data []byte) BufferReader {
@@ -136,7 +137,18 @@ func ReadCSVFromFile[T any](filename string, delimiter rune) func(yield func(T)
 		}
 		defer file.Close()
 
-		reader := csv.NewReader(file)
+		for value := range ReadCSVFromReader[T](file, delimiter) {
+			if !yield(value) {
+				break
+			}
+		}
+	}
+}
+
+// Reads the rows of a csv-stream with a header into values of T (fields are matched by their csv-tag).
+func ReadCSVFromReader[T any](r io.Reader, delimiter rune) func(yield func(T) bool) {
+	return func(yield func(T) bool) {
+		reader := csv.NewReader(r)
 		reader.Comma = delimiter
 		header, err := reader.Read()
 		if err != nil {
@@ -144,6 +156,10 @@ func ReadCSVFromFile[T any](filename string, delimiter rune) func(yield func(T)
 		}
 		name_row_mapping := NewDict[string, int](10)
 		for i, name := range header {
+			// strip utf-8 byte-order-mark
+			if i == 0 {
+				name = strings.TrimPrefix(name, "\uFEFF")
+			}
 			name_row_mapping[name] = i
 		}
 
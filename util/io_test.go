@@ -1,6 +1,7 @@
 package util
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -62,3 +63,26 @@ func TestCSVError(t *testing.T) {
 		i++
 	}
 }
+
+func TestCSVReader(t *testing.T) {
+	data := "\uFEFFname,age,height,gender\nJohn,30,170,false\nJane,25,160,true\n"
+
+	i := 0
+	for row := range ReadCSVFromReader[CVSSimpleTest](strings.NewReader(data), ',') {
+		if i == 0 {
+			if row.Name != "John" || row.Age != 30 || row.Height != 170 || row.Gender != false {
+				t.Errorf("row.Name = %v; want John", row.Name)
+			}
+		} else if i == 1 {
+			if row.Name != "Jane" || row.Age != 25 || row.Height != 160 || row.Gender != true {
+				t.Errorf("row.Name = %v; want Jane", row.Name)
+			}
+		} else {
+			t.Errorf("too many rows")
+		}
+		i++
+	}
+	if i != 2 {
+		t.Errorf("read %v rows; want 2", i)
+	}
+}
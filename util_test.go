@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProfileDecoderNames(t *testing.T) {
+	dir := t.TempDir()
+	decoder := "highways:\n  primary: 60\naccess: [\"access\", \"hgv\"]\n"
+	for _, sub := range []string{"a", "b"} {
+		os.Mkdir(filepath.Join(dir, sub), 0755)
+		os.WriteFile(filepath.Join(dir, sub, "truck.yml"), []byte(decoder), 0644)
+	}
+
+	name_a, _ := GetProfileDecoder(DRIVING, filepath.Join(dir, "a", "truck.yml"))
+	name_b, _ := GetProfileDecoder(DRIVING, filepath.Join(dir, "b", "truck.yml"))
+	if !strings.HasPrefix(name_a, "driving-truck-") {
+		t.Errorf("expected graph name driving-truck-<hash>, got %v", name_a)
+	}
+	if name_a == name_b {
+		t.Errorf("decoder files of different directories share the graph %v", name_a)
+	}
+	name_c, _ := GetProfileDecoder(DRIVING, filepath.Join(dir, "b", "..", "a", "truck.yml"))
+	if name_a != name_c {
+		t.Errorf("expected the same file to share the graph, got %v and %v", name_a, name_c)
+	}
+	name_d, _ := GetProfileDecoder(DRIVING, "")
+	if name_d != "driving" {
+		t.Errorf("expected default graph driving, got %v", name_d)
+	}
+}